@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ibrahim/remote-vibecode/internal/client"
+	"github.com/ibrahim/remote-vibecode/internal/relay"
+)
+
+var (
+	agentRelayURL       string
+	agentName           string
+	agentTLSFingerprint string
+)
+
+var AgentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Connect the local rvc server to a relay",
+	Long: `Connect the rvc server (--server, $RVC_SERVER or the local server) to an
+'rvc relay', so its sessions show up on the relay's dashboard even though
+the relay cannot connect to this machine, e.g. behind NAT.
+
+The agent dials out to the relay and keeps a single connection open,
+reconnecting when it drops. The relay reaches the server's terminals and
+session API through it, nothing else, with the server's access token
+(auth.token or --token). Agent and relay prove to each other
+that they know the agent's secret; set relay.connect in the config (or
+RVC_RELAY_URL, RVC_RELAY_NAME and RVC_RELAY_SECRET), e.g. with the
+snippet printed by 'rvc relay new-agent NAME' on the relay.
+
+The agent keeps running while the server restarts; start the server with
+'rvc serve --daemon'.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runAgent,
+}
+
+func init() {
+	AgentCmd.Flags().StringVar(&agentRelayURL, "relay", "", "URL of the relay (default relay.connect.url or $RVC_RELAY_URL)")
+	AgentCmd.Flags().StringVar(&agentName, "name", "", "Name of this agent on the relay (default relay.connect.name or $RVC_RELAY_NAME)")
+	AgentCmd.Flags().StringVar(&agentTLSFingerprint, "tls-fingerprint", "", "Hex SHA-256 of the relay's certificate, to trust a self-signed one")
+}
+
+func runAgent(cmd *cobra.Command, args []string) error {
+	cfg, _, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	conn := cfg.Relay.Connect
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		switch f.Name {
+		case "relay":
+			conn.URL = agentRelayURL
+		case "name":
+			conn.Name = agentName
+		case "tls-fingerprint":
+			conn.TLSFingerprint = agentTLSFingerprint
+		}
+	})
+	switch {
+	case conn.URL == "":
+		return errors.New("no relay is configured; set relay.connect.url or pass --relay")
+	case conn.Name == "" || conn.Secret == "":
+		return errors.New("the agent needs a name and secret; set relay.connect.name and relay.connect.secret (or $RVC_RELAY_SECRET)")
+	}
+	cfg.Relay.Connect = conn
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	if u, _ := url.Parse(conn.URL); u.Scheme == "http" && !isLoopbackHost(u.Hostname()) {
+		log.Printf("Warning: %s is not HTTPS; terminal traffic to the relay is not encrypted", conn.URL)
+	}
+
+	token := cfg.Auth.Token
+	if ServerToken != "" {
+		token = ServerToken
+	}
+	if token == "" {
+		log.Printf("Warning: auth.token is not set, so the relay can show this server's sessions but not change them")
+	}
+
+	agent := &relay.Agent{
+		RelayURL:       conn.URL,
+		Name:           conn.Name,
+		Secret:         conn.Secret,
+		TLSFingerprint: conn.TLSFingerprint,
+		// The relay's requests are forwarded with the access token, never
+		// with the control token of local commands, so a server without a
+		// token only shows its sessions through the relay
+		Server: func() (*client.Client, error) {
+			c, _, err := serverTarget()
+			if c != nil {
+				c.Token = token
+			}
+			return c, err
+		},
+	}
+	if c, _ := agent.Server(); c == nil {
+		log.Printf("No rvc server is running yet; start it with: rvc serve --daemon")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	log.Printf("Connecting to %s as %s", conn.URL, conn.Name)
+	return agent.Run(ctx)
+}
+
+// isLoopbackHost reports whether host names this machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
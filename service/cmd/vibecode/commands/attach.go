@@ -0,0 +1,307 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/ibrahim/remote-vibecode/internal/client"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+var (
+	attachDetachKey string
+	attachReadOnly  bool
+)
+
+const (
+	// attachPingInterval keeps idle connections open
+	attachPingInterval = 30 * time.Second
+	// attachMaxBackoff caps the delay between reconnect attempts
+	attachMaxBackoff = 10 * time.Second
+)
+
+// terminalReset leaves the alternate screen and turns off the mouse and
+// bracketed paste modes that tmux may have left on when the connection ended
+const terminalReset = "\x1b[?1049l\x1b[?1000l\x1b[?1002l\x1b[?1006l\x1b[?2004l\x1b[?25h"
+
+var AttachCmd = &cobra.Command{
+	Use:   "attach [session-name]",
+	Short: "Attach this terminal to a session through an rvc server",
+	Long: `Attach this terminal to a session through an rvc server, without SSH
+or a browser. The server is --remote (or --server, $RVC_SERVER, or the
+local server); without a server, a local session is joined directly.
+
+The connection is re-established automatically if it drops. Press the
+detach key (default ctrl-]) to leave; the session keeps running. Input
+is only sent to writable sessions.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAttach,
+}
+
+func init() {
+	// --remote is --server for this command
+	AttachCmd.Flags().StringVar(&ServerURL, "remote", "", "URL of the rvc server, e.g. https://devbox:7676")
+	AttachCmd.Flags().StringVar(&attachDetachKey, "detach-key", "ctrl-]", "Key that detaches from the session")
+	AttachCmd.Flags().BoolVarP(&attachReadOnly, "read-only", "r", false, "Never send input, even to writable sessions")
+}
+
+func runAttach(cmd *cobra.Command, args []string) error {
+	detachKey, err := parseDetachKey(attachDetachKey)
+	if err != nil {
+		return err
+	}
+
+	c, err := connectServer()
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		// No server: behave like 'rvc join'
+		return runJoin(cmd, args)
+	}
+
+	var sessionName string
+	if len(args) > 0 {
+		sessionName = args[0]
+	} else {
+		names, err := serverSessionNames(c)
+		if err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+		if sessionName, err = selectSession(names); err != nil {
+			return err
+		}
+	}
+	if !tmux.IsValidSessionName(sessionName) {
+		return fmt.Errorf("invalid session name: %s", sessionName)
+	}
+
+	session, err := serverSession(c, sessionName)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return fmt.Errorf("session '%s' does not exist on %s. Use 'rvc list' to see available sessions.", sessionName, c.BaseURL)
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("rvc attach needs an interactive terminal")
+	}
+
+	writable := session.Writable && !attachReadOnly
+	mode := "read-only"
+	if writable {
+		mode = "writable"
+	}
+	fmt.Fprintf(os.Stderr, "Attached to '%s' on %s (%s). Detach with %s.\n", sessionName, c.BaseURL, mode, attachDetachKey)
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to put the terminal in raw mode: %w", err)
+	}
+	a := &remoteAttach{
+		client:    c,
+		session:   sessionName,
+		writable:  writable,
+		detachKey: detachKey,
+		fd:        fd,
+	}
+	ended, err := a.run()
+
+	fmt.Fprint(os.Stdout, terminalReset)
+	_ = term.Restore(fd, state)
+
+	if err != nil {
+		return err
+	}
+	if ended {
+		fmt.Fprintf(os.Stderr, "\nSession '%s' has ended.\n", sessionName)
+	} else {
+		fmt.Fprintf(os.Stderr, "\nDetached from '%s'. Session continues running.\n", sessionName)
+		fmt.Fprintf(os.Stderr, "Reattach with: rvc attach --remote %s %s\n", c.BaseURL, sessionName)
+	}
+	return nil
+}
+
+// parseDetachKey turns "ctrl-]" style names into the control byte they send
+func parseDetachKey(name string) (byte, error) {
+	key, ok := strings.CutPrefix(strings.ToLower(name), "ctrl-")
+	if ok && len(key) == 1 {
+		k := strings.ToUpper(key)[0]
+		if k >= '@' && k <= '_' {
+			return k - '@', nil
+		}
+	}
+	return 0, fmt.Errorf("invalid detach key %q: use ctrl-<letter> or one of ctrl-@ ctrl-[ ctrl-\\ ctrl-] ctrl-^ ctrl-_", name)
+}
+
+// serverSession returns a session tracked by the server, or nil if there is none
+func serverSession(c *client.Client, name string) (*client.Session, error) {
+	ctx, cancel := serverContext()
+	defer cancel()
+	sessions, err := c.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		if sessions[i].SessionName == name {
+			return &sessions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// remoteAttach relays one local terminal to a session on a server
+type remoteAttach struct {
+	client    *client.Client
+	session   string
+	writable  bool
+	detachKey byte
+	fd        int
+	connected bool // set once a connection is established
+}
+
+// run relays the terminal until the user detaches (false) or the session
+// ends (true), reconnecting whenever the connection drops
+func (a *remoteAttach) run() (bool, error) {
+	// Stdin cannot be interrupted, so a single reader serves every connection
+	input := make(chan []byte)
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(input)
+				return
+			}
+			input <- bytes.Clone(buf[:n])
+		}
+	}()
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+
+	backoff := time.Second
+	for {
+		detached, err := a.connect(input, winch)
+		if detached {
+			return false, nil
+		}
+		if errors.Is(err, client.ErrSessionEnded) {
+			return true, nil
+		}
+		if a.connected {
+			backoff = time.Second
+			a.connected = false
+		}
+
+		// Find out whether the session is gone or only the connection
+		session, lookupErr := serverSession(a.client, a.session)
+		if lookupErr == nil && session == nil {
+			return true, nil
+		}
+		var apiErr *client.Error
+		if errors.As(err, &apiErr) || errors.As(lookupErr, &apiErr) {
+			if apiErr.Status == http.StatusUnauthorized {
+				return false, fmt.Errorf("the server rejected the access token")
+			}
+		}
+
+		fmt.Fprintf(os.Stdout, "\r\n\x1b[38;5;215m*** Connection lost, reconnecting in %s ***\x1b[0m\r\n", backoff)
+		select {
+		case data, ok := <-input:
+			if !ok || bytes.IndexByte(data, a.detachKey) >= 0 {
+				return false, nil
+			}
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, attachMaxBackoff)
+	}
+}
+
+// connect relays the terminal over one connection. It returns true when the
+// user detached and otherwise the reason the connection ended.
+func (a *remoteAttach) connect(input <-chan []byte, winch <-chan os.Signal) (bool, error) {
+	ctx, cancel := serverContext()
+	t, err := a.client.DialTerminal(ctx, a.session)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	defer t.Close()
+	a.connected = true
+
+	if err := a.resize(t); err != nil {
+		return false, err
+	}
+
+	output := make(chan error, 1)
+	go func() {
+		for {
+			data, err := t.ReadOutput()
+			if err != nil {
+				output <- err
+				return
+			}
+			if _, err := os.Stdout.Write(data); err != nil {
+				output <- err
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(attachPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case data, ok := <-input:
+			if !ok {
+				return true, nil
+			}
+			i := bytes.IndexByte(data, a.detachKey)
+			if i >= 0 {
+				data = data[:i]
+			}
+			if a.writable && len(data) > 0 {
+				if err := t.Input(data); err != nil {
+					return false, err
+				}
+			}
+			if i >= 0 {
+				return true, nil
+			}
+		case <-winch:
+			if err := a.resize(t); err != nil {
+				return false, err
+			}
+		case <-ping.C:
+			if err := t.Ping(); err != nil {
+				return false, err
+			}
+		case err := <-output:
+			return false, err
+		}
+	}
+}
+
+// resize sends the size of the local terminal
+func (a *remoteAttach) resize(t *client.Terminal) error {
+	cols, rows, err := term.GetSize(a.fd)
+	if err != nil {
+		return nil
+	}
+	return t.Resize(cols, rows)
+}
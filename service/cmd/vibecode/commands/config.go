@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ibrahim/remote-vibecode/internal/config"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+// ConfigFile is the --config flag shared by all commands
+var ConfigFile string
+
+// LoadConfig loads the configuration from --config (or its default location)
+// and the environment, and returns it with the path of the file
+func LoadConfig() (*config.Config, string, error) {
+	return config.Load(ConfigFile)
+}
+
+// applyCLIConfig applies the settings that local commands share with the server
+func applyCLIConfig() (*config.Config, error) {
+	cfg, _, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	tmux.SetRecordingDir(cfg.Recording.Dir)
+	return cfg, nil
+}
+
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the rvc configuration",
+	Long: `Inspect the rvc configuration.
+
+Settings are layered: built-in defaults, then the config file
+(--config, $RVC_CONFIG or ~/.config/rvc/config.yaml), then RVC_*
+environment variables, then command-line flags of 'rvc serve'.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration (without serve flags)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, path, err := LoadConfig()
+		if err != nil {
+			return err
+		}
+		data, err := cfg.Redacted().Marshal()
+		if err != nil {
+			return err
+		}
+
+		source := path
+		if _, err := os.Stat(path); err != nil {
+			source += " (not found, using defaults)"
+		}
+		fmt.Printf("# config file: %s\n%s", source, data)
+		return nil
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a configuration file for errors",
+	Args:  cobra.MaximumNArgs(1),
+	// Errors are about the file, not the command line
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		override := ConfigFile
+		if len(args) > 0 {
+			override = args[0]
+		}
+		cfg, path, err := config.Load(override)
+		if err != nil {
+			return err
+		}
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("%s is invalid:\n%w", path, err)
+		}
+		fmt.Printf("%s: ok\n", path)
+		return nil
+	},
+}
+
+func init() {
+	ConfigCmd.AddCommand(configShowCmd)
+	ConfigCmd.AddCommand(configValidateCmd)
+}
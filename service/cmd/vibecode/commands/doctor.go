@@ -0,0 +1,488 @@
+package commands
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ibrahim/remote-vibecode/internal/client"
+	"github.com/ibrahim/remote-vibecode/internal/config"
+	"github.com/ibrahim/remote-vibecode/internal/daemon"
+	"github.com/ibrahim/remote-vibecode/internal/paths"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
+	"github.com/ibrahim/remote-vibecode/internal/ws"
+)
+
+// Check outcomes reported by rvc doctor
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+const (
+	// tmuxMinVersion is the oldest tmux rvc works with
+	tmuxMinVersion = 3.0
+	// tmuxEnvVersion added new-session -e, used for session environment variables
+	tmuxEnvVersion = 3.2
+	// certExpiryWarning is how long before expiry a certificate is reported
+	certExpiryWarning = 14 * 24 * time.Hour
+	// maxClockSkew is the clock difference tolerated before links and push
+	// subscriptions signed on one side are rejected by the other
+	maxClockSkew = 30 * time.Second
+)
+
+var doctorOutput string
+
+// tmuxVersionPattern extracts major.minor from tmux -V, e.g. "tmux 3.3a" or "tmux next-3.5"
+var tmuxVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+var DoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose common problems with tmux, the server and the configuration",
+	Long: `Run a series of checks and report each as pass, warn or fail with a hint
+on how to fix it: the tmux version and features, the default shell,
+session options, the config file, the watched tmux sockets, the server's
+reachability and health, federation peers, permissions of the state
+directory and TLS files, and clock skew.
+
+Exits with status 1 if any check fails.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runDoctor,
+}
+
+func init() {
+	addOutputFlag(DoctorCmd, &doctorOutput)
+}
+
+// checkResult is the outcome of one doctor check
+type checkResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// doctorReport collects check results
+type doctorReport struct {
+	Checks []checkResult `json:"checks"`
+}
+
+func (r *doctorReport) add(name, status, message, hint string) {
+	r.Checks = append(r.Checks, checkResult{Name: name, Status: status, Message: message, Hint: hint})
+}
+
+func (r *doctorReport) pass(name, format string, args ...interface{}) {
+	r.add(name, checkPass, fmt.Sprintf(format, args...), "")
+}
+
+func (r *doctorReport) count(status string) int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	isStructured, err := structured(doctorOutput)
+	if err != nil {
+		return err
+	}
+
+	report := &doctorReport{}
+	if checkTmux(report) {
+		checkShell(report)
+		checkSessions(report)
+	}
+	cfg := checkConfig(report)
+	checkServer(report, cfg)
+	checkStateDir(report)
+	if cfg != nil {
+		checkClipboard(report, cfg)
+		checkSockets(report, cfg)
+		checkPeers(report, cfg)
+		checkTLS(report, cfg)
+	}
+
+	if isStructured {
+		if err := writeStructured(cmd.OutOrStdout(), doctorOutput, report); err != nil {
+			return err
+		}
+	} else {
+		printReport(report)
+	}
+	if failed := report.count(checkFail); failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(report.Checks))
+	}
+	return nil
+}
+
+// printReport prints the results for humans
+func printReport(report *doctorReport) {
+	symbols := map[string]string{checkPass: "✓", checkWarn: "⚠", checkFail: "✗"}
+	for _, c := range report.Checks {
+		fmt.Printf("%s %-12s %s\n", symbols[c.Status], c.Name, c.Message)
+		if c.Hint != "" {
+			for _, line := range strings.Split(c.Hint, "\n") {
+				fmt.Printf("  %-12s → %s\n", "", line)
+			}
+		}
+	}
+	fmt.Printf("\n%d passed, %d warnings, %d failed\n",
+		report.count(checkPass), report.count(checkWarn), report.count(checkFail))
+}
+
+// checkTmux checks that tmux is installed and recent enough, and reports
+// whether the remaining tmux checks can run
+func checkTmux(report *doctorReport) bool {
+	version, err := tmux.Version()
+	if err != nil {
+		report.add("tmux", checkFail, "tmux is not installed or not on PATH",
+			"Install it with: brew install tmux (macOS) or sudo apt install tmux (Debian/Ubuntu)")
+		return false
+	}
+
+	match := tmuxVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		report.add("tmux", checkWarn, fmt.Sprintf("%s: unrecognised version", version), "")
+		return true
+	}
+	number, _ := strconv.ParseFloat(match[1]+"."+match[2], 64)
+	switch {
+	case number < tmuxMinVersion:
+		report.add("tmux", checkFail, fmt.Sprintf("%s is too old; rvc needs tmux %.1f or newer", version, tmuxMinVersion),
+			"Upgrade tmux from your package manager")
+	case number < tmuxEnvVersion:
+		report.add("tmux", checkWarn, fmt.Sprintf("%s does not support session environment variables (--env, profile env)", version),
+			fmt.Sprintf("Upgrade to tmux %.1f or newer", tmuxEnvVersion))
+	default:
+		report.pass("tmux", "%s", version)
+	}
+	return true
+}
+
+// checkShell checks that the shell used for new sessions exists
+func checkShell(report *doctorReport) {
+	shell := tmux.DefaultShell()
+	path, err := exec.LookPath(shell)
+	if err != nil {
+		report.add("shell", checkFail,
+			fmt.Sprintf("new sessions run %s (from $SHELL=%q), which is not installed", shell, os.Getenv("SHELL")),
+			"Set $SHELL to bash, zsh or fish, or start sessions with a command: rvc start name -- <command>")
+		return
+	}
+	report.pass("shell", "new sessions run %s", path)
+}
+
+// checkSessions checks tmux state left behind by other tools or older rvc
+// versions: sessions without @rvc-writable and rvc's status line set globally
+func checkSessions(report *doctorReport) {
+	if !tmux.SessionsRunning() {
+		report.pass("sessions", "no tmux server running")
+		return
+	}
+
+	flags, err := tmux.ListWritableFlags()
+	switch {
+	case err != nil:
+		report.add("sessions", checkWarn, fmt.Sprintf("could not list sessions: %v", err), "")
+	case len(flags) == 0:
+		// tmux has sessions, so their list could not be parsed
+		report.add("sessions", checkFail, "tmux has sessions, but rvc could not read their @rvc-writable options",
+			"Report this together with the output of: tmux -V")
+	default:
+		var unset []string
+		for name, value := range flags {
+			if value == "" {
+				unset = append(unset, name)
+			}
+		}
+		sort.Strings(unset)
+		if len(unset) > 0 {
+			c, name, _ := tmuxclient.Resolve(unset[0])
+			report.add("sessions", checkWarn,
+				fmt.Sprintf("%d of %d sessions have no @rvc-writable option and are read-only in the browser: %s",
+					len(unset), len(flags), strings.Join(unset, ", ")),
+				fmt.Sprintf("Mark a session explicitly: %s @rvc-writable 1 (or 0)", tmuxCommandLine(c, "set-option", "-t", name)))
+		} else {
+			report.pass("sessions", "%d sessions, all with @rvc-writable set", len(flags))
+		}
+	}
+
+	// Older rvc versions set the status line globally, for every session
+	left, _ := tmux.GlobalOption("status-left")
+	style, _ := tmux.GlobalOption("status-style")
+	if strings.Contains(left, " RVC ") || style == "bg=#1a1a2e,fg=#eee8aa" {
+		report.add("status line", checkWarn, "rvc's status line is set globally, so every tmux session shows it",
+			"Restore the defaults: tmux set-option -gu status-left \\; set-option -gu status-right \\; set-option -gu status-style \\; set-option -gu status-interval\n"+
+				"and remove those lines from ~/.tmux.conf if they are there")
+	} else {
+		report.pass("status line", "global status line untouched")
+	}
+}
+
+// checkConfig loads and validates the config file, returning it if usable
+func checkConfig(report *doctorReport) *config.Config {
+	cfg, path, err := LoadConfig()
+	if err != nil {
+		report.add("config", checkFail, err.Error(), "Fix the file, then check it with: rvc config validate")
+		return nil
+	}
+	if err := cfg.Validate(); err != nil {
+		report.add("config", checkFail, fmt.Sprintf("%s is invalid: %s", path, strings.ReplaceAll(err.Error(), "\n", "; ")),
+			"Fix the file, then check it with: rvc config validate")
+		return nil
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		report.pass("config", "no config file at %s, using defaults", path)
+	} else {
+		report.pass("config", "%s is valid", path)
+		secret := cfg.Auth.Token != ""
+		for _, p := range cfg.Federation.Peers {
+			secret = secret || p.Token != ""
+		}
+		secret = secret || len(cfg.Relay.Agents) > 0 || cfg.Relay.Connect.Secret != ""
+		if secret {
+			checkPrivate(report, "config", path, checkWarn, "holds access tokens or relay secrets")
+		}
+	}
+
+	host := cfg.Server.Host
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		switch {
+		case cfg.Auth.Token == "":
+			report.add("exposure", checkFail, fmt.Sprintf("the server listens on %s without an access token", host),
+				"Set auth.token (or RVC_TOKEN), or listen on 127.0.0.1 only")
+		case !cfg.Server.TLS.Enabled():
+			report.add("exposure", checkWarn, fmt.Sprintf("the server listens on %s without TLS; the token is sent in clear text", host),
+				"Set server.tls.cert and server.tls.key, or reach it over a VPN such as Tailscale")
+		}
+	}
+	return cfg
+}
+
+// checkClipboard checks that tmux passes clipboard writes of programs in
+// panes on to the browser. With set-clipboard "external", tmux's default,
+// only copies from copy mode reach it.
+func checkClipboard(report *doctorReport, cfg *config.Config) {
+	if cfg.Clipboard == string(ws.ClipboardOff) || !tmux.SessionsRunning() {
+		return
+	}
+	value, err := tmux.ServerOption("set-clipboard")
+	if err != nil {
+		return
+	}
+	if value != "on" {
+		report.add("clipboard", checkWarn, fmt.Sprintf("tmux's set-clipboard is %q, so only copy-mode copies reach the browser", value),
+			"Let programs in panes copy too: tmux set -s set-clipboard on (and add it to ~/.tmux.conf)")
+		return
+	}
+	report.pass("clipboard", "tmux forwards clipboard writes of programs in panes")
+}
+
+// checkServer checks the server the CLI would use: its health, whether the
+// CLI can authenticate and its clock. Without one it checks that the
+// configured port is free.
+func checkServer(report *doctorReport, cfg *config.Config) {
+	c, explicit, err := serverTarget()
+	if err != nil {
+		report.add("server", checkFail, err.Error(), "")
+		return
+	}
+	if c == nil {
+		if cfg != nil {
+			addr := net.JoinHostPort(cfg.Server.Host, cfg.Server.Port)
+			if ln, err := net.Listen("tcp", addr); err != nil {
+				report.add("port", checkFail, fmt.Sprintf("%s is not available: %v", addr, err),
+					"Another program uses the port; pick another with server.port or rvc serve --port")
+			} else {
+				ln.Close()
+				report.pass("port", "%s is free", addr)
+			}
+		}
+		report.add("server", checkWarn, "not running; sessions are not shown in the browser", "Start it with: rvc serve --daemon")
+		return
+	}
+
+	ctx, cancel := serverContext()
+	defer cancel()
+	sent := time.Now()
+	health, err := c.Health(ctx)
+	received := time.Now()
+	if err != nil {
+		hint := "Restart it with: rvc server restart"
+		if logPath, err := daemon.LogFile(); err == nil {
+			hint = fmt.Sprintf("Check the log in %s, or restart it with: rvc server restart", logPath)
+		}
+		if explicit {
+			hint = "Check the URL and that the server is running and reachable from here"
+		}
+		report.add("server", checkFail, fmt.Sprintf("%s does not answer: %v", c.BaseURL, err), hint)
+		return
+	}
+	report.pass("server", "%s is healthy, up %s", c.BaseURL, time.Since(health.StartedAt).Round(time.Second))
+
+	if _, err := c.ListSessions(ctx); err != nil {
+		var apiErr *client.Error
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized {
+			report.add("auth", checkFail, "the server rejects the access token", "Pass the server's token with --token or set RVC_TOKEN")
+		} else {
+			report.add("auth", checkFail, fmt.Sprintf("listing sessions failed: %v", err), "")
+		}
+	} else {
+		report.pass("auth", "authenticated to the API")
+	}
+
+	if health.Time.IsZero() {
+		return
+	}
+	// Compare against the middle of the round trip
+	local := sent.Add(received.Sub(sent) / 2)
+	skew := health.Time.Sub(local)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxClockSkew {
+		report.add("clock", checkWarn, fmt.Sprintf("this machine's clock differs from the server's by %s", skew.Round(time.Second)),
+			"Signed links and push notifications may be rejected; enable NTP on both machines")
+	} else {
+		report.pass("clock", "in sync with the server (±%s)", skew.Round(time.Millisecond))
+	}
+}
+
+// checkPeers checks that every federation peer answers and accepts its token
+func checkPeers(report *doctorReport, cfg *config.Config) {
+	for _, p := range cfg.Federation.Peers {
+		c := client.New(p.URL, p.Token)
+		if p.TLSFingerprint != "" {
+			c.PinCertificate(p.TLSFingerprint)
+		}
+		ctx, cancel := serverContext()
+		_, err := c.ListSessions(ctx)
+		cancel()
+
+		var apiErr *client.Error
+		switch {
+		case err == nil:
+			report.pass("peer", "%s (%s) is reachable and accepts the token", p.Name, p.URL)
+		case errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized:
+			report.add("peer", checkFail, fmt.Sprintf("%s (%s) rejects the token", p.Name, p.URL), "Set the peer's access token in federation.peers[].token")
+		default:
+			report.add("peer", checkWarn, fmt.Sprintf("%s is not reachable: %v", p.Name, err),
+				"Its sessions are hidden until it answers; check that it runs and listens on a reachable address")
+		}
+	}
+}
+
+// checkSockets checks that the tmux servers under discovery.sockets are running
+func checkSockets(report *doctorReport, cfg *config.Config) {
+	for _, s := range cfg.Discovery.Sockets {
+		c := s.Client()
+		if c.Command("has-session").Run() != nil {
+			report.add("tmux socket", checkWarn, fmt.Sprintf("%s: no sessions on %s", s.Name, c),
+				fmt.Sprintf("Its sessions show up once it runs, e.g.: %s", tmuxCommandLine(c, "new-session", "-d")))
+			continue
+		}
+		report.pass("tmux socket", "%s: %s is running", s.Name, c)
+	}
+}
+
+// tmuxCommandLine returns the command line that runs a tmux command on c's server
+func tmuxCommandLine(c tmuxclient.Client, args ...string) string {
+	return strings.Join(append(append([]string{"tmux"}, c.Args()...), args...), " ")
+}
+
+// checkStateDir checks that the state directory and the secrets in it are private
+func checkStateDir(report *doctorReport) {
+	dir, err := paths.StateDir()
+	if err != nil {
+		report.add("state dir", checkFail, err.Error(), "")
+		return
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		report.add("state dir", checkFail, err.Error(), "")
+		return
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		report.add("state dir", checkWarn, fmt.Sprintf("%s is accessible by other users (%s)", dir, info.Mode().Perm()),
+			fmt.Sprintf("chmod 700 %s", dir))
+	} else {
+		report.pass("state dir", "%s is private", dir)
+	}
+
+	// Files holding secrets: the control token, the VAPID private key and
+	// the push subscription endpoints
+	for _, name := range []string{"server.json", "vapid.json", "push-subscriptions.json"} {
+		checkPrivate(report, "state dir", filepath.Join(dir, name), checkFail, "holds secrets")
+	}
+}
+
+// checkPrivate reports a file that other users can read. Missing files are
+// fine; only problems are reported.
+func checkPrivate(report *doctorReport, name, path, status, why string) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		report.add(name, checkWarn, err.Error(), "")
+		return
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		report.add(name, status, fmt.Sprintf("%s %s but is accessible by other users (%s)", path, why, info.Mode().Perm()),
+			fmt.Sprintf("chmod 600 %s", path))
+	}
+}
+
+// checkTLS checks the configured certificate and key
+func checkTLS(report *doctorReport, cfg *config.Config) {
+	tlsCfg := cfg.Server.TLS
+	if !tlsCfg.Enabled() {
+		return
+	}
+	pair, err := tls.LoadX509KeyPair(tlsCfg.Cert, tlsCfg.Key)
+	if err != nil {
+		report.add("tls", checkFail, fmt.Sprintf("cannot load the certificate and key: %v", err),
+			"Check server.tls.cert and server.tls.key; the key must match the certificate")
+		return
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		report.add("tls", checkFail, fmt.Sprintf("cannot parse %s: %v", tlsCfg.Cert, err), "")
+		return
+	}
+
+	remaining := time.Until(cert.NotAfter)
+	switch {
+	case remaining <= 0:
+		report.add("tls", checkFail, fmt.Sprintf("%s expired on %s", tlsCfg.Cert, formatTime(cert.NotAfter)),
+			"Renew the certificate, then run: rvc server restart")
+	case remaining < certExpiryWarning:
+		report.add("tls", checkWarn, fmt.Sprintf("%s expires in %d days", tlsCfg.Cert, int(remaining.Hours()/24)),
+			"Renew the certificate, then run: rvc server restart")
+	default:
+		report.pass("tls", "%s is valid until %s", tlsCfg.Cert, formatTime(cert.NotAfter))
+	}
+	checkPrivate(report, "tls", tlsCfg.Key, checkWarn, "is a private key")
+}
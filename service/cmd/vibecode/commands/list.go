@@ -2,29 +2,73 @@ package commands
 
 import (
 	"fmt"
-	"os/exec"
-	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/ibrahim/remote-vibecode/internal/client"
 	"github.com/ibrahim/remote-vibecode/internal/tmux"
 )
 
 var ListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all rvc sessions",
-	Long:  `List all available tmux sessions with their details.`,
-	RunE:  runList,
+	Long: `List all available tmux sessions with their details.
+
+When an rvc server is running (or --server is given) its view is shown,
+including web viewers, activity state and recordings; otherwise tmux is
+queried directly.`,
+	Args: cobra.NoArgs,
+	RunE: runList,
+}
+
+var listOutput string
+
+func init() {
+	addOutputFlag(ListCmd, &listOutput)
+}
+
+// listedSession is a row of rvc list. Viewers and State are only known to a server.
+type listedSession struct {
+	tmux.SessionSummary `yaml:",inline"`
+	Viewers             *int       `json:"viewers,omitempty"`
+	State               string     `json:"state,omitempty"`
+	LastActivity        *time.Time `json:"last_activity,omitempty"`
+	Recording           string     `json:"recording,omitempty"`
+}
+
+// listResult is the --output representation of rvc list
+type listResult struct {
+	Server   string          `json:"server,omitempty"`
+	Sessions []listedSession `json:"sessions"`
 }
 
 func runList(cmd *cobra.Command, args []string) error {
-	sessions, err := tmux.ListSessions()
+	isStructured, err := structured(listOutput)
+	if err != nil {
+		return err
+	}
+
+	c, err := connectServer()
+	if err != nil {
+		return err
+	}
+	var result *listResult
+	if c != nil {
+		result, err = listServerSessions(c)
+	} else {
+		result, err = listLocalSessions()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to list sessions: %w", err)
 	}
 
-	if len(sessions) == 0 {
+	if isStructured {
+		return writeStructured(cmd.OutOrStdout(), listOutput, result)
+	}
+
+	if len(result.Sessions) == 0 {
 		fmt.Println("No tmux sessions found.")
 		fmt.Println("Create one with: rvc start")
 		return nil
@@ -32,50 +76,114 @@ func runList(cmd *cobra.Command, args []string) error {
 
 	// Use tabwriter for nice formatting
 	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "SESSION NAME\tWINDOWS\tCREATED")
-
-	for _, sessionName := range sessions {
-		// Get session info
-		info, err := getSessionDetails(sessionName)
-		if err != nil {
-			fmt.Fprintf(w, "%s\t?\t?\n", sessionName)
-			continue
+	fmt.Fprintln(w, "SESSION NAME\tWINDOWS\tCLIENTS\tVIEWERS\tSTATE\tMODE\tCREATED\tLAST ACTIVITY\tCOMMAND")
+
+	for _, s := range result.Sessions {
+		mode := "read-only"
+		if s.Writable {
+			mode = "writable"
+		}
+		if s.Recording != "" {
+			mode += ", rec"
+		}
+		viewers, state, activity := "-", "-", "-"
+		if s.Viewers != nil {
+			viewers = fmt.Sprint(*s.Viewers)
+		}
+		if s.State != "" {
+			state = s.State
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\n", sessionName, info.windows, info.created)
+		if s.LastActivity != nil {
+			activity = formatAgo(*s.LastActivity)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Name, s.Windows, s.Attached, viewers, state, mode, formatTime(s.Created), activity, s.Command)
 	}
 
 	w.Flush()
-	fmt.Printf("\nTotal sessions: %d\n", len(sessions))
+	fmt.Printf("\nTotal sessions: %d\n", len(result.Sessions))
+	if result.Server != "" {
+		fmt.Printf("Server: %s\n", result.Server)
+	}
 	fmt.Println("Join a session: rvc join [session-name]")
 
 	return nil
 }
 
-type sessionInfo struct {
-	windows string
-	created string
-}
-
-func getSessionDetails(sessionName string) (*sessionInfo, error) {
-	// Get window count
-	cmd := exec.Command("tmux", "display-message", "-p", "-t", sessionName, "#{session_windows}")
-	output, err := cmd.Output()
+// listServerSessions lists the sessions tracked by a running server
+func listServerSessions(c *client.Client) (*listResult, error) {
+	ctx, cancel := serverContext()
+	defer cancel()
+	sessions, err := c.ListSessions(ctx)
 	if err != nil {
 		return nil, err
 	}
-	windows := strings.TrimSpace(string(output))
 
-	// Get creation time (using session group format)
-	// Note: tmux doesn't easily expose creation time, so we'll show the session group instead
-	cmd = exec.Command("tmux", "display-message", "-p", "-t", sessionName, "#{session_group}")
-	output, err = cmd.Output()
+	result := &listResult{Server: c.BaseURL, Sessions: make([]listedSession, 0, len(sessions))}
+	for _, s := range sessions {
+		row := listedSession{
+			SessionSummary: tmux.SessionSummary{Name: s.SessionName, Writable: s.Writable, Windows: len(s.Windows)},
+			Viewers:        &s.Viewers,
+			State:          s.State,
+			Recording:      s.Recording,
+		}
+		if s.Tmux != nil {
+			row.SessionSummary = *s.Tmux
+		}
+		if activity := s.LastActivity(); !activity.IsZero() {
+			row.LastActivity = &activity
+		}
+		result.Sessions = append(result.Sessions, row)
+	}
+	return result, nil
+}
+
+// listLocalSessions lists sessions straight from tmux
+func listLocalSessions() (*listResult, error) {
+	summaries, err := tmux.ListSessionSummaries()
 	if err != nil {
 		return nil, err
 	}
-	group := strings.TrimSpace(string(output))
+	activity, err := tmux.ListSessionActivity()
+	if err != nil {
+		activity = map[string]tmux.SessionActivity{}
+	}
+
+	result := &listResult{Sessions: make([]listedSession, 0, len(summaries))}
+	for _, s := range summaries {
+		row := listedSession{SessionSummary: s}
+		if a, ok := activity[s.Name]; ok {
+			latest := a.Window
+			if a.Session.After(latest) {
+				latest = a.Session
+			}
+			row.LastActivity = &latest
+		}
+		row.Recording, _ = tmux.Recording(s.Name)
+		result.Sessions = append(result.Sessions, row)
+	}
+	return result, nil
+}
 
-	return &sessionInfo{
-		windows: windows,
-		created: group,
-	}, nil
+// formatTime formats a timestamp for tables, or "-" if it is unknown
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.DateTime)
+}
+
+// formatAgo formats the time since t, e.g. "3m ago"
+func formatAgo(t time.Time) string {
+	d := time.Since(t).Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
 }
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ibrahim/remote-vibecode/internal/client"
+	"github.com/ibrahim/remote-vibecode/internal/daemon"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+// openLinkTTL is how long the login link opened by 'rvc open' stays valid;
+// the browser uses it right away
+const openLinkTTL = time.Minute
+
+var openPrint bool
+
+var OpenCmd = &cobra.Command{
+	Use:   "open [session-name]",
+	Short: "Open the dashboard in the local browser",
+	Long: `Open the dashboard of the rvc server (--server, $RVC_SERVER or the local
+server) in the default browser. The browser is logged in through a one-time
+link, so the token never has to be typed. With a session name, that session
+is shown.
+
+The browser is $BROWSER if set, otherwise xdg-open (open on macOS).`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runOpen,
+}
+
+func init() {
+	OpenCmd.Flags().BoolVar(&openPrint, "print", false, "Print the link instead of launching a browser")
+}
+
+func runOpen(cmd *cobra.Command, args []string) error {
+	c, err := connectServer()
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return fmt.Errorf("%w; start it with: rvc serve --daemon", daemon.ErrNotRunning)
+	}
+
+	link, err := createLoginLink(c, args, openLinkTTL)
+	if err != nil {
+		return err
+	}
+	url := c.BaseURL + link.Path
+	if openPrint {
+		fmt.Println(url)
+		return nil
+	}
+
+	if err := openBrowser(url); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not launch a browser: %v\n", err)
+		fmt.Printf("Open this link within %s:\n  %s\n", openLinkTTL, url)
+		return nil
+	}
+	fmt.Printf("✓ Opened %s in the browser\n", c.BaseURL)
+	return nil
+}
+
+// createLoginLink asks the server for a login link that opens the session
+// named in args, if any, after checking that the server tracks it
+func createLoginLink(c *client.Client, args []string, ttl time.Duration) (*client.LoginLink, error) {
+	var sessionName string
+	if len(args) > 0 {
+		sessionName = args[0]
+		if !tmux.IsValidSessionName(sessionName) {
+			return nil, fmt.Errorf("invalid session name: %s", sessionName)
+		}
+		session, err := serverSession(c, sessionName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		if session == nil {
+			return nil, fmt.Errorf("session '%s' does not exist on %s. Use 'rvc list' to see available sessions.", sessionName, c.BaseURL)
+		}
+	}
+
+	ctx, cancel := serverContext()
+	defer cancel()
+	link, err := c.CreateLoginLink(ctx, sessionName, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a login link: %w", err)
+	}
+	return link, nil
+}
+
+// openBrowser opens url in the user's browser without waiting for it
+func openBrowser(url string) error {
+	name := os.Getenv("BROWSER")
+	if name == "" {
+		name = "xdg-open"
+		if runtime.GOOS == "darwin" {
+			name = "open"
+		}
+	}
+	cmd := exec.Command(name, url)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+)
+
+// Output formats accepted by --output
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// addOutputFlag adds --output to a command, storing the format in target
+func addOutputFlag(cmd *cobra.Command, target *string) {
+	cmd.Flags().StringVarP(target, "output", "o", outputText, "Output format: text, json or yaml")
+}
+
+// structured reports whether a format is machine-readable, rejecting unknown formats
+func structured(format string) (bool, error) {
+	switch format {
+	case outputText:
+		return false, nil
+	case outputJSON, outputYAML:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown output format %q: use text, json or yaml", format)
+	}
+}
+
+// writeStructured writes v as JSON or YAML
+func writeStructured(w io.Writer, format string, v interface{}) error {
+	if format == outputYAML {
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
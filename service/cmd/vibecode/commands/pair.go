@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/spf13/cobra"
+
+	"github.com/ibrahim/remote-vibecode/internal/client"
+	"github.com/ibrahim/remote-vibecode/internal/daemon"
+)
+
+var (
+	pairTTL    time.Duration
+	pairInvert bool
+)
+
+var PairCmd = &cobra.Command{
+	Use:   "pair [session-name]",
+	Short: "Show a QR code that logs a phone in to the dashboard",
+	Long: `Print a QR code for each network address of the local server. Scanning
+one with a phone opens the dashboard already logged in, and with a session
+name, on that session.
+
+The QR codes share a single login link: it works once and expires after
+--ttl. The server must listen on an address the phone can reach, e.g.
+'rvc serve --host 0.0.0.0'. With --server, a code for that URL is shown.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runPair,
+}
+
+func init() {
+	PairCmd.Flags().DurationVar(&pairTTL, "ttl", 5*time.Minute, "How long the login link stays valid (at most 1h)")
+	PairCmd.Flags().BoolVar(&pairInvert, "invert", false, "Invert the QR codes, for terminals with a light background")
+}
+
+// pairTarget is a URL at which a phone may reach the server
+type pairTarget struct {
+	Interface string // network interface, empty for --server
+	URL       string
+}
+
+func runPair(cmd *cobra.Command, args []string) error {
+	c, err := connectServer()
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return fmt.Errorf("%w; start it with: rvc serve --daemon --host 0.0.0.0", daemon.ErrNotRunning)
+	}
+	targets, fingerprint, err := pairTargets(c)
+	if err != nil {
+		return err
+	}
+
+	link, err := createLoginLink(c, args, pairTTL)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Scan a code with your phone to open the dashboard.")
+	fmt.Printf("The link works once and expires at %s.\n", link.ExpiresAt.Local().Format("15:04:05"))
+	if fingerprint != "" {
+		fmt.Println("If the certificate is self-signed, check that the phone shows this SHA-256 fingerprint:")
+		fmt.Printf("  %s\n", fingerprint)
+	}
+	for _, t := range targets {
+		full := t.URL + link.Path
+		qr, err := qrcode.New(full, qrcode.Medium)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", full, err)
+		}
+		fmt.Println()
+		if t.Interface != "" {
+			fmt.Printf("%s: %s\n", t.Interface, full)
+		} else {
+			fmt.Println(full)
+		}
+		fmt.Print(qr.ToSmallString(pairInvert))
+	}
+	return nil
+}
+
+// pairTargets returns the URLs to encode and the TLS fingerprint of the
+// local server. The local server is reached through every LAN address it
+// listens on; any other server only through its URL.
+func pairTargets(c *client.Client) ([]pairTarget, string, error) {
+	rt, err := daemon.Running()
+	if err != nil && !errors.Is(err, daemon.ErrNotRunning) {
+		return nil, "", err
+	}
+	if rt == nil || rt.URL != c.BaseURL {
+		return []pairTarget{{URL: c.BaseURL}}, "", nil
+	}
+
+	u, err := url.Parse(rt.URL)
+	if err != nil {
+		return nil, "", err
+	}
+	host, port, err := net.SplitHostPort(rt.Addr)
+	if err != nil {
+		return nil, "", fmt.Errorf("the server does not report its listen address; restart it with: rvc server restart")
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() {
+		return nil, "", fmt.Errorf("the server listens on %s, which other devices cannot reach; restart it with --host 0.0.0.0 (or set server.host in the config)", rt.Addr)
+	}
+	if !ip.IsUnspecified() {
+		return []pairTarget{{URL: u.Scheme + "://" + rt.Addr}}, rt.TLSFingerprint, nil
+	}
+
+	addrs, err := lanAddresses()
+	if err != nil {
+		return nil, "", err
+	}
+	if len(addrs) == 0 {
+		return nil, "", fmt.Errorf("no network interface with an IPv4 address is up")
+	}
+	targets := make([]pairTarget, 0, len(addrs))
+	for _, a := range addrs {
+		targets = append(targets, pairTarget{
+			Interface: a.Interface,
+			URL:       u.Scheme + "://" + net.JoinHostPort(a.IP.String(), port),
+		})
+	}
+	return targets, rt.TLSFingerprint, nil
+}
+
+// lanAddress is an address of a network interface
+type lanAddress struct {
+	Interface string
+	IP        net.IP
+}
+
+// lanAddresses returns the IPv4 addresses of the connected, non-loopback
+// network interfaces
+func lanAddresses() ([]lanAddress, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+	var addrs []lanAddress
+	for _, iface := range ifaces {
+		// Interfaces without a link, such as an unused docker0, are not running
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagRunning == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		ifaceAddrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range ifaceAddrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip := ipNet.IP.To4()
+			if ip == nil || ip.IsLinkLocalUnicast() {
+				continue
+			}
+			addrs = append(addrs, lanAddress{Interface: iface.Name, IP: ip})
+		}
+	}
+	return addrs, nil
+}
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ibrahim/remote-vibecode/internal/client"
+	"github.com/ibrahim/remote-vibecode/internal/daemon"
+)
+
+// ServerURL and ServerToken are the --server and --token flags shared by all commands
+var (
+	ServerURL   string
+	ServerToken string
+)
+
+// serverProbeTimeout bounds the health check used to decide whether a server is running
+const serverProbeTimeout = time.Second
+
+// serverTarget returns a client for the server to use and whether it was
+// chosen explicitly (--server or $RVC_SERVER). Otherwise the local server is
+// found through its runtime file, and nil is returned if none is running.
+func serverTarget() (*client.Client, bool, error) {
+	url := ServerURL
+	if url == "" {
+		url = os.Getenv("RVC_SERVER")
+	}
+	if url == "" {
+		rt, err := daemon.Running()
+		if errors.Is(err, daemon.ErrNotRunning) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return runtimeClient(rt), false, nil
+	}
+
+	cfg, _, err := LoadConfig()
+	if err != nil {
+		return nil, false, err
+	}
+	token := cfg.Auth.Token
+	if ServerToken != "" {
+		token = ServerToken
+	}
+	return client.New(url, token), true, nil
+}
+
+// runtimeClient returns a client for the local server described by rt. It
+// authenticates with the control token unless --token is given and trusts
+// exactly the server's certificate.
+func runtimeClient(rt *daemon.Runtime) *client.Client {
+	token := rt.ControlToken
+	if ServerToken != "" {
+		token = ServerToken
+	}
+	c := client.New(rt.URL, token)
+	if rt.TLSFingerprint != "" {
+		c.PinCertificate(rt.TLSFingerprint)
+	}
+	return c
+}
+
+// connectServer returns a client for the rvc server if one is reachable.
+// When no server was chosen explicitly and none answers, it returns nil so
+// the caller can fall back to local tmux; an explicit server must answer.
+func connectServer() (*client.Client, error) {
+	c, explicit, err := serverTarget()
+	if err != nil || c == nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), serverProbeTimeout)
+	defer cancel()
+	if _, err := c.Health(ctx); err != nil {
+		if explicit {
+			return nil, fmt.Errorf("rvc server at %s is not reachable: %w", c.BaseURL, err)
+		}
+		return nil, nil
+	}
+	return c, nil
+}
+
+// serverContext returns a context for a single API call
+func serverContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 30*time.Second)
+}
+
+// serverSessionNames returns the names of the sessions tracked by a server
+func serverSessionNames(c *client.Client) ([]string, error) {
+	ctx, cancel := serverContext()
+	defer cancel()
+	sessions, err := c.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		names = append(names, s.SessionName)
+	}
+	return names, nil
+}
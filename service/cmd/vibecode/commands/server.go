@@ -0,0 +1,427 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ibrahim/remote-vibecode/internal/client"
+	"github.com/ibrahim/remote-vibecode/internal/daemon"
+)
+
+const (
+	// serverStartTimeout bounds how long start and restart wait for the server to answer
+	serverStartTimeout = 10 * time.Second
+	// serverStopTimeout bounds how long stop waits for the server to exit
+	serverStopTimeout = 10 * time.Second
+	// systemdUnitName is the name of the unit written by install-systemd
+	systemdUnitName = "rvc.service"
+)
+
+var (
+	serverStatusOutput string
+	systemdUser        bool
+	systemdPrint       bool
+)
+
+var ServerCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Manage the background rvc server",
+	Long: `Manage the rvc server started with 'rvc serve --daemon' (or by systemd).
+
+The server describes itself in a runtime file in the rvc state directory
+(~/.local/state/rvc/server.json); a background server logs to server.log
+next to it.`,
+}
+
+var serverStatusCmd = &cobra.Command{
+	Use:          "status",
+	Short:        "Show whether the server is running, its address, uptime, sessions and clients",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runServerStatus,
+}
+
+var serverStopCmd = &cobra.Command{
+	Use:          "stop",
+	Short:        "Stop the server",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rt, err := daemon.Running()
+		if err != nil {
+			return err
+		}
+		if err := daemon.Stop(rt.PID, serverStopTimeout); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Stopped rvc server (pid %d)\n", rt.PID)
+		return nil
+	},
+}
+
+var serverRestartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Restart the server in place",
+	Long: `Restart the server in place, keeping its pid, command line and log.
+Open terminals are disconnected and reconnect; tmux sessions keep running.
+To only reload the config file, send SIGHUP instead.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rt, err := daemon.Running()
+		if err != nil {
+			return fmt.Errorf("%w; start it with: rvc serve --daemon", err)
+		}
+
+		requested := time.Now()
+		if err := daemon.Restart(rt.PID); err != nil {
+			return err
+		}
+		c, err := waitForServer(rt.PID, requested, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Restarted rvc server (pid %d) at %s\n", rt.PID, c.BaseURL)
+		return nil
+	},
+}
+
+var serverInstallSystemdCmd = &cobra.Command{
+	Use:   "install-systemd [-- serve flags...]",
+	Short: "Install and enable a systemd unit that runs the server",
+	Long: `Write a systemd unit that runs 'rvc serve', then enable and start it.
+
+With --user the unit is installed for the current user
+(~/.config/systemd/user) and lingering is enabled, so the server survives
+logouts and starts at boot. Without it a system unit is written to
+/etc/systemd/system, which requires root.
+
+Arguments after -- are passed to 'rvc serve'. Stopping the unit stops only
+the server; tmux sessions keep running.`,
+	SilenceUsage: true,
+	RunE:         runInstallSystemd,
+}
+
+func init() {
+	addOutputFlag(serverStatusCmd, &serverStatusOutput)
+	serverInstallSystemdCmd.Flags().BoolVar(&systemdUser, "user", false, "Install a user unit instead of a system unit")
+	serverInstallSystemdCmd.Flags().BoolVar(&systemdPrint, "print", false, "Print the unit instead of installing it")
+
+	ServerCmd.AddCommand(serverStatusCmd)
+	ServerCmd.AddCommand(serverStopCmd)
+	ServerCmd.AddCommand(serverRestartCmd)
+	ServerCmd.AddCommand(serverInstallSystemdCmd)
+}
+
+// StartDaemon starts 'rvc' with args (a serve command line) in the
+// background and waits until the server answers
+func StartDaemon(args []string) error {
+	if err := daemon.CheckNotRunning(); err != nil {
+		return err
+	}
+	logPath, err := daemon.LogFile()
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	proc, exited, err := daemon.Start(args)
+	if err != nil {
+		return err
+	}
+	c, err := waitForServer(proc.Pid, started, exited)
+	if err != nil {
+		return fmt.Errorf("%w; see %s", err, logPath)
+	}
+
+	fmt.Printf("✓ rvc server started in the background (pid %d)\n", proc.Pid)
+	fmt.Printf("  URL:  %s\n", c.BaseURL)
+	fmt.Printf("  Logs: %s\n", logPath)
+	fmt.Printf("Stop it with: rvc server stop\n")
+	return nil
+}
+
+// serveArgs returns the command line that starts a server with the same
+// config file and tmux server as this command
+func serveArgs() []string {
+	args := []string{"serve"}
+	if ConfigFile != "" {
+		args = append(args, "--config", ConfigFile)
+	}
+	if TmuxSocketName != "" {
+		args = append(args, "--socket-name", TmuxSocketName)
+	}
+	if TmuxSocketPath != "" {
+		args = append(args, "--socket-path", TmuxSocketPath)
+	}
+	return args
+}
+
+// waitForServer waits until the server with pid has written its runtime
+// file (after since) and answers its health check. It gives up when the
+// process exits or serverStartTimeout elapses.
+func waitForServer(pid int, since time.Time, exited <-chan error) (*client.Client, error) {
+	deadline := time.Now().Add(serverStartTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-exited:
+			if err == nil {
+				err = errors.New("exit status 0")
+			}
+			return nil, fmt.Errorf("server exited during startup: %w", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		rt, err := daemon.Running()
+		if err != nil || rt.PID != pid || rt.StartedAt.Before(since) {
+			continue
+		}
+		c := runtimeClient(rt)
+		ctx, cancel := context.WithTimeout(context.Background(), serverProbeTimeout)
+		_, err = c.Health(ctx)
+		cancel()
+		if err == nil {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("server did not start within %s", serverStartTimeout)
+}
+
+// serverStatus is the status of the server, also used for --output
+type serverStatus struct {
+	Running         bool       `json:"running"`
+	PID             int        `json:"pid,omitempty"`
+	URL             string     `json:"url,omitempty"`
+	TLSFingerprint  string     `json:"tls_fingerprint,omitempty"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	Sessions        *int       `json:"sessions,omitempty"`
+	Viewers         *int       `json:"viewers,omitempty"`          // web and rvc attach clients
+	TerminalClients *int       `json:"terminal_clients,omitempty"` // tmux clients, e.g. rvc join
+	Log             string     `json:"log,omitempty"`
+	Error           string     `json:"error,omitempty"` // why the details are missing
+}
+
+func runServerStatus(cmd *cobra.Command, args []string) error {
+	isStructured, err := structured(serverStatusOutput)
+	if err != nil {
+		return err
+	}
+
+	status := serverStatus{}
+	rt, err := daemon.Running()
+	if err != nil && !errors.Is(err, daemon.ErrNotRunning) {
+		return err
+	}
+	if err == nil {
+		status.Running = true
+		status.PID = rt.PID
+		status.URL = rt.URL
+		status.TLSFingerprint = rt.TLSFingerprint
+		status.Error = fillServerStatus(runtimeClient(rt), &status)
+		if logPath, err := daemon.LogFile(); err == nil {
+			if _, err := os.Stat(logPath); err == nil {
+				status.Log = logPath
+			}
+		}
+	}
+
+	if isStructured {
+		return writeStructured(os.Stdout, serverStatusOutput, status)
+	}
+	if !status.Running {
+		fmt.Println("rvc server is not running")
+		fmt.Println("Start it with: rvc serve --daemon")
+		return nil
+	}
+
+	fmt.Printf("rvc server is running (pid %d)\n", status.PID)
+	fmt.Printf("  URL:      %s\n", status.URL)
+	if status.TLSFingerprint != "" {
+		fmt.Printf("  TLS:      SHA-256 %s\n", status.TLSFingerprint)
+	}
+	if status.StartedAt != nil {
+		fmt.Printf("  Uptime:   %s (since %s)\n", time.Since(*status.StartedAt).Round(time.Second), formatTime(*status.StartedAt))
+	}
+	if status.Sessions != nil {
+		fmt.Printf("  Sessions: %d\n", *status.Sessions)
+		fmt.Printf("  Clients:  %d viewers, %d terminal clients\n", *status.Viewers, *status.TerminalClients)
+	}
+	if status.Log != "" {
+		fmt.Printf("  Log:      %s\n", status.Log)
+	}
+	if status.Error != "" {
+		fmt.Printf("  Warning:  could not query the server: %s\n", status.Error)
+	}
+	return nil
+}
+
+// fillServerStatus adds what the server reports about itself to status and
+// returns a description of the first failure, if any
+func fillServerStatus(c *client.Client, status *serverStatus) string {
+	ctx, cancel := serverContext()
+	defer cancel()
+	health, err := c.Health(ctx)
+	if err != nil {
+		return err.Error()
+	}
+	status.StartedAt = &health.StartedAt
+
+	sessions, err := c.ListSessions(ctx)
+	if err != nil {
+		return err.Error()
+	}
+	count, viewers, terminals := len(sessions), 0, 0
+	for _, s := range sessions {
+		viewers += s.Viewers
+		if s.Tmux != nil {
+			terminals += s.Tmux.Attached
+		}
+	}
+	// Every viewer is also a tmux client of its own
+	terminals = max(terminals-viewers, 0)
+	status.Sessions, status.Viewers, status.TerminalClients = &count, &viewers, &terminals
+	return ""
+}
+
+func runInstallSystemd(cmd *cobra.Command, args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+
+	serveArgs := []string{exe, "serve"}
+	if ConfigFile != "" {
+		configPath, err := filepath.Abs(ConfigFile)
+		if err != nil {
+			return err
+		}
+		serveArgs = append(serveArgs, "--config", configPath)
+	}
+	serveArgs = append(serveArgs, args...)
+
+	current, err := user.Current()
+	if err != nil {
+		return err
+	}
+	unit := systemdUnit(serveArgs, current.Username, systemdUser)
+	if systemdPrint {
+		fmt.Print(unit)
+		return nil
+	}
+
+	unitDir := "/etc/systemd/system"
+	if systemdUser {
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			configHome = filepath.Join(current.HomeDir, ".config")
+		}
+		unitDir = filepath.Join(configHome, "systemd", "user")
+	}
+	if err := os.MkdirAll(unitDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", unitDir, err)
+	}
+	unitPath := filepath.Join(unitDir, systemdUnitName)
+	if err := os.WriteFile(unitPath, []byte(unit), 0o644); err != nil {
+		return fmt.Errorf("failed to write the unit: %w", err)
+	}
+	fmt.Printf("✓ Wrote %s\n", unitPath)
+
+	systemctl := func(args ...string) error {
+		if systemdUser {
+			args = append([]string{"--user"}, args...)
+		}
+		out, err := exec.Command("systemctl", args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("systemctl %s failed: %w\n%s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	if err := systemctl("enable", "--now", systemdUnitName); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Enabled and started %s\n", systemdUnitName)
+
+	if systemdUser {
+		// Without lingering, user units stop at logout and only start at login
+		if out, err := exec.Command("loginctl", "enable-linger", current.Username).CombinedOutput(); err != nil {
+			fmt.Printf("⚠ Could not enable lingering (%s); the server will stop when you log out.\n", strings.TrimSpace(string(out)))
+			fmt.Printf("  Ask an administrator to run: loginctl enable-linger %s\n", current.Username)
+		} else {
+			fmt.Println("✓ Enabled lingering, so the server runs without a login session")
+		}
+	}
+
+	scope := ""
+	if systemdUser {
+		scope = "--user "
+	}
+	fmt.Printf("Check it with: systemctl %sstatus %s\n", scope, systemdUnitName)
+	return nil
+}
+
+// systemdUnit renders the unit file for running the server with args
+func systemdUnit(args []string, username string, userUnit bool) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = systemdQuote(arg)
+	}
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Remote Vibecode server\n")
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("Wants=network-online.target\n\n")
+
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=simple\n")
+	if !userUnit {
+		fmt.Fprintf(&b, "User=%s\n", username)
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(quoted, " "))
+	b.WriteString("ExecReload=/bin/kill -HUP $MAINPID\n")
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=2\n")
+	// tmux servers started through the API live in this unit's cgroup; only
+	// stop rvc itself so sessions survive a stop or restart of the unit
+	b.WriteString("KillMode=process\n")
+	// tmux and agent commands are found with the PATH of the installing shell
+	for _, name := range []string{"PATH", "XDG_CONFIG_HOME", "XDG_STATE_HOME", "TMUX_TMPDIR"} {
+		if value := os.Getenv(name); value != "" {
+			fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(name+"="+value))
+		}
+	}
+	b.WriteString("\n[Install]\n")
+	if userUnit {
+		b.WriteString("WantedBy=default.target\n")
+	} else {
+		b.WriteString("WantedBy=multi-user.target\n")
+	}
+	return b.String()
+}
+
+// systemdQuote quotes a word for a unit file, escaping % specifiers
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\$;") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "$", "$$")
+	return `"` + s + `"`
+}
@@ -3,18 +3,39 @@ package commands
 import (
 	"fmt"
 	"os"
-	"os/exec"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/ibrahim/remote-vibecode/internal/tmux"
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
 )
 
+// TmuxSocketName and TmuxSocketPath are the -L and -S flags shared by all
+// commands; like tmux's own options, they select the tmux server to use
+var (
+	TmuxSocketName string
+	TmuxSocketPath string
+)
+
+// SelectTmuxServers points the tmux client at the server chosen with -L or
+// -S and at the sockets under discovery.sockets, so "session@label" names
+// work locally too. A broken config is reported by the commands that need it.
+func SelectTmuxServers() {
+	tmuxclient.SetDefault(tmuxclient.Client{SocketName: TmuxSocketName, SocketPath: TmuxSocketPath})
+	if cfg, _, err := LoadConfig(); err == nil && cfg.Validate() == nil {
+		tmuxclient.SetServers(cfg.Discovery.TmuxServers())
+	}
+}
+
 // attachSession attaches the current terminal to a tmux session
 func attachSession(sessionName string) error {
 	fmt.Printf("\nSession continues running after you detach.\n")
 	fmt.Printf("Rejoin with: rvc join %s\n\n", sessionName)
 
-	cmd := exec.Command("tmux", "attach-session", "-t", sessionName)
+	c, name, err := tmuxclient.Resolve(sessionName)
+	if err != nil {
+		return err
+	}
+	cmd := c.TerminalCommand("attach-session", "-t", name)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -28,7 +49,11 @@ func promptSessionSelection() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to list sessions: %w", err)
 	}
+	return selectSession(sessions)
+}
 
+// selectSession prompts the user to pick one of the given session names
+func selectSession(sessions []string) (string, error) {
 	if len(sessions) == 0 {
 		return "", fmt.Errorf("no tmux sessions found")
 	}
@@ -38,7 +63,7 @@ func promptSessionSelection() (string, error) {
 		Message: "Select a session:",
 		Options: sessions,
 	}
-	err = survey.AskOne(prompt, &sessionName)
+	err := survey.AskOne(prompt, &sessionName)
 	if err != nil {
 		return "", fmt.Errorf("prompt failed: %w", err)
 	}
@@ -3,68 +3,308 @@ package commands
 import (
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
 	"github.com/ibrahim/remote-vibecode/cmd/vibecode/internal/banner"
+	"github.com/ibrahim/remote-vibecode/internal/client"
+	"github.com/ibrahim/remote-vibecode/internal/daemon"
+	"github.com/ibrahim/remote-vibecode/internal/profile"
 	"github.com/ibrahim/remote-vibecode/internal/tmux"
 )
 
 var StartCmd = &cobra.Command{
-	Use:   "start",
+	Use:   "start [name] [-- command [args...]]",
 	Short: "Start a new rvc session",
 	Long: `Start a new rvc tmux session with custom configuration.
-The session will have a distinctive status bar and startup banner.`,
+The session will have a distinctive status bar and startup banner.
+
+Profiles defined in ~/.config/rvc/profiles.yaml set the command, working
+directory, environment, windows, triggers and recording of the session.
+The "default" profile is used when --profile is not given; --cwd, --env
+and a command after "--" override it.`,
+	Example: `  rvc start
+  rvc start -w myproj
+  rvc start --profile agent myproj
+  rvc start api -w --cwd ~/src/api --env DEBUG=1 -- claude
+  rvc start --name nightly --detach -- ./run-tests.sh`,
+	Args: startArgs,
 	RunE: runStart,
 }
 
-var writableFlag bool
+var (
+	writableFlag bool
+	profileFlag  string
+	nameFlag     string
+	cwdFlag      string
+	envFlag      []string
+	detachFlag   bool
+	startOutput  string
+)
 
 func init() {
 	StartCmd.Flags().BoolVarP(&writableFlag, "writable", "w", false, "Create a writable session (web clients can type)")
+	StartCmd.Flags().StringVarP(&profileFlag, "profile", "p", "", "Profile from ~/.config/rvc/profiles.yaml to start the session with")
+	StartCmd.Flags().StringVarP(&nameFlag, "name", "n", "", "Session name (same as the positional name)")
+	StartCmd.Flags().StringVar(&cwdFlag, "cwd", "", "Working directory of the session")
+	StartCmd.Flags().StringArrayVarP(&envFlag, "env", "e", nil, "Environment variable KEY=VAL for the session (repeatable)")
+	StartCmd.Flags().BoolVarP(&detachFlag, "detach", "d", false, "Start the session without attaching to it")
+	addOutputFlag(StartCmd, &startOutput)
+}
+
+// startResult is the --output representation of a started session
+type startResult struct {
+	*tmux.SessionSummary `yaml:",inline"`
+	Profile              string `json:"profile,omitempty"`
+}
+
+// startArgs allows at most one name before "--" and any command after it
+func startArgs(cmd *cobra.Command, args []string) error {
+	if n := len(startNameArgs(cmd, args)); n > 1 {
+		return fmt.Errorf("accepts at most 1 session name, received %d (put the command after \"--\")", n)
+	}
+	return nil
+}
+
+// startNameArgs returns the arguments before "--"
+func startNameArgs(cmd *cobra.Command, args []string) []string {
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		return args[:dash]
+	}
+	return args
+}
+
+// startCommand returns the command after "--" as a single shell command.
+// A lone argument is passed through so "rvc start -- 'make watch'" works.
+func startCommand(cmd *cobra.Command, args []string) string {
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 || dash == len(args) {
+		return ""
+	}
+	command := args[dash:]
+	if len(command) == 1 {
+		return command[0]
+	}
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote quotes an argument for sh unless it only has safe characters
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,@%+") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// applyStartFlags overrides a profile with --cwd, --env, -w and the command
+func applyStartFlags(cmd *cobra.Command, args []string, prof *profile.Profile) error {
+	if cmd.Flags().Changed("writable") {
+		prof.Writable = writableFlag
+	}
+	if cwdFlag != "" {
+		prof.Cwd = cwdFlag
+		// Sessions need an absolute directory; resolve relative ones here
+		if !filepath.IsAbs(cwdFlag) && !strings.HasPrefix(cwdFlag, "~") {
+			abs, err := filepath.Abs(cwdFlag)
+			if err != nil {
+				return err
+			}
+			prof.Cwd = abs
+		}
+	}
+	if command := startCommand(cmd, args); command != "" {
+		prof.Command = command
+	}
+	if len(envFlag) > 0 {
+		env, err := parseEnvFlags()
+		if err != nil {
+			return err
+		}
+		for k, v := range prof.Env {
+			if _, ok := env[k]; !ok {
+				env[k] = v
+			}
+		}
+		prof.Env = env
+	}
+	return nil
+}
+
+// parseEnvFlags parses the KEY=VAL pairs given with --env
+func parseEnvFlags() (map[string]string, error) {
+	env := make(map[string]string, len(envFlag))
+	for _, kv := range envFlag {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --env %q: expected KEY=VAL", kv)
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+// startSessionName returns the name given as an argument or with --name, or ""
+func startSessionName(cmd *cobra.Command, args []string) (string, error) {
+	sessionName := nameFlag
+	if names := startNameArgs(cmd, args); len(names) > 0 {
+		if sessionName != "" && sessionName != names[0] {
+			return "", fmt.Errorf("session name given twice: %q and --name %q", names[0], sessionName)
+		}
+		sessionName = names[0]
+	}
+	return sessionName, nil
+}
+
+// runStartRemote creates a session on the server chosen with --server, from
+// one of the server's profiles or from the command-line options. The
+// session runs on the server's machine, so it is never attached here.
+func runStartRemote(cmd *cobra.Command, args []string, isStructured bool) error {
+	c, err := connectServer()
+	if err != nil {
+		return err
+	}
+	sessionName, err := startSessionName(cmd, args)
+	if err != nil {
+		return err
+	}
+	if sessionName == "" {
+		sessionName = defaultSessionName()
+	}
+
+	ctx, cancel := serverContext()
+	defer cancel()
+	if profileFlag != "" {
+		_, err = c.StartProfile(ctx, profileFlag, sessionName)
+	} else {
+		var env map[string]string
+		if env, err = parseEnvFlags(); err != nil {
+			return err
+		}
+		_, err = c.CreateSession(ctx, client.CreateSessionRequest{
+			Name:     sessionName,
+			Command:  startCommand(cmd, args),
+			Cwd:      cwdFlag,
+			Writable: writableFlag,
+			Env:      env,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if isStructured {
+		result, err := listServerSessions(c)
+		if err != nil {
+			return err
+		}
+		for _, s := range result.Sessions {
+			if s.Name == sessionName {
+				return writeStructured(cmd.OutOrStdout(), startOutput, startResult{SessionSummary: &s.SessionSummary, Profile: profileFlag})
+			}
+		}
+		return fmt.Errorf("session '%s' was created but is not tracked by %s", sessionName, c.BaseURL)
+	}
+
+	fmt.Printf("Session '%s' started on %s\n", sessionName, c.BaseURL)
+	return nil
 }
 
 func runStart(cmd *cobra.Command, args []string) error {
+	// Structured output is for scripts, so it never attaches
+	isStructured, err := structured(startOutput)
+	if err != nil {
+		return err
+	}
+	detach := detachFlag || isStructured
+
+	// With an explicit --server the session is created there instead
+	if _, explicit, err := serverTarget(); err != nil {
+		return err
+	} else if explicit {
+		return runStartRemote(cmd, args, isStructured)
+	}
+
 	// Check if tmux is installed
 	if err := checkTmuxInstalled(); err != nil {
 		return err
 	}
 
-	// Optional: Check if service is running (warn if not)
-	checkServiceRunning()
+	// Warn (and offer to start the server) if it is not running
+	checkServiceRunning(!detach)
 
-	// Prompt for session name
-	sessionName, err := promptSessionName()
+	cfg, err := applyCLIConfig()
 	if err != nil {
 		return err
 	}
+	prof, err := loadStartProfile(cfg.Profiles.File)
+	if err != nil {
+		return err
+	}
+	if err := applyStartFlags(cmd, args, &prof); err != nil {
+		return err
+	}
 
-	// Create the session
-	if err := tmux.CreateSession(sessionName); err != nil {
+	// Use the given session name, or prompt for one unless detaching
+	sessionName, err := startSessionName(cmd, args)
+	if err != nil {
+		return err
+	}
+	switch {
+	case sessionName != "":
+		err = validateNewSessionName(sessionName)
+	case detach:
+		sessionName = defaultSessionName()
+		err = validateNewSessionName(sessionName)
+	default:
+		sessionName, err = promptSessionName()
+	}
+	if err != nil {
 		return err
 	}
 
-	// Set writable flag if -w was provided
-	if writableFlag {
-		if err := tmux.SetWritable(sessionName, true); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to set writable flag: %v\n", err)
+	// Create the session with its status bar, layout and triggers
+	if err := profile.Start(sessionName, prof); err != nil {
+		if !tmux.SessionExists(sessionName) {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: session created but not fully set up: %v\n", err)
+	}
+
+	if isStructured {
+		summary, err := tmux.GetSessionSummary(sessionName)
+		if err != nil {
+			return err
 		}
+		return writeStructured(cmd.OutOrStdout(), startOutput, startResult{SessionSummary: summary, Profile: profileFlag})
+	}
+
+	if prof.Writable {
 		fmt.Printf("Session '%s' is WRITABLE (web clients can type)\n", sessionName)
 	} else {
 		fmt.Printf("Session '%s' is READ-ONLY (use -w flag for writable)\n", sessionName)
 	}
 
-	// Set status bar
-	if err := setStatusLine(sessionName); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to set status line: %v\n", err)
+	if detach {
+		fmt.Printf("Session '%s' started in the background.\nAttach with: rvc join %s\n", sessionName, sessionName)
+		return nil
 	}
 
 	// Show banner
+	serverURL := ""
+	if rt, err := daemon.Running(); err == nil {
+		serverURL = rt.URL
+	}
 	fmt.Println()
-	fmt.Print(banner.String(sessionName))
+	fmt.Print(banner.String(sessionName, serverURL))
 
 	// Show progress and wait
 	fmt.Printf("\n► Starting %s rvc session", sessionName)
@@ -78,102 +318,89 @@ func runStart(cmd *cobra.Command, args []string) error {
 	return attachSession(sessionName)
 }
 
+// loadStartProfile returns the profile named by --profile, the "default"
+// profile if one is defined, or an empty profile (a plain shell). Profiles
+// are read from path, or ~/.config/rvc/profiles.yaml if it is empty.
+func loadStartProfile(path string) (profile.Profile, error) {
+	if path == "" {
+		var err error
+		if path, err = profile.DefaultPath(); err != nil {
+			return profile.Profile{}, err
+		}
+	}
+	profiles, err := profile.Load(path)
+	if err != nil {
+		return profile.Profile{}, err
+	}
+
+	if profileFlag == "" {
+		return profiles[profile.DefaultName], nil
+	}
+	prof, err := profiles.Get(profileFlag)
+	if err != nil {
+		return profile.Profile{}, fmt.Errorf("%w (defined: %s)", err, strings.Join(profiles.Names(), ", "))
+	}
+	return prof, nil
+}
+
 func checkTmuxInstalled() error {
-	cmd := exec.Command("tmux", "-V")
-	if err := cmd.Run(); err != nil {
+	if _, err := tmux.Version(); err != nil {
 		return fmt.Errorf("tmux not installed.\n\nInstall with:\n  macOS: brew install tmux\n  Linux (apt): sudo apt install tmux\n  Linux (yum): sudo yum install tmux\n  Linux (dnf): sudo dnf install tmux")
 	}
 	return nil
 }
 
-func checkServiceRunning() {
-	// Try to connect to the service
-	cmd := exec.Command("curl", "-s", "-o", "/dev/null", "-w", "%{http_code}", "http://localhost:8080/api/v1/health")
-	output, err := cmd.Output()
-	if err != nil || string(output) != "200" {
-		fmt.Fprintf(os.Stderr, "⚠ Warning: rvc servcer may not be running.\n")
-		fmt.Fprintf(os.Stderr, "  Start it with: rvc servce\n\n")
+// checkServiceRunning warns when no rvc server is running, since the
+// session is then not shown in the browser, and offers to start one when
+// the user can answer
+func checkServiceRunning(interactive bool) {
+	if _, err := daemon.Running(); err == nil {
+		return
 	}
+	fmt.Fprintf(os.Stderr, "⚠ Warning: the rvc server is not running, so the session will not be shown in the browser.\n")
+	if !interactive || !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintf(os.Stderr, "  Start it with: rvc serve --daemon\n\n")
+		return
+	}
+
+	start := true
+	prompt := &survey.Confirm{Message: "Start it in the background now?", Default: true}
+	if err := survey.AskOne(prompt, &start, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)); err != nil || !start {
+		fmt.Fprintln(os.Stderr)
+		return
+	}
+	if err := StartDaemon(serveArgs()); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not start the server: %v\n", err)
+	}
+	fmt.Println()
 }
 
-func promptSessionName() (string, error) {
-	defaultName := fmt.Sprintf("rvc-%d", time.Now().Unix())
+// defaultSessionName returns a timestamped name for unnamed sessions
+func defaultSessionName() string {
+	return fmt.Sprintf("rvc-%d", time.Now().Unix())
+}
 
+func promptSessionName() (string, error) {
 	var sessionName string
 	prompt := &survey.Input{
 		Message: "Session name:",
-		Default: defaultName,
+		Default: defaultSessionName(),
 	}
 	err := survey.AskOne(prompt, &sessionName, survey.WithValidator(survey.Required))
 	if err != nil {
 		return "", fmt.Errorf("prompt failed: %w", err)
 	}
 
-	// Validate session name
+	return sessionName, validateNewSessionName(sessionName)
+}
+
+// validateNewSessionName checks that a name is valid and not already taken
+func validateNewSessionName(sessionName string) error {
 	if !tmux.IsValidSessionName(sessionName) {
-		return "", fmt.Errorf("invalid session name: must contain only letters, numbers, hyphens, and underscores")
+		return fmt.Errorf("invalid session name: must contain only letters, numbers, hyphens, and underscores")
 	}
-
-	// Check if session already exists
 	if tmux.SessionExists(sessionName) {
-		return "", fmt.Errorf("session '%s' already exists. Use 'rvc join %s' to connect.", sessionName, sessionName)
-	}
-
-	return sessionName, nil
-}
-
-func setStatusLine(sessionName string) error {
-	// Set the global status line options (not session-specific)
-	statusConfig := []string{
-		"set-option -g status-left '#[bg=green]#[fg=black] RV #[default] #{session_name} '",
-		"set-option -g status-right '%H:%M %d-%b-%y'",
-		"set-option -g status-bg '#1a1a2e'",
-		"set-option -g status-fg '#eee8aa'",
-		"set-option -g status-intervcal 1",
-	}
-
-	for _, cfg := range statusConfig {
-		cmd := exec.Command("tmux", "set-option", "-g")
-		// Parse the config string into arguments
-		// set-option -g status-left '...'
-		// becomes: ["set-option", "-g", "status-left", "..."]
-		args := []string{"set-option", "-g"}
-		parts := quoteSplit(cfg[len("set-option -g "):])
-		args = append(args, parts...)
-
-		cmd = exec.Command("tmux", args...)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to run: tmux %s: %w", cfg, err)
-		}
+		return fmt.Errorf("session '%s' already exists. Use 'rvc join %s' to connect.", sessionName, sessionName)
 	}
 	return nil
 }
-
-// quoteSplit splits a string by spaces, respecting quotes
-func quoteSplit(s string) []string {
-	var result []string
-	var current string
-	var inQuote bool
-	var quoteChar rune
-
-	for _, r := range s {
-		switch {
-		case (r == '\'' || r == '"') && !inQuote:
-			inQuote = true
-			quoteChar = r
-		case r == quoteChar && inQuote:
-			inQuote = false
-		case r == ' ' && !inQuote:
-			if current != "" {
-				result = append(result, current)
-				current = ""
-			}
-		default:
-			current += string(r)
-		}
-	}
-	if current != "" {
-		result = append(result, current)
-	}
-	return result
-}
@@ -2,6 +2,8 @@ package commands
 
 import (
 	"fmt"
+	"os"
+	"slices"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
@@ -10,7 +12,8 @@ import (
 )
 
 var (
-	forceStop bool
+	forceStop  bool
+	stopOutput string
 )
 
 var StopCmd = &cobra.Command{
@@ -22,17 +25,45 @@ var StopCmd = &cobra.Command{
 
 func init() {
 	StopCmd.Flags().BoolVarP(&forceStop, "force", "f", false, "Skip confirmation prompt")
+	addOutputFlag(StopCmd, &stopOutput)
+}
+
+// stopResult is the --output representation of a stopped session
+type stopResult struct {
+	Name    string `json:"name"`
+	Stopped bool   `json:"stopped"`
 }
 
 func runStop(cmd *cobra.Command, args []string) error {
+	isStructured, err := structured(stopOutput)
+	if err != nil {
+		return err
+	}
+
+	// Stop through the server when one is running, so the stop is audited
+	c, err := connectServer()
+	if err != nil {
+		return err
+	}
+	var sessions []string
+	if c != nil {
+		sessions, err = serverSessionNames(c)
+	} else {
+		sessions, err = tmux.ListSessions()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
 	var sessionName string
 
 	if len(args) > 0 {
 		sessionName = args[0]
+	} else if isStructured {
+		return fmt.Errorf("a session name is required with --output %s", stopOutput)
 	} else {
 		// Prompt for session selection
-		var err error
-		sessionName, err = promptSessionSelection()
+		sessionName, err = selectSession(sessions)
 		if err != nil {
 			return err
 		}
@@ -44,31 +75,46 @@ func runStop(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check if session exists
-	if !tmux.SessionExists(sessionName) {
+	if !slices.Contains(sessions, sessionName) {
 		return fmt.Errorf("session '%s' does not exist. Use 'rvc list' to see available sessions.", sessionName)
 	}
 
-	// Confirm before killing (unless --force)
+	// Confirm before killing (unless --force). With structured output the
+	// prompt goes to stderr so stdout stays parseable.
 	if !forceStop {
 		confirmed := false
 		prompt := &survey.Confirm{
 			Message: fmt.Sprintf("Are you sure you want to stop session '%s'?", sessionName),
 			Default: false,
 		}
-		if err := survey.AskOne(prompt, &confirmed); err != nil {
+		if err := survey.AskOne(prompt, &confirmed, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)); err != nil {
 			return err
 		}
 		if !confirmed {
+			if isStructured {
+				return writeStructured(cmd.OutOrStdout(), stopOutput, stopResult{Name: sessionName})
+			}
 			fmt.Println("Cancelled.")
 			return nil
 		}
 	}
 
 	// Kill the session
-	if err := tmux.KillSession(sessionName); err != nil {
+	if c != nil {
+		ctx, cancel := serverContext()
+		defer cancel()
+		err = c.DeleteSession(ctx, sessionName)
+	} else {
+		err = tmux.KillSession(sessionName)
+	}
+	if err != nil {
 		return err
 	}
 
+	if isStructured {
+		return writeStructured(cmd.OutOrStdout(), stopOutput, stopResult{Name: sessionName, Stopped: true})
+	}
+
 	fmt.Printf("✓ Stopped session: %s\n", sessionName)
 	return nil
 }
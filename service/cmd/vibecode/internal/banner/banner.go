@@ -2,8 +2,13 @@ package banner
 
 import "fmt"
 
-// String returns the vibecode startup banner with session name
-func String(sessionName string) string {
+// String returns the vibecode startup banner with session name and the URL
+// of the server showing it; the URL line is left out when url is empty
+func String(sessionName, url string) string {
+	access := ""
+	if url != "" {
+		access = "Access at: " + url + "\n"
+	}
 	return fmt.Sprintf(`.................................................
 .#####...######..##...##...####...######..######.               
 .##..##..##......###.###..##..##....##....##.....               
@@ -20,7 +25,6 @@ func String(sessionName string) string {
 Remote vibecode session started!
 Session: %s
 Run your coding agent (Claude, opencode, etc.) below
-Access at: http://localhost:8080
-.................................................
-`, sessionName)
+%s.................................................
+`, sessionName, access)
 }
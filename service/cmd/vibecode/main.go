@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"embed"
 	"errors"
 	"fmt"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -16,20 +18,27 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/ibrahim/remote-vibecode/cmd/vibecode/commands"
+	"github.com/ibrahim/remote-vibecode/internal/agent"
 	"github.com/ibrahim/remote-vibecode/internal/api"
+	"github.com/ibrahim/remote-vibecode/internal/audit"
+	"github.com/ibrahim/remote-vibecode/internal/config"
+	"github.com/ibrahim/remote-vibecode/internal/daemon"
+	"github.com/ibrahim/remote-vibecode/internal/federation"
 	gottylib "github.com/ibrahim/remote-vibecode/internal/gotty"
+	"github.com/ibrahim/remote-vibecode/internal/paths"
+	"github.com/ibrahim/remote-vibecode/internal/profile"
+	"github.com/ibrahim/remote-vibecode/internal/push"
 	"github.com/ibrahim/remote-vibecode/internal/tmux"
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
 	"github.com/ibrahim/remote-vibecode/internal/ws"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 //go:embed web
 var webFS embed.FS
 
-const (
-	DefaultHost = "127.0.0.1"
-	DefaultPort = "7676"
-	Banner      = `.................................................
+const Banner = `.................................................
 .#####...######..##...##...####...######..######.
 .##..##..##......###.###..##..##....##....##.....
 .#####...####....##.#.##..##..##....##....####...
@@ -43,48 +52,370 @@ const (
 ...##....######..#####...######...####....####...#####...######.
 ................................................................
 Remote vibecode service listening on %s
-Web UI available at http://%s
+Web UI available at %s
 .................................................
 `
-)
 
 var (
-	serveHost string
-	servePort string
+	serveHost         string
+	servePort         string
+	serveToken        string
+	tlsCert           string
+	tlsKey            string
+	vapidSubject      string
+	clipboard         string
+	discoveryInterval time.Duration
+	autoAttach        []string
+	procInterval      time.Duration
+	agentsFile        string
+	profilesFile      string
+	recordingDir      string
+	serveDaemon       bool
 )
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the rvc web server",
-	Long:  "Start the web server for remote terminal viewing. Use --host and --port to customize.",
-	RunE:  runServe,
+	Long: `Start the web server for remote terminal viewing.
+
+Flags override the config file and RVC_* environment variables (see
+'rvc config'). Send SIGHUP to reload everything except the listen
+address, TLS and --proc-interval; open terminals stay connected.
+
+With --daemon the server runs in the background; manage it with
+'rvc server status|stop|restart'.`,
+	RunE: runServe,
 }
 
 func init() {
-	serveCmd.Flags().StringVar(&serveHost, "host", DefaultHost, "Host to bind to")
-	serveCmd.Flags().StringVar(&servePort, "port", DefaultPort, "Port to listen on")
+	defaults := config.Defaults()
+	serveCmd.Flags().StringVar(&serveHost, "host", defaults.Server.Host, "Host to bind to")
+	serveCmd.Flags().StringVar(&servePort, "port", defaults.Server.Port, "Port to listen on")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Access token required by the web UI and API (default $RVC_TOKEN, empty disables auth)")
+	serveCmd.Flags().StringVar(&tlsCert, "tls-cert", "", "TLS certificate file; serves HTTPS together with --tls-key")
+	serveCmd.Flags().StringVar(&tlsKey, "tls-key", "", "TLS private key file")
+	serveCmd.Flags().StringVar(&vapidSubject, "vapid-subject", defaults.Notifications.VAPIDSubject, "Contact URI (mailto: or https:) sent to Web Push services")
+	serveCmd.Flags().DurationVar(&discoveryInterval, "discovery-interval", time.Duration(defaults.Discovery.Interval), "How often to update session states, and to scan tmux servers that cannot be followed in control mode")
+	serveCmd.Flags().StringSliceVar(&autoAttach, "auto-attach", defaults.Discovery.AutoAttach, "Glob patterns of session names to track")
+	serveCmd.Flags().DurationVar(&procInterval, "proc-interval", time.Duration(defaults.Discovery.ProcInterval), "How often to sample pane processes for CPU and memory stats (0 disables)")
+	serveCmd.Flags().StringVar(&agentsFile, "agents", "", "YAML file with regex agent adapters (default ~/.config/rvc/agents.yaml)")
+	serveCmd.Flags().StringVar(&profilesFile, "profiles", "", "YAML file with session profiles (default ~/.config/rvc/profiles.yaml)")
+	serveCmd.Flags().StringVar(&recordingDir, "recording-dir", "", "Directory for session recordings (default ~/.local/state/rvc/recordings)")
+	serveCmd.Flags().BoolVarP(&serveDaemon, "daemon", "d", false, "Run in the background, logging to the rvc state directory")
+	serveCmd.Flags().StringVar(&clipboard, "clipboard", defaults.Clipboard, "Forward OSC 52 clipboard writes to browsers: off, writable (writable sessions only) or on")
+}
+
+// loadServeConfig layers the flags that were set on the command line over
+// the config file and environment, and validates the result
+func loadServeConfig(cmd *cobra.Command) (*config.Config, error) {
+	cfg, _, err := commands.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		switch f.Name {
+		case "host":
+			cfg.Server.Host = serveHost
+		case "port":
+			cfg.Server.Port = servePort
+		case "token":
+			cfg.Auth.Token = serveToken
+		case "tls-cert":
+			cfg.Server.TLS.Cert = tlsCert
+		case "tls-key":
+			cfg.Server.TLS.Key = tlsKey
+		case "vapid-subject":
+			cfg.Notifications.VAPIDSubject = vapidSubject
+		case "discovery-interval":
+			cfg.Discovery.Interval = config.Duration(discoveryInterval)
+		case "auto-attach":
+			cfg.Discovery.AutoAttach = autoAttach
+		case "proc-interval":
+			cfg.Discovery.ProcInterval = config.Duration(procInterval)
+		case "agents":
+			cfg.Agents.File = agentsFile
+		case "profiles":
+			cfg.Profiles.File = profilesFile
+		case "recording-dir":
+			cfg.Recording.Dir = recordingDir
+		case "clipboard":
+			cfg.Clipboard = clipboard
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
 }
 
 func runServe(cmd *cobra.Command, args []string) error {
 	gin.SetMode(gin.ReleaseMode)
 
-	serverAddr := fmt.Sprintf("%s:%s", serveHost, servePort)
-	fmt.Printf(Banner, serverAddr, serverAddr)
+	cfg, err := loadServeConfig(cmd)
+	if err != nil {
+		return err
+	}
+	// From here on errors are not about the command line
+	cmd.SilenceUsage = true
+	if err := daemon.CheckNotRunning(); err != nil {
+		return err
+	}
+	if serveDaemon && os.Getenv(daemon.ChildEnv) == "" {
+		return commands.StartDaemon(os.Args[1:])
+	}
+
+	serverAddr := net.JoinHostPort(cfg.Server.Host, cfg.Server.Port)
+	scheme := "http"
+	if cfg.Server.TLS.Enabled() {
+		scheme = "https"
+	}
+	fmt.Printf(Banner, serverAddr, scheme+"://"+serverAddr)
 
 	sessionHub := ws.NewSessionHub()
-	tmuxMgr := tmux.New(sessionHub)
+	tmuxMgr := tmux.New(sessionHub, time.Duration(cfg.Discovery.Interval), cfg.Discovery.AutoAttach)
+	tmuxMgr.StartProcessSampling(time.Duration(cfg.Discovery.ProcInterval))
+
 	apiHandlers := api.New()
+	auth := api.NewTokenAuth(cfg.Auth.Token)
+	controlToken, err := daemon.NewControlToken()
+	if err != nil {
+		return err
+	}
+	auth.SetControlToken(controlToken)
 
 	gottyMgr := gottylib.NewManager()
-	gottyHandler := ws.NewGottyHandler(gottyMgr)
-	tmuxHandlers := api.NewTmuxHandlers(tmuxMgr, sessionHub)
+	gottyHandler := ws.NewGottyHandler(gottyMgr, tmuxMgr, ws.ClipboardOn)
+
+	auditPath, err := paths.StateFile("audit.log")
+	if err != nil {
+		return err
+	}
+	auditLog, err := audit.Open(auditPath)
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
+
+	tmuxHandlers := api.NewTmuxHandlers(tmuxMgr, sessionHub, auditLog)
+
+	peers := federation.New(sessionHub)
+	defer peers.Stop()
+
+	pushStore, pushSender, err := newPushSender(cfg.Notifications.VAPIDSubject)
+	if err != nil {
+		return err
+	}
+	pushHandlers := api.NewPushHandlers(pushStore, pushSender)
+	tmuxMgr.OnEvent(func(ev tmux.SessionEvent) {
+		pushSender.Notify(pushEventFor(ev))
+	})
+
+	// applyConfig pushes the settings that can change at runtime into the
+	// running components; it is used at startup and on every SIGHUP
+	applyConfig := func(cfg *config.Config) error {
+		agents, err := newAgentRegistry(cfg.Agents.File)
+		if err != nil {
+			return err
+		}
+		profiles := cfg.Profiles.File
+		if profiles == "" {
+			if profiles, err = profile.DefaultPath(); err != nil {
+				return err
+			}
+		}
+		policy, err := ws.ParseClipboardPolicy(cfg.Clipboard)
+		if err != nil {
+			return err
+		}
+
+		auth.SetToken(cfg.Auth.Token)
+		tmuxclient.SetServers(cfg.Discovery.TmuxServers())
+		tmuxMgr.SetDiscovery(time.Duration(cfg.Discovery.Interval), cfg.Discovery.AutoAttach)
+		tmuxMgr.SetAgentRegistry(agents)
+		tmuxHandlers.SetProfilesPath(profiles)
+		tmux.SetRecordingDir(cfg.Recording.Dir)
+		gottyHandler.SetClipboardPolicy(policy)
+		pushSender.SetSubject(cfg.Notifications.VAPIDSubject)
+		pushSender.SetEvents(cfg.Notifications.Events)
+		apiHandlers.SetUIPreferences(cfg.UI)
+		peers.SetPeers(federationPeers(cfg.Federation))
+		return nil
+	}
+	if err := applyConfig(cfg); err != nil {
+		return err
+	}
 
 	router := gin.New()
 	router.Use(gin.Recovery())
 	router.Use(gin.Logger())
+	router.Use(api.SameOrigin())
+	router.Use(auth.RequireKnownHost(cfg.Server.Host))
 	router.RedirectTrailingSlash = false
 	router.RedirectFixedPath = false
 
+	requireToken := auth.RequireToken()
+	requireCommandAuth := auth.RequireCommandAuth()
+
+	if err := registerDashboard(router, requireToken); err != nil {
+		return err
+	}
+
+	router.GET("/gotty/:tmux_session", requireToken, gottyHandler.HandleTmuxSession)
+	router.GET("/api/v1/health", apiHandlers.HealthCheck)
+	// Terminals and session actions of federated peers' sessions, which
+	// this server reaches with the peers' tokens
+	router.Any("/peers/:peer/*path", requireToken, requireCommandAuth, peers.Proxy)
+	router.GET("/login/:code", auth.RedeemLoginLink)
+
+	// Views of the sessions that the dashboard also shows without a token
+	apiV1 := router.Group("/api/v1", requireToken, api.RequireJSON())
+	apiV1.GET("/tmux/sessions", tmuxHandlers.ListSessions)
+	apiV1.GET("/tmux/sessions/:name/windows", tmuxHandlers.ListWindows)
+	apiV1.GET("/tmux/sessions/:name/windows/:window/panes", tmuxHandlers.ListPanes)
+	apiV1.GET("/tmux/sessions/:name/agent", tmuxHandlers.GetDecision)
+	apiV1.GET("/tmux/sessions/:name/actions", tmuxHandlers.GetQuickActions)
+	apiV1.GET("/profiles", tmuxHandlers.ListProfiles)
+	apiV1.GET("/sessions/ws", tmuxHandlers.SessionWebSocket)
+	apiV1.GET("/ui/preferences", apiHandlers.UIPreferences)
+	apiV1.GET("/push/vapid-public-key", pushHandlers.PublicKey)
+
+	// Everything that changes something or reads files. Without an access
+	// token, only local rvc commands may use these.
+	commandV1 := apiV1.Group("", requireCommandAuth)
+	commandV1.POST("/tmux/sessions", tmuxHandlers.CreateSession)
+	commandV1.PATCH("/tmux/sessions/:name", tmuxHandlers.UpdateSession)
+	commandV1.DELETE("/tmux/sessions/:name", tmuxHandlers.DeleteSession)
+	commandV1.POST("/tmux/sessions/:name/keys", tmuxHandlers.SendKeys)
+	commandV1.POST("/tmux/sessions/:name/input", tmuxHandlers.SendInput)
+	commandV1.POST("/tmux/sessions/:name/windows", tmuxHandlers.NewWindow)
+	commandV1.POST("/tmux/sessions/:name/windows/:window/select", tmuxHandlers.SelectWindow)
+	commandV1.POST("/tmux/sessions/:name/windows/:window/panes", tmuxHandlers.SplitPane)
+	commandV1.POST("/tmux/sessions/:name/windows/:window/panes/:pane/select", tmuxHandlers.SelectPane)
+	commandV1.DELETE("/tmux/sessions/:name/windows/:window/panes/:pane", tmuxHandlers.KillPane)
+	commandV1.POST("/tmux/sessions/:name/paste", tmuxHandlers.PasteBuffer)
+	commandV1.GET("/tmux/sessions/:name/files", tmuxHandlers.ListFiles)
+	commandV1.GET("/tmux/sessions/:name/files/download", tmuxHandlers.DownloadFile)
+	commandV1.GET("/tmux/sessions/:name/git", tmuxHandlers.GitStatus)
+	commandV1.GET("/tmux/sessions/:name/git/diff", tmuxHandlers.GitDiff)
+	commandV1.PUT("/tmux/sessions/:name/agent", tmuxHandlers.SetAgent)
+	commandV1.POST("/tmux/sessions/:name/decision", tmuxHandlers.AnswerDecision)
+	commandV1.PUT("/tmux/sessions/:name/actions", tmuxHandlers.SetQuickActions)
+	commandV1.GET("/tmux/buffers", tmuxHandlers.ListBuffers)
+	commandV1.GET("/tmux/buffers/:buffer", tmuxHandlers.GetBuffer)
+	commandV1.DELETE("/tmux/buffers/:buffer", tmuxHandlers.DeleteBuffer)
+	commandV1.POST("/profiles/:profile/start", tmuxHandlers.StartProfile)
+	commandV1.POST("/auth/links", auth.CreateLoginLink)
+	commandV1.GET("/push/subscriptions", pushHandlers.ListSubscriptions)
+	commandV1.POST("/push/subscriptions", pushHandlers.Subscribe)
+	commandV1.PUT("/push/subscriptions/:id", pushHandlers.UpdateFilters)
+	commandV1.DELETE("/push/subscriptions/:id", pushHandlers.Unsubscribe)
+	commandV1.POST("/push/subscriptions/:id/test", pushHandlers.SendTest)
+
+	// Buffers and uploads also take text/plain and multipart bodies
+	rawV1 := router.Group("/api/v1", requireToken, requireCommandAuth)
+	rawV1.POST("/tmux/sessions/:name/files", tmuxHandlers.UploadFiles)
+	rawV1.POST("/tmux/buffers", tmuxHandlers.CreateBuffer)
+	rawV1.PUT("/tmux/buffers/:buffer", tmuxHandlers.SetBuffer)
+
+	srv := &http.Server{
+		Addr:    serverAddr,
+		Handler: router,
+	}
+
+	// Listen before writing the runtime file, so it only ever names a
+	// server that answers
+	listener, err := net.Listen("tcp", serverAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", serverAddr, err)
+	}
+	runtime := daemon.Runtime{
+		PID:          os.Getpid(),
+		URL:          cfg.Server.LocalURL(),
+		Addr:         listener.Addr().String(),
+		StartedAt:    time.Now(),
+		ControlToken: controlToken,
+	}
+	if cfg.Server.TLS.Enabled() {
+		cert, err := tls.LoadX509KeyPair(cfg.Server.TLS.Cert, cfg.Server.TLS.Key)
+		if err != nil {
+			listener.Close()
+			return fmt.Errorf("failed to load the TLS certificate: %w", err)
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		runtime.TLSFingerprint = daemon.Fingerprint(cert.Certificate[0])
+	}
+	if err := daemon.WriteRuntime(runtime); err != nil {
+		listener.Close()
+		return err
+	}
+	defer daemon.RemoveRuntime()
+
+	go func() {
+		log.Printf("Server started on %s", serverAddr)
+		var err error
+		if cfg.Server.TLS.Enabled() {
+			err = srv.ServeTLS(listener, "", "")
+		} else {
+			err = srv.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	restartSignal := make(chan os.Signal, 1)
+	signal.Notify(restartSignal, daemon.RestartSignal)
+
+	restart := false
+	for running := true; running; {
+		select {
+		case <-quit:
+			running = false
+		case <-restartSignal:
+			restart, running = true, false
+		case <-hup:
+			next, err := loadServeConfig(cmd)
+			if err == nil {
+				err = applyConfig(next)
+			}
+			if err != nil {
+				log.Printf("Config reload failed, keeping the current settings: %v", err)
+				continue
+			}
+			if next.Server != cfg.Server || next.Discovery.ProcInterval != cfg.Discovery.ProcInterval {
+				log.Printf("Config reloaded; listen address, TLS and proc_interval changes take effect after a restart")
+			} else {
+				log.Printf("Config reloaded")
+			}
+			// Keep the settings that only a restart applies at their running
+			// values, so later reloads still report them as pending
+			next.Server, next.Discovery.ProcInterval = cfg.Server, cfg.Discovery.ProcInterval
+			cfg = next
+		}
+	}
+
+	log.Println("Shutting down the server...")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+
+	if restart {
+		return restartServe(auditLog)
+	}
+	return nil
+}
+
+// registerDashboard serves the web dashboard and its static files
+func registerDashboard(router *gin.Engine, requireToken gin.HandlerFunc) error {
 	webSubFS, err := fs.Sub(webFS, "web")
 	if err != nil {
 		return fmt.Errorf("failed to get web subdirectory: %w", err)
@@ -105,7 +436,7 @@ func runServe(cmd *cobra.Command, args []string) error {
 		c.Data(200, "", content)
 	})
 
-	router.GET("/", func(c *gin.Context) {
+	router.GET("/", requireToken, func(c *gin.Context) {
 		content, err := fs.ReadFile(webSubFS, "index.html")
 		if err != nil {
 			log.Printf("Error reading index.html: %v", err)
@@ -116,38 +447,112 @@ func runServe(cmd *cobra.Command, args []string) error {
 		c.Data(200, "", content)
 	})
 
-	router.GET("/gotty/:tmux_session", gottyHandler.HandleTmuxSession)
+	// The service worker must be served from the root to control the whole dashboard
+	router.GET("/sw.js", func(c *gin.Context) {
+		content, err := fs.ReadFile(webSubFS, "sw.js")
+		if err != nil {
+			log.Printf("Error reading sw.js: %v", err)
+			c.Status(404)
+			return
+		}
+		c.Header("Content-Type", getContentType("sw.js"))
+		c.Header("Cache-Control", "no-cache")
+		c.Data(200, "", content)
+	})
+	return nil
+}
 
-	apiV1 := router.Group("/api/v1")
-	apiV1.GET("/tmux/sessions", tmuxHandlers.ListSessions)
-	apiV1.GET("/sessions/ws", tmuxHandlers.SessionWebSocket)
-	apiV1.GET("/health", apiHandlers.HealthCheck)
+// restartServe replaces the process with a fresh copy of itself, keeping the
+// pid (and with it the runtime file and any supervisor), arguments and log
+func restartServe(auditLog *audit.Logger) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	auditLog.Close()
+	log.Println("Restarting the server...")
+	return syscall.Exec(exe, os.Args, os.Environ())
+}
 
-	srv := &http.Server{
-		Addr:    serverAddr,
-		Handler: router,
+// federationPeers converts the configured peers for the federation
+func federationPeers(cfg config.Federation) []federation.Peer {
+	peers := make([]federation.Peer, 0, len(cfg.Peers))
+	for _, p := range cfg.Peers {
+		peers = append(peers, federation.Peer{
+			Name:           p.Name,
+			URL:            p.URL,
+			Token:          p.Token,
+			TLSFingerprint: p.TLSFingerprint,
+		})
 	}
+	return peers
+}
 
-	go func() {
-		log.Printf("Server started on %s", serverAddr)
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("Server failed to start: %v", err)
-		}
-	}()
+// newPushSender loads (or creates) the VAPID keys and subscription store from the state directory
+func newPushSender(subject string) (*push.Store, *push.Sender, error) {
+	keysPath, err := paths.StateFile("vapid.json")
+	if err != nil {
+		return nil, nil, err
+	}
+	keys, err := push.LoadOrCreateVAPIDKeys(keysPath)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	storePath, err := paths.StateFile("push-subscriptions.json")
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := push.NewStore(storePath)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	log.Println("Shutting down the server...")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	return store, push.NewSender(keys, store, subject), nil
+}
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
+// newAgentRegistry loads the built-in agent adapters and the regex adapters
+// from path, or ~/.config/rvc/agents.yaml if it is empty
+func newAgentRegistry(path string) (*agent.Registry, error) {
+	registry := agent.NewRegistry()
+	if path == "" {
+		var err error
+		if path, err = paths.ConfigFile("agents.yaml"); err != nil {
+			return nil, err
+		}
 	}
+	if err := registry.LoadFile(path); err != nil {
+		return nil, fmt.Errorf("failed to load agent adapters: %w", err)
+	}
+	return registry, nil
+}
 
-	return nil
+// pushEventFor converts a tmux session event into a notification
+func pushEventFor(ev tmux.SessionEvent) push.Event {
+	pev := push.Event{
+		Type:    ev.Type,
+		Session: ev.SessionName,
+		URL:     "/",
+		Time:    ev.Time,
+	}
+	switch ev.Type {
+	case tmux.EventSessionCreated:
+		pev.Title = fmt.Sprintf("Session %s started", ev.SessionName)
+	case tmux.EventSessionClosed:
+		pev.Title = fmt.Sprintf("Session %s ended", ev.SessionName)
+	case tmux.EventAwaitingInput:
+		pev.Title = fmt.Sprintf("%s is waiting for input", ev.SessionName)
+		pev.Body = "The session stopped producing output and may need your attention."
+	case tmux.EventDecisionPending:
+		pev.Title = fmt.Sprintf("%s needs a decision", ev.SessionName)
+		pev.Body = ev.Detail
+	case tmux.EventTrigger:
+		pev.Title = fmt.Sprintf("%s: trigger fired", ev.SessionName)
+		pev.Body = ev.Detail
+	default:
+		pev.Title = fmt.Sprintf("%s: %s", ev.SessionName, ev.Type)
+	}
+	return pev
 }
 
 func getContentType(filepath string) string {
@@ -181,14 +586,30 @@ func main() {
 the remote vibecode service. It provides an easy way to create, join,
 list, and stop tmux sessions with custom configuration.`,
 		Version: "1.0.0",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			commands.SelectTmuxServers()
+		},
 	}
 
 	// Add subcommands
 	rootCmd.AddCommand(commands.StartCmd)
 	rootCmd.AddCommand(commands.JoinCmd)
+	rootCmd.AddCommand(commands.AttachCmd)
+	rootCmd.AddCommand(commands.OpenCmd)
+	rootCmd.AddCommand(commands.PairCmd)
 	rootCmd.AddCommand(commands.ListCmd)
 	rootCmd.AddCommand(commands.StopCmd)
+	rootCmd.AddCommand(commands.ConfigCmd)
+	rootCmd.AddCommand(commands.ServerCmd)
+	rootCmd.AddCommand(commands.DoctorCmd)
+	rootCmd.AddCommand(commands.AgentCmd)
 	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(relayCmd)
+	rootCmd.PersistentFlags().StringVar(&commands.ConfigFile, "config", "", "Config file (default $RVC_CONFIG or ~/.config/rvc/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&commands.ServerURL, "server", "", "URL of the rvc server to use (default $RVC_SERVER, or a local server if one is running)")
+	rootCmd.PersistentFlags().StringVar(&commands.ServerToken, "token", "", "Access token for --server (default from the config or $RVC_TOKEN)")
+	rootCmd.PersistentFlags().StringVarP(&commands.TmuxSocketName, "socket-name", "L", "", "Use the tmux server on this socket name, like tmux -L")
+	rootCmd.PersistentFlags().StringVarP(&commands.TmuxSocketPath, "socket-path", "S", "", "Use the tmux server on this socket path, like tmux -S")
 
 	// Run the command
 	if err := rootCmd.Execute(); err != nil {
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+
+	"github.com/ibrahim/remote-vibecode/internal/api"
+	"github.com/ibrahim/remote-vibecode/internal/config"
+	"github.com/ibrahim/remote-vibecode/internal/daemon"
+	"github.com/ibrahim/remote-vibecode/internal/federation"
+	"github.com/ibrahim/remote-vibecode/internal/relay"
+	"github.com/ibrahim/remote-vibecode/internal/ws"
+)
+
+var relayCmd = &cobra.Command{
+	Use:   "relay",
+	Short: "Run a public relay that rvc agents connect to",
+	Long: `Run a relay: a server, usually on a machine with a public address, that
+serves the dashboard for the sessions of rvc servers that cannot accept
+connections themselves, e.g. behind NAT.
+
+On each such machine, 'rvc agent' dials out to the relay and keeps one
+connection open, through which the relay reaches the machine's terminals.
+Agent and relay authenticate each other with a secret per agent, listed
+under relay.agents in the relay's config; create one with
+'rvc relay new-agent NAME'. Peers under federation.peers are shown as well.
+
+The relay runs no tmux sessions of its own. It needs auth.token; serve TLS
+(--tls-cert and --tls-key) or put the relay behind an HTTPS proxy. Send
+SIGHUP to reload the token, agents, peers and dashboard preferences.`,
+	RunE: runRelay,
+}
+
+var relayNewAgentCmd = &cobra.Command{
+	Use:          "new-agent NAME",
+	Short:        "Generate a secret for an agent and print the config for both ends",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runRelayNewAgent,
+}
+
+func init() {
+	defaults := config.Defaults()
+	relayCmd.Flags().StringVar(&serveHost, "host", defaults.Server.Host, "Host to bind to")
+	relayCmd.Flags().StringVar(&servePort, "port", defaults.Server.Port, "Port to listen on")
+	relayCmd.Flags().StringVar(&serveToken, "token", "", "Access token required by the web UI and API (default $RVC_TOKEN, empty disables auth)")
+	relayCmd.Flags().StringVar(&tlsCert, "tls-cert", "", "TLS certificate file; serves HTTPS together with --tls-key")
+	relayCmd.Flags().StringVar(&tlsKey, "tls-key", "", "TLS private key file")
+	relayCmd.Flags().DurationVar(&discoveryInterval, "discovery-interval", time.Duration(defaults.Discovery.Interval), "How often to send changes to the session list to dashboards")
+	relayCmd.AddCommand(relayNewAgentCmd)
+}
+
+func runRelay(cmd *cobra.Command, args []string) error {
+	gin.SetMode(gin.ReleaseMode)
+
+	cfg, err := loadServeConfig(cmd)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	// Anyone who reaches a relay could otherwise use the agents' terminals
+	if cfg.Auth.Token == "" {
+		return errors.New("the relay needs an access token; set auth.token, $RVC_TOKEN or --token")
+	}
+
+	serverAddr := net.JoinHostPort(cfg.Server.Host, cfg.Server.Port)
+	scheme := "http"
+	if cfg.Server.TLS.Enabled() {
+		scheme = "https"
+	}
+	log.Printf("rvc relay listening on %s://%s", scheme, serverAddr)
+
+	sessionHub := ws.NewSessionHub()
+	peers := federation.New(sessionHub)
+	defer peers.Stop()
+	agents := relay.New(peers)
+	defer agents.Stop()
+
+	apiHandlers := api.New()
+	auth := api.NewTokenAuth(cfg.Auth.Token)
+
+	applyConfig := func(cfg *config.Config) {
+		auth.SetToken(cfg.Auth.Token)
+		apiHandlers.SetUIPreferences(cfg.UI)
+		peers.SetPeers(federationPeers(cfg.Federation))
+		agents.SetAgents(relayAgents(cfg.Relay))
+	}
+	applyConfig(cfg)
+	if len(cfg.Relay.Agents) == 0 && len(cfg.Federation.Peers) == 0 {
+		log.Printf("No agents are configured; add one with: rvc relay new-agent NAME")
+	}
+	if !cfg.Server.TLS.Enabled() {
+		log.Printf("Serving without TLS; agents and browsers should reach the relay through an HTTPS proxy")
+	}
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(gin.Logger())
+	router.Use(api.SameOrigin())
+	router.RedirectTrailingSlash = false
+	router.RedirectFixedPath = false
+
+	requireToken := auth.RequireToken()
+	if err := registerDashboard(router, requireToken); err != nil {
+		return err
+	}
+
+	router.GET("/api/v1/health", apiHandlers.HealthCheck)
+	router.Any("/peers/:peer/*path", requireToken, peers.Proxy)
+	router.GET("/login/:code", auth.RedeemLoginLink)
+	// Agents authenticate with their secret instead of the access token
+	router.GET(relay.ConnectPath, agents.Connect)
+
+	// The relay has no sessions of its own, only those of its agents and peers
+	noSessions := func() []ws.SessionInfo { return []ws.SessionInfo{} }
+	apiV1 := router.Group("/api/v1", requireToken)
+	apiV1.GET("/sessions/ws", api.SessionWebSocket(sessionHub, noSessions))
+	apiV1.GET("/ui/preferences", apiHandlers.UIPreferences)
+	apiV1.POST("/auth/links", auth.CreateLoginLink)
+
+	srv := &http.Server{
+		Addr:    serverAddr,
+		Handler: router,
+	}
+	listener, err := net.Listen("tcp", serverAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", serverAddr, err)
+	}
+	if cfg.Server.TLS.Enabled() {
+		cert, err := tls.LoadX509KeyPair(cfg.Server.TLS.Cert, cfg.Server.TLS.Key)
+		if err != nil {
+			listener.Close()
+			return fmt.Errorf("failed to load the TLS certificate: %w", err)
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		log.Printf("TLS certificate fingerprint (SHA-256): %s", daemon.Fingerprint(cert.Certificate[0]))
+	}
+
+	go func() {
+		var err error
+		if cfg.Server.TLS.Enabled() {
+			err = srv.ServeTLS(listener, "", "")
+		} else {
+			err = srv.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	// Dashboards learn about agents coming and going with the next broadcast
+	ticker := time.NewTicker(time.Duration(cfg.Discovery.Interval))
+	defer ticker.Stop()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for running := true; running; {
+		select {
+		case <-quit:
+			running = false
+		case <-ticker.C:
+			sessionHub.BroadcastSessions([]ws.SessionInfo{})
+		case <-hup:
+			next, err := loadServeConfig(cmd)
+			if err != nil {
+				log.Printf("Config reload failed, keeping the current settings: %v", err)
+				continue
+			}
+			if next.Auth.Token == "" {
+				log.Printf("Config reload failed, keeping the current settings: auth.token is not set")
+				continue
+			}
+			applyConfig(next)
+			log.Printf("Config reloaded")
+		}
+	}
+
+	log.Println("Shutting down the relay...")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+	return nil
+}
+
+func runRelayNewAgent(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	secret, err := daemon.NewControlToken()
+	if err != nil {
+		return err
+	}
+	cfg := config.Defaults()
+	cfg.Relay.Agents = []config.RelayAgent{{Name: name, Secret: secret}}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	fmt.Printf(`Add the agent to the relay's config and reload it (SIGHUP):
+
+relay:
+  agents:
+    - name: %s
+      secret: %s
+
+On the agent's machine, add this to its config and run 'rvc agent':
+
+relay:
+  connect:
+    url: https://relay.example.com  # the relay's URL
+    name: %s
+    secret: %s
+`, name, secret, name, secret)
+	return nil
+}
+
+// relayAgents converts the configured agents for the relay
+func relayAgents(cfg config.Relay) map[string]string {
+	agents := make(map[string]string, len(cfg.Agents))
+	for _, a := range cfg.Agents {
+		agents[a.Name] = a.Secret
+	}
+	return agents
+}
@@ -0,0 +1,149 @@
+// Package agent recognises confirmation prompts of AI coding-agent CLIs on a
+// terminal screen and turns them into structured decisions
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Auto is the adapter name that tries every registered adapter in turn
+const Auto = "auto"
+
+// Option is one answer to a pending decision and the keys that select it
+type Option struct {
+	Label string   `json:"label"`
+	Keys  []string `json:"keys"`
+}
+
+// Decision is a prompt an agent is waiting on
+type Decision struct {
+	ID         string    `json:"id"` // stable for as long as the same prompt is on screen
+	Adapter    string    `json:"adapter"`
+	Prompt     string    `json:"prompt"`
+	Options    []Option  `json:"options"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// Adapter recognises the prompts of one agent CLI
+type Adapter interface {
+	// Name identifies the adapter in the @rvc-agent session option
+	Name() string
+	// Detect parses the visible screen of a pane and returns the pending
+	// decision, or nil if the agent is not asking anything
+	Detect(screen string) *Decision
+}
+
+// newDecision builds a decision and derives its ID from its content and the
+// screen above the prompt. Agents often ask the same question twice in a row,
+// e.g. Claude's "Do you want to proceed?", but about different commands, so
+// the lines above tell the two prompts apart.
+func newDecision(adapter, above, prompt string, options []Option) *Decision {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", adapter, above, prompt)
+	for _, o := range options {
+		fmt.Fprintf(h, "\x00%s\x00%s", o.Label, strings.Join(o.Keys, " "))
+	}
+	return &Decision{
+		ID:         hex.EncodeToString(h.Sum(nil))[:12],
+		Adapter:    adapter,
+		Prompt:     prompt,
+		Options:    options,
+		DetectedAt: time.Now(),
+	}
+}
+
+// Registry holds the available adapters by name
+type Registry struct {
+	mu       sync.RWMutex
+	adapters map[string]Adapter
+}
+
+// NewRegistry creates a registry with the built-in adapters
+func NewRegistry() *Registry {
+	r := &Registry{adapters: make(map[string]Adapter)}
+	r.Register(claudeAdapter{})
+	r.Register(aiderAdapter{})
+	return r
+}
+
+// Register adds an adapter, replacing any adapter with the same name
+func (r *Registry) Register(a Adapter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.adapters[a.Name()] = a
+}
+
+// Has reports whether name is a registered adapter or Auto
+func (r *Registry) Has(name string) bool {
+	if name == Auto {
+		return true
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.adapters[name]
+	return ok
+}
+
+// Names returns the registered adapter names, sorted
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.adapters))
+	for name := range r.adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Detect runs the named adapter (or every adapter for Auto) on a screen
+func (r *Registry) Detect(name, screen string) *Decision {
+	if name == Auto {
+		for _, n := range r.Names() {
+			if d := r.Detect(n, screen); d != nil {
+				return d
+			}
+		}
+		return nil
+	}
+
+	r.mu.RLock()
+	a, ok := r.adapters[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return a.Detect(screen)
+}
+
+// lastLines returns up to n trailing lines of a screen, ignoring the blank
+// rows below the cursor
+func lastLines(screen string, n int) []string {
+	lines := strings.Split(strings.TrimRight(screen, "\n "), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// boxChars are the border characters agent CLIs draw around prompts
+const boxChars = "│┃║|╭╮╰╯┌┐└┘─━"
+
+// stripBox removes box borders from a screen line. The indentation inside the
+// box is kept, since it tells wrapped option labels apart from new lines.
+func stripBox(line string) string {
+	line = strings.TrimRight(line, " ")
+	trimmed := strings.TrimLeft(line, " ")
+	if r, _ := utf8.DecodeRuneInString(trimmed); strings.ContainsRune(boxChars, r) {
+		line = strings.TrimLeft(trimmed, boxChars)
+	}
+	line = strings.TrimRight(line, boxChars)
+	return strings.TrimRight(line, " ")
+}
@@ -0,0 +1,300 @@
+package agent
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClaudeDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		screen  string
+		prompt  string
+		options []Option
+	}{
+		{
+			name: "boxed menu",
+			screen: `╭──────────────────────────────────────────────╮
+│ Bash command                                 │
+│                                              │
+│   rm -rf build                               │
+│                                              │
+│ Do you want to proceed?                      │
+│ ❯ 1. Yes                                     │
+│   2. Yes, and don't ask again for this command │
+│   3. No, and tell Claude what to do differently (esc) │
+╰──────────────────────────────────────────────╯
+`,
+			prompt: "Do you want to proceed?",
+			options: []Option{
+				{Label: "Yes", Keys: []string{"1"}},
+				{Label: "Yes, and don't ask again for this command", Keys: []string{"2"}},
+				{Label: "No, and tell Claude what to do differently (esc)", Keys: []string{"3"}},
+			},
+		},
+		{
+			name: "wrapped label",
+			screen: `Do you want to make this edit to main.go?
+› 1. Yes
+  2. Yes, allow all edits during this session
+       (shift+tab)
+  3. No
+`,
+			prompt: "Do you want to make this edit to main.go?",
+			options: []Option{
+				{Label: "Yes", Keys: []string{"1"}},
+				{Label: "Yes, allow all edits during this session (shift+tab)", Keys: []string{"2"}},
+				{Label: "No", Keys: []string{"3"}},
+			},
+		},
+		{
+			name: "prompt without question mark",
+			screen: `Select a model
+> 1. Opus
+  2. Sonnet
+`,
+			prompt: "Select a model",
+			options: []Option{
+				{Label: "Opus", Keys: []string{"1"}},
+				{Label: "Sonnet", Keys: []string{"2"}},
+			},
+		},
+		{
+			name: "numbered list in the output",
+			screen: `Steps:
+1. Build
+2. Test
+`,
+		},
+		{
+			name:   "single option",
+			screen: "❯ 1. Continue\n",
+		},
+		{
+			name:   "no menu",
+			screen: "$ make\nok\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := claudeAdapter{}.Detect(tt.screen)
+			if tt.options == nil {
+				if d != nil {
+					t.Fatalf("Detect = %+v, want nil", d)
+				}
+				return
+			}
+			if d == nil {
+				t.Fatal("Detect = nil, want a decision")
+			}
+			if d.Adapter != "claude" {
+				t.Errorf("Adapter = %q, want claude", d.Adapter)
+			}
+			if d.Prompt != tt.prompt {
+				t.Errorf("Prompt = %q, want %q", d.Prompt, tt.prompt)
+			}
+			if !reflect.DeepEqual(d.Options, tt.options) {
+				t.Errorf("Options = %+v, want %+v", d.Options, tt.options)
+			}
+		})
+	}
+}
+
+func TestAiderDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		screen  string
+		prompt  string
+		options []Option
+	}{
+		{
+			name:   "yes no",
+			screen: "aider> /add main.go\nCreate new file main.go? (Y)es/(N)o [Yes]: \n\n",
+			prompt: "Create new file main.go?",
+			options: []Option{
+				{Label: "Yes", Keys: []string{"y", "Enter"}},
+				{Label: "No", Keys: []string{"n", "Enter"}},
+			},
+		},
+		{
+			name:   "all options",
+			screen: "Add file to the chat? (Y)es/(N)o/(A)ll/(S)kip all/(D)on't ask again [Yes]:",
+			prompt: "Add file to the chat?",
+			options: []Option{
+				{Label: "Yes", Keys: []string{"y", "Enter"}},
+				{Label: "No", Keys: []string{"n", "Enter"}},
+				{Label: "All", Keys: []string{"a", "Enter"}},
+				{Label: "Skip all", Keys: []string{"s", "Enter"}},
+				{Label: "Don't ask again", Keys: []string{"d", "Enter"}},
+			},
+		},
+		{
+			name:   "answered prompt",
+			screen: "Create new file main.go? (Y)es/(N)o [Yes]: y\naider> ",
+		},
+		{
+			name:   "no prompt",
+			screen: "aider> ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := aiderAdapter{}.Detect(tt.screen)
+			if tt.options == nil {
+				if d != nil {
+					t.Fatalf("Detect = %+v, want nil", d)
+				}
+				return
+			}
+			if d == nil {
+				t.Fatal("Detect = nil, want a decision")
+			}
+			if d.Prompt != tt.prompt {
+				t.Errorf("Prompt = %q, want %q", d.Prompt, tt.prompt)
+			}
+			if !reflect.DeepEqual(d.Options, tt.options) {
+				t.Errorf("Options = %+v, want %+v", d.Options, tt.options)
+			}
+		})
+	}
+}
+
+func TestRegexDetect(t *testing.T) {
+	static := RegexConfig{
+		Name:  "deploy",
+		Match: `(?m)^(?P<prompt>Deploy to production\?) \[y/N\]\s*$`,
+		Options: []Option{
+			{Label: "Yes", Keys: []string{"y", "Enter"}},
+			{Label: "No", Keys: []string{"n", "Enter"}},
+		},
+	}
+	pattern := RegexConfig{
+		Name:          "picker",
+		Match:         `(?m)^Pick an environment:$`,
+		OptionPattern: `(?m)^\s*\[(?P<key>\w)\] (?P<label>.+)$`,
+		Confirm:       []string{"Enter"},
+	}
+
+	tests := []struct {
+		name    string
+		cfg     RegexConfig
+		screen  string
+		prompt  string
+		options []Option
+	}{
+		{
+			name:    "static options",
+			cfg:     static,
+			screen:  "$ ./deploy\nDeploy to production? [y/N] \n",
+			prompt:  "Deploy to production?",
+			options: static.Options,
+		},
+		{
+			name:   "option pattern",
+			cfg:    pattern,
+			screen: "Pick an environment:\n  [s] staging\n  [p] production\n",
+			prompt: "Pick an environment:",
+			options: []Option{
+				{Label: "staging", Keys: []string{"s", "Enter"}},
+				{Label: "production", Keys: []string{"p", "Enter"}},
+			},
+		},
+		{
+			name:   "option pattern without options",
+			cfg:    pattern,
+			screen: "Pick an environment:\n",
+		},
+		{
+			name:   "outside the searched lines",
+			cfg:    RegexConfig{Name: "deploy", Match: static.Match, Lines: 1, Options: static.Options},
+			screen: "Deploy to production? [y/N]\ndeploying...\n",
+		},
+		{
+			name:   "no match",
+			cfg:    static,
+			screen: "$ ./deploy --yes\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewRegexAdapter(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewRegexAdapter: %v", err)
+			}
+			d := a.Detect(tt.screen)
+			if tt.options == nil {
+				if d != nil {
+					t.Fatalf("Detect = %+v, want nil", d)
+				}
+				return
+			}
+			if d == nil {
+				t.Fatal("Detect = nil, want a decision")
+			}
+			if d.Adapter != tt.cfg.Name {
+				t.Errorf("Adapter = %q, want %q", d.Adapter, tt.cfg.Name)
+			}
+			if d.Prompt != tt.prompt {
+				t.Errorf("Prompt = %q, want %q", d.Prompt, tt.prompt)
+			}
+			if !reflect.DeepEqual(d.Options, tt.options) {
+				t.Errorf("Options = %+v, want %+v", d.Options, tt.options)
+			}
+		})
+	}
+}
+
+func TestNewRegexAdapterErrors(t *testing.T) {
+	yes := []Option{{Label: "Yes", Keys: []string{"y"}}}
+	tests := []struct {
+		name string
+		cfg  RegexConfig
+		want string
+	}{
+		{"no name", RegexConfig{Match: "x", Options: yes}, "needs a name"},
+		{"auto name", RegexConfig{Name: Auto, Match: "x", Options: yes}, "needs a name"},
+		{"empty match", RegexConfig{Name: "a", Options: yes}, "invalid match pattern"},
+		{"invalid match", RegexConfig{Name: "a", Match: "(", Options: yes}, "invalid match pattern"},
+		{"no options", RegexConfig{Name: "a", Match: "x"}, "needs options or option_pattern"},
+		{"option without keys", RegexConfig{Name: "a", Match: "x", Options: []Option{{Label: "Yes"}}}, "label and keys"},
+		{"option pattern without groups", RegexConfig{Name: "a", Match: "x", OptionPattern: `(\w)`}, "groups"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegexAdapter(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewRegexAdapter = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecisionID(t *testing.T) {
+	menu := "Do you want to proceed?\n❯ 1. Yes\n  2. No\n"
+	first := claudeAdapter{}.Detect("Bash command\n  rm -rf build\n" + menu)
+	again := claudeAdapter{}.Detect("Bash command\n  rm -rf build\n" + menu)
+	other := claudeAdapter{}.Detect("Bash command\n  rm -rf dist\n" + menu)
+	if first == nil || again == nil || other == nil {
+		t.Fatal("Detect = nil, want a decision")
+	}
+	if first.ID != again.ID {
+		t.Errorf("IDs of the same prompt differ: %s, %s", first.ID, again.ID)
+	}
+	if first.ID == other.ID {
+		t.Errorf("prompts about different commands share the ID %s", first.ID)
+	}
+}
+
+func TestRegistryDetectAuto(t *testing.T) {
+	r := NewRegistry()
+	if d := r.Detect(Auto, "Create new file main.go? (Y)es/(N)o [Yes]:"); d == nil || d.Adapter != "aider" {
+		t.Errorf("Detect(auto) = %+v, want an aider decision", d)
+	}
+	if d := r.Detect("claude", "Create new file main.go? (Y)es/(N)o [Yes]:"); d != nil {
+		t.Errorf("Detect(claude) = %+v, want nil", d)
+	}
+	if d := r.Detect("missing", "❯ 1. Yes\n  2. No"); d != nil {
+		t.Errorf("Detect(missing) = %+v, want nil", d)
+	}
+}
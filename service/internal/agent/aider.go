@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"regexp"
+	"strings"
+)
+
+// aiderAdapter recognises aider's inline confirmations:
+//
+//	Add file to the chat? (Y)es/(N)o/(A)ll/(S)kip all/(D)on't ask again [Yes]:
+//
+// An option is answered with its letter followed by Enter.
+type aiderAdapter struct{}
+
+var (
+	aiderPromptPattern = regexp.MustCompile(`^(.+?\?)\s+((?:\([A-Za-z]\)[^/\[]*/?)+)\s*\[[^\]]*\]:\s*$`)
+	aiderOptionPattern = regexp.MustCompile(`\(([A-Za-z])\)([^/]*)`)
+)
+
+// aiderScreenLines is how many lines up to the prompt identify it
+const aiderScreenLines = 10
+
+func (aiderAdapter) Name() string { return "aider" }
+
+func (aiderAdapter) Detect(screen string) *Decision {
+	// The prompt is always on the cursor line, the last non-empty one
+	lines := lastLines(screen, aiderScreenLines)
+	m := aiderPromptPattern.FindStringSubmatch(strings.TrimSpace(lines[len(lines)-1]))
+	if m == nil {
+		return nil
+	}
+
+	var options []Option
+	for _, o := range aiderOptionPattern.FindAllStringSubmatch(m[2], -1) {
+		options = append(options, Option{
+			Label: o[1] + strings.TrimSpace(o[2]),
+			Keys:  []string{strings.ToLower(o[1]), "Enter"},
+		})
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	return newDecision("aider", strings.Join(lines[:len(lines)-1], "\n"), m[1], options)
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// claudeAdapter recognises Claude Code's numbered permission menus:
+//
+//	Do you want to proceed?
+//	❯ 1. Yes
+//	  2. Yes, and don't ask again for this command
+//	  3. No, and tell Claude what to do differently (esc)
+//
+// Pressing an option's number selects it.
+type claudeAdapter struct{}
+
+var (
+	claudeOptionPattern = regexp.MustCompile(`^(\s*)(❯|›|>)?\s*(\d+)\.\s+(.+)$`)
+	claudeMarker        = regexp.MustCompile(`^\s*(❯|›|>)\s*\d+\.`)
+)
+
+// claudeScreenLines is how far up from the bottom a menu is looked for
+const claudeScreenLines = 40
+
+func (claudeAdapter) Name() string { return "claude" }
+
+func (claudeAdapter) Detect(screen string) *Decision {
+	lines := lastLines(screen, claudeScreenLines)
+	for i := range lines {
+		lines[i] = stripBox(lines[i])
+	}
+
+	// The menu is the last run of options starting at "1."
+	start := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if m := claudeOptionPattern.FindStringSubmatch(lines[i]); m != nil && m[3] == "1" {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	var options []Option
+	selected := false
+	indent := 0
+	for _, line := range lines[start:] {
+		if m := claudeOptionPattern.FindStringSubmatch(line); m != nil && m[3] == strconv.Itoa(len(options)+1) {
+			options = append(options, Option{Label: strings.TrimSpace(m[4]), Keys: []string{m[3]}})
+			selected = selected || claudeMarker.MatchString(line)
+			indent = len(m[1]) + len(m[2])
+			continue
+		}
+		// Long labels wrap onto lines indented past the option number
+		trimmed := strings.TrimLeft(line, " ")
+		if len(options) > 0 && trimmed != "" && len(line)-len(trimmed) > indent+1 {
+			last := &options[len(options)-1]
+			last.Label += " " + trimmed
+			continue
+		}
+		break
+	}
+
+	// A selection marker tells a menu apart from a numbered list in the output
+	if len(options) < 2 || !selected {
+		return nil
+	}
+	return newDecision("claude", strings.Join(lines[:start], "\n"), claudePrompt(lines[:start]), options)
+}
+
+// claudePrompt finds the question above a menu: the nearest line ending in
+// "?", or else the nearest non-empty line
+func claudePrompt(above []string) string {
+	fallback := ""
+	for i := len(above) - 1; i >= 0 && i >= len(above)-6; i-- {
+		line := strings.TrimSpace(above[i])
+		if line == "" {
+			continue
+		}
+		if strings.HasSuffix(line, "?") {
+			return line
+		}
+		if fallback == "" {
+			fallback = line
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// RegexConfig configures a regex-driven adapter. Match is searched for in the
+// last Lines lines of the screen; its "prompt" group (or the whole match) is
+// the prompt text. Options are either listed statically or extracted with
+// OptionPattern, whose "key" and "label" groups give each option, answered by
+// pressing key followed by Confirm.
+//
+//	adapters:
+//	  - name: deploy-tool
+//	    match: '(?m)^(?P<prompt>Deploy to production\?) \[y/N\]\s*$'
+//	    options:
+//	      - {label: "Yes", keys: ["y", "Enter"]}
+//	      - {label: "No", keys: ["n", "Enter"]}
+type RegexConfig struct {
+	Name          string   `yaml:"name"`
+	Match         string   `yaml:"match"`
+	Lines         int      `yaml:"lines"`
+	Options       []Option `yaml:"options"`
+	OptionPattern string   `yaml:"option_pattern"`
+	Confirm       []string `yaml:"confirm"`
+}
+
+// regexAdapter is an adapter built from a RegexConfig
+type regexAdapter struct {
+	name          string
+	match         *regexp.Regexp
+	lines         int
+	options       []Option
+	optionPattern *regexp.Regexp
+	confirm       []string
+}
+
+// NewRegexAdapter validates a config and builds its adapter
+func NewRegexAdapter(cfg RegexConfig) (Adapter, error) {
+	if cfg.Name == "" || cfg.Name == Auto {
+		return nil, fmt.Errorf("adapter needs a name other than %q", Auto)
+	}
+	match, err := regexp.Compile(cfg.Match)
+	if err != nil || cfg.Match == "" {
+		return nil, fmt.Errorf("adapter %s: invalid match pattern: %v", cfg.Name, err)
+	}
+
+	a := &regexAdapter{
+		name:    cfg.Name,
+		match:   match,
+		lines:   cfg.Lines,
+		options: cfg.Options,
+		confirm: cfg.Confirm,
+	}
+	if a.lines <= 0 {
+		a.lines = 20
+	}
+	if cfg.OptionPattern != "" {
+		if a.optionPattern, err = regexp.Compile(cfg.OptionPattern); err != nil {
+			return nil, fmt.Errorf("adapter %s: invalid option_pattern: %w", cfg.Name, err)
+		}
+		if a.optionPattern.SubexpIndex("key") < 0 || a.optionPattern.SubexpIndex("label") < 0 {
+			return nil, fmt.Errorf("adapter %s: option_pattern needs (?P<key>...) and (?P<label>...) groups", cfg.Name)
+		}
+	}
+	if len(a.options) == 0 && a.optionPattern == nil {
+		return nil, fmt.Errorf("adapter %s: needs options or option_pattern", cfg.Name)
+	}
+	for _, o := range a.options {
+		if o.Label == "" || len(o.Keys) == 0 {
+			return nil, fmt.Errorf("adapter %s: every option needs a label and keys", cfg.Name)
+		}
+	}
+	return a, nil
+}
+
+func (a *regexAdapter) Name() string { return a.name }
+
+func (a *regexAdapter) Detect(screen string) *Decision {
+	text := strings.Join(lastLines(screen, a.lines), "\n")
+	loc := a.match.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return nil
+	}
+
+	prompt := text[loc[0]:loc[1]]
+	if i := a.match.SubexpIndex("prompt"); i >= 0 && loc[2*i] >= 0 {
+		prompt = text[loc[2*i]:loc[2*i+1]]
+	}
+
+	options := a.options
+	if a.optionPattern != nil {
+		keyIdx, labelIdx := a.optionPattern.SubexpIndex("key"), a.optionPattern.SubexpIndex("label")
+		options = nil
+		for _, m := range a.optionPattern.FindAllStringSubmatch(text, -1) {
+			options = append(options, Option{
+				Label: strings.TrimSpace(m[labelIdx]),
+				Keys:  append([]string{m[keyIdx]}, a.confirm...),
+			})
+		}
+		if len(options) == 0 {
+			return nil
+		}
+	}
+	return newDecision(a.name, text[:loc[0]], strings.TrimSpace(prompt), options)
+}
+
+// LoadFile registers the regex adapters defined in a YAML file. A missing
+// file is not an error.
+func (r *Registry) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var file struct {
+		Adapters []RegexConfig `yaml:"adapters"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for _, cfg := range file.Adapters {
+		a, err := NewRegexAdapter(cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		r.Register(a)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/audit"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+type setAgentRequest struct {
+	Adapter string `json:"adapter"`
+}
+
+type answerDecisionRequest struct {
+	ID     string `json:"id" binding:"required"`
+	Option *int   `json:"option" binding:"required"`
+}
+
+// GetDecision returns the agent adapter of a session and the decision it is waiting on
+// GET /api/v1/tmux/sessions/:name/agent
+func (h *TmuxHandlers) GetDecision(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	adapter, decision, err := h.manager.Decision(sessionName)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"adapter":  adapter,
+		"adapters": h.manager.AgentRegistry().Names(),
+		"decision": decision,
+	})
+}
+
+// SetAgent chooses the adapter used to detect prompts in a session
+// ("auto" tries them all, "" turns detection off)
+// PUT /api/v1/tmux/sessions/:name/agent
+func (h *TmuxHandlers) SetAgent(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	var req setAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+	if req.Adapter != "" && !h.manager.AgentRegistry().Has(req.Adapter) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "unknown adapter: " + req.Adapter,
+			"code":  "unknown_adapter",
+		})
+		return
+	}
+
+	entry := audit.Entry{
+		Action:  "set_agent",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{"adapter": req.Adapter},
+	}
+	err := tmux.SetAgent(sessionName, req.Adapter)
+	if !h.recordResult(c, entry, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"adapter": req.Adapter,
+	})
+}
+
+// AnswerDecision selects an option of the pending decision by sending its keys.
+// The decision ID must match the one on screen, so a stale answer is never
+// delivered to a newer prompt.
+// POST /api/v1/tmux/sessions/:name/decision
+func (h *TmuxHandlers) AnswerDecision(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	var req answerDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+
+	entry := audit.Entry{
+		Action:  "answer_decision",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{
+			"id":     req.ID,
+			"option": *req.Option,
+		},
+	}
+	if !h.requireWritable(c, entry) {
+		return
+	}
+
+	_, decision, err := h.manager.Decision(sessionName)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if decision == nil || decision.ID != req.ID {
+		entry.Error = "decision changed"
+		h.audit.Record(entry)
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "the pending decision has changed",
+			"code":     "decision_changed",
+			"decision": decision,
+		})
+		return
+	}
+	if *req.Option < 0 || *req.Option >= len(decision.Options) {
+		respondBadRequest(c, "option out of range")
+		return
+	}
+
+	option := decision.Options[*req.Option]
+	entry.Details["prompt"] = decision.Prompt
+	entry.Details["label"] = option.Label
+	err = tmux.SendKeys(sessionName, option.Keys...)
+	if !h.recordResult(c, entry, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "answered",
+		"option": option,
+	})
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+// authCookie stores the access token after a successful ?token= login
+const authCookie = "rvc_token"
+
+const (
+	// defaultLoginLinkTTL is how long a login link stays valid unless the
+	// request asks for another duration, up to maxLoginLinkTTL
+	defaultLoginLinkTTL = 5 * time.Minute
+	maxLoginLinkTTL     = time.Hour
+)
+
+// TokenAuth holds the access token checked by its middleware. The token can be
+// replaced at runtime; connections that are already open are not affected.
+type TokenAuth struct {
+	mu           sync.RWMutex
+	token        string
+	controlToken string
+	links        map[string]loginLink // unused login links by code
+}
+
+// loginLink logs a browser in once, then opens an optional session
+type loginLink struct {
+	session string
+	expires time.Time
+}
+
+// NewTokenAuth creates a TokenAuth for the given token
+func NewTokenAuth(token string) *TokenAuth {
+	return &TokenAuth{token: token, links: make(map[string]loginLink)}
+}
+
+// SetToken replaces the access token
+func (a *TokenAuth) SetToken(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = token
+}
+
+// SetControlToken sets the token local rvc commands read from the runtime
+// file. It is accepted as a Bearer header in addition to the access token.
+func (a *TokenAuth) SetControlToken(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.controlToken = token
+}
+
+// Token returns the current access token
+func (a *TokenAuth) Token() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.token
+}
+
+// RequireToken returns middleware that rejects requests without the access token.
+// The token is accepted as a Bearer Authorization header, a ?token= query
+// parameter (which also sets a cookie so the dashboard keeps working), or the
+// cookie itself. An empty token disables authentication.
+func (a *TokenAuth) RequireToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := a.Token()
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		if query := c.Query("token"); query != "" && tokenEqual(query, token) {
+			setAuthCookie(c, query)
+			c.Next()
+			return
+		}
+		if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && (tokenEqual(bearer, token) || a.isControlToken(bearer)) {
+			c.Next()
+			return
+		}
+		if cookie, err := c.Cookie(authCookie); err == nil && tokenEqual(cookie, token) {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "missing or invalid access token",
+			"code":  "unauthorized",
+		})
+	}
+}
+
+// RequireCommandAuth returns middleware for endpoints that change sessions,
+// run commands or read files. While no access token is set, RequireToken lets
+// every request through, so these endpoints then only accept the control
+// token of local rvc commands.
+func (a *TokenAuth) RequireCommandAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a.Token() != "" {
+			c.Next()
+			return
+		}
+		if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && a.isControlToken(bearer) {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "set auth.token to change sessions or read their files through the API",
+			"code":  "token_required",
+		})
+	}
+}
+
+// RequireKnownHost returns middleware that, while no access token is set,
+// rejects requests whose Host header names neither an IP address, localhost
+// nor one of hosts. A page of another site whose name was made to resolve to
+// this machine (DNS rebinding) is same-origin with itself, so SameOrigin lets
+// it through, but its requests carry its own name.
+func (a *TokenAuth) RequireKnownHost(hosts ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a.Token() != "" || knownHost(c.Request.Host, hosts) {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "unknown host " + c.Request.Host + "; set auth.token to reach the server by name",
+			"code":  "unknown_host",
+		})
+	}
+}
+
+// knownHost reports whether a Host header names an IP address, localhost or
+// one of hosts
+func knownHost(hostport string, hosts []string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if net.ParseIP(strings.Trim(host, "[]")) != nil || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	for _, h := range hosts {
+		if h != "" && strings.EqualFold(host, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateLoginLink issues a one-time link that logs a browser in without
+// typing the token, e.g. on a phone. The link optionally opens a session.
+// POST /api/v1/auth/links
+func (a *TokenAuth) CreateLoginLink(c *gin.Context) {
+	var req struct {
+		Session    string `json:"session"`
+		TTLSeconds int    `json:"ttl_seconds"`
+	}
+	// The body is optional
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondBadRequest(c, err.Error())
+		return
+	}
+	if req.Session != "" && !tmux.IsValidSessionName(req.Session) {
+		respondError(c, tmux.ErrInvalidSessionName)
+		return
+	}
+	ttl := defaultLoginLinkTTL
+	if req.TTLSeconds != 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl <= 0 || ttl > maxLoginLinkTTL {
+		respondBadRequest(c, "ttl_seconds must be between 1 and 3600")
+		return
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		respondError(c, err)
+		return
+	}
+	code := base64.RawURLEncoding.EncodeToString(buf)
+	now := time.Now()
+	link := loginLink{session: req.Session, expires: now.Add(ttl)}
+
+	a.mu.Lock()
+	for k, l := range a.links {
+		if now.After(l.expires) {
+			delete(a.links, k)
+		}
+	}
+	a.links[code] = link
+	a.mu.Unlock()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"path":       "/login/" + code,
+		"session":    link.session,
+		"expires_at": link.expires,
+	})
+}
+
+// RedeemLoginLink logs the browser in with a login link and redirects to
+// the dashboard. A link works only once.
+// GET /login/:code
+func (a *TokenAuth) RedeemLoginLink(c *gin.Context) {
+	code := c.Param("code")
+	a.mu.Lock()
+	link, ok := a.links[code]
+	delete(a.links, code)
+	token := a.token
+	a.mu.Unlock()
+
+	if !ok || time.Now().After(link.expires) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "login link is invalid, already used or expired",
+			"code":  "invalid_login_link",
+		})
+		return
+	}
+	log.Printf("Login link used from %s", c.ClientIP())
+
+	if token != "" {
+		setAuthCookie(c, token)
+	}
+	target := "/"
+	if link.session != "" {
+		target += "?session=" + url.QueryEscape(link.session)
+	}
+	c.Redirect(http.StatusSeeOther, target)
+}
+
+// setAuthCookie keeps the browser logged in for the rest of its session
+func setAuthCookie(c *gin.Context, token string) {
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(authCookie, token, 0, "/", "", c.Request.TLS != nil, true)
+}
+
+func (a *TokenAuth) isControlToken(token string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.controlToken != "" && tokenEqual(token, a.controlToken)
+}
+
+func tokenEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/audit"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+type setBufferRequest struct {
+	Content string `json:"content"`
+}
+
+type pasteBufferRequest struct {
+	Buffer string `json:"buffer" binding:"required"`
+	Pane   string `json:"pane"`
+}
+
+// ListBuffers lists the tmux paste buffers
+// GET /api/v1/tmux/buffers
+func (h *TmuxHandlers) ListBuffers(c *gin.Context) {
+	buffers, err := tmux.ListBuffers()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"buffers": buffers,
+	})
+}
+
+// GetBuffer returns the content of a paste buffer, as JSON or, with
+// Accept: text/plain, as the raw text
+// GET /api/v1/tmux/buffers/:buffer
+func (h *TmuxHandlers) GetBuffer(c *gin.Context) {
+	name := c.Param("buffer")
+	data, err := tmux.ShowBuffer(name)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEPlain) == gin.MIMEPlain {
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", data)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"name":    name,
+		"content": string(data),
+	})
+}
+
+// CreateBuffer stores text in a new automatically named paste buffer
+// POST /api/v1/tmux/buffers
+func (h *TmuxHandlers) CreateBuffer(c *gin.Context) {
+	h.storeBuffer(c, "", http.StatusCreated)
+}
+
+// SetBuffer creates or replaces a named paste buffer
+// PUT /api/v1/tmux/buffers/:buffer
+func (h *TmuxHandlers) SetBuffer(c *gin.Context) {
+	name := c.Param("buffer")
+	if !tmux.IsValidBufferName(name) {
+		respondError(c, tmux.ErrInvalidBuffer)
+		return
+	}
+	h.storeBuffer(c, name, http.StatusOK)
+}
+
+// storeBuffer reads the buffer content from a JSON ({"content"}) or text/plain body
+func (h *TmuxHandlers) storeBuffer(c *gin.Context, name string, status int) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, tmux.MaxBufferBytes)
+
+	var content string
+	var err error
+	if strings.HasPrefix(c.ContentType(), "text/plain") {
+		var data []byte
+		data, err = io.ReadAll(c.Request.Body)
+		content = string(data)
+	} else {
+		var req setBufferRequest
+		err = c.ShouldBindJSON(&req)
+		content = req.Content
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "buffer exceeds " + strconv.Itoa(tmux.MaxBufferBytes) + " bytes",
+				"code":  "input_too_large",
+			})
+			return
+		}
+		respondBadRequest(c, err.Error())
+		return
+	}
+
+	entry := audit.Entry{
+		Action: "set_buffer",
+		Remote: c.ClientIP(),
+		Details: map[string]interface{}{
+			"buffer": name,
+			"bytes":  len(content),
+		},
+	}
+	name, err = tmux.SetBuffer(name, []byte(content))
+	if err != nil {
+		entry.Error = err.Error()
+		h.audit.Record(entry)
+		respondError(c, err)
+		return
+	}
+	entry.Details["buffer"] = name
+	h.audit.Record(entry)
+
+	c.JSON(status, gin.H{
+		"name": name,
+		"size": len(content),
+	})
+}
+
+// DeleteBuffer removes a paste buffer
+// DELETE /api/v1/tmux/buffers/:buffer
+func (h *TmuxHandlers) DeleteBuffer(c *gin.Context) {
+	name := c.Param("buffer")
+	entry := audit.Entry{
+		Action:  "delete_buffer",
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{"buffer": name},
+	}
+	if err := tmux.DeleteBuffer(name); err != nil {
+		entry.Error = err.Error()
+		h.audit.Record(entry)
+		respondError(c, err)
+		return
+	}
+	h.audit.Record(entry)
+	c.Status(http.StatusNoContent)
+}
+
+// PasteBuffer pastes a paste buffer into a writable session
+// POST /api/v1/tmux/sessions/:name/paste
+func (h *TmuxHandlers) PasteBuffer(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	var req pasteBufferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+
+	entry := audit.Entry{
+		Action:  "paste_buffer",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{
+			"buffer": req.Buffer,
+			"pane":   req.Pane,
+		},
+	}
+	if !h.requireWritable(c, entry) {
+		return
+	}
+
+	target, err := tmux.PaneTarget(sessionName, req.Pane)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := tmux.PasteBuffer(req.Buffer, target); err != nil {
+		entry.Error = err.Error()
+		h.audit.Record(entry)
+		respondError(c, err)
+		return
+	}
+	h.audit.Record(entry)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "pasted",
+	})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+// respondError writes a structured error response: {"error": message, "code": code}.
+// Errors wrapping a tmux.TmuxError use its code and a matching HTTP status;
+// anything else is reported as an internal error.
+func respondError(c *gin.Context, err error) {
+	var tmuxErr *tmux.TmuxError
+	if !errors.As(err, &tmuxErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "internal_error"})
+		return
+	}
+
+	c.JSON(statusForCode(tmuxErr.Code), gin.H{
+		"error": err.Error(),
+		"code":  tmuxErr.Code,
+	})
+}
+
+// respondBadRequest writes a structured error for a malformed request
+func respondBadRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{"error": message, "code": "invalid_request"})
+}
+
+// statusForCode maps TmuxError codes to HTTP status codes
+func statusForCode(code string) int {
+	switch code {
+	case tmux.ErrInvalidSessionName.Code, tmux.ErrInvalidWindow.Code, tmux.ErrInvalidPane.Code, tmux.ErrInvalidBuffer.Code,
+		tmux.ErrInvalidOptions.Code:
+		return http.StatusBadRequest
+	case tmux.ErrSessionNotFound.Code, tmux.ErrWindowNotFound.Code, tmux.ErrPaneNotFound.Code, tmux.ErrBufferNotFound.Code,
+		tmux.ErrServerNotFound.Code:
+		return http.StatusNotFound
+	case tmux.ErrSessionExists.Code:
+		return http.StatusConflict
+	case tmux.ErrSessionReadOnly.Code:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/audit"
+	"github.com/ibrahim/remote-vibecode/internal/files"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+// MaxUploadBytes limits the total size of a single upload request
+const MaxUploadBytes = 64 << 20
+
+type uploadedFile struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// ListFiles lists a directory below the working directory of a session's pane.
+// Query parameters: path (relative, default "."), pane (default active pane).
+// GET /api/v1/tmux/sessions/:name/files
+func (h *TmuxHandlers) ListFiles(c *gin.Context) {
+	root, ok := h.sessionRoot(c)
+	if !ok {
+		return
+	}
+
+	dir := c.DefaultQuery("path", ".")
+	entries, err := root.List(dir)
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"root":    root.Dir(),
+		"path":    dir,
+		"entries": entries,
+	})
+}
+
+// DownloadFile sends a file below the working directory of a session's pane
+// GET /api/v1/tmux/sessions/:name/files/download?path=...
+func (h *TmuxHandlers) DownloadFile(c *gin.Context) {
+	sessionName := c.Param("name")
+	root, ok := h.sessionRoot(c)
+	if !ok {
+		return
+	}
+
+	path := c.Query("path")
+	entry := audit.Entry{
+		Action:  "download_file",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{
+			"root": root.Dir(),
+			"path": path,
+		},
+	}
+	if path == "" {
+		respondBadRequest(c, "path is required")
+		return
+	}
+
+	file, info, err := root.Open(path)
+	if err != nil {
+		entry.Error = err.Error()
+		h.audit.Record(entry)
+		respondFileError(c, err)
+		return
+	}
+	defer file.Close()
+	entry.Details["bytes"] = info.Size()
+	h.audit.Record(entry)
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": info.Name()}))
+	c.Header("Content-Type", "application/octet-stream")
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), file)
+}
+
+// UploadFiles stores multipart "file" parts in a directory below the working
+// directory of a writable session's pane. Query parameters: path (target
+// directory, default "."), pane, overwrite=true to replace existing files.
+// POST /api/v1/tmux/sessions/:name/files
+func (h *TmuxHandlers) UploadFiles(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	dir := c.DefaultQuery("path", ".")
+	overwrite := c.Query("overwrite") == "true"
+	entry := audit.Entry{
+		Action:  "upload_file",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{
+			"path":      dir,
+			"overwrite": overwrite,
+		},
+	}
+	if !h.requireWritable(c, entry) {
+		return
+	}
+
+	root, ok := h.sessionRoot(c)
+	if !ok {
+		return
+	}
+	entry.Details["root"] = root.Dir()
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxUploadBytes)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		respondBadRequest(c, "expected a multipart/form-data body: "+err.Error())
+		return
+	}
+
+	uploaded := []uploadedFile{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			entry.Error = err.Error()
+			h.audit.Record(entry)
+			respondFileError(c, err)
+			return
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		fileEntry := entry
+		fileEntry.Details = map[string]interface{}{"name": part.FileName()}
+		for k, v := range entry.Details {
+			fileEntry.Details[k] = v
+		}
+
+		path, size, err := root.Create(dir, part.FileName(), part, overwrite)
+		part.Close()
+		if err != nil {
+			fileEntry.Error = err.Error()
+			h.audit.Record(fileEntry)
+			respondFileError(c, err)
+			return
+		}
+		fileEntry.Details["bytes"] = size
+		h.audit.Record(fileEntry)
+
+		uploaded = append(uploaded, uploadedFile{
+			Name: part.FileName(),
+			Path: root.Rel(path),
+			Size: size,
+		})
+	}
+
+	if len(uploaded) == 0 {
+		respondBadRequest(c, `no "file" parts in upload`)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"files": uploaded,
+	})
+}
+
+// paneDir resolves the working directory of the pane given by the "pane"
+// query parameter (default: active pane) and writes an error response on failure
+func (h *TmuxHandlers) paneDir(c *gin.Context) (string, bool) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return "", false
+	}
+
+	target, err := tmux.PaneTarget(sessionName, c.Query("pane"))
+	if err != nil {
+		respondError(c, err)
+		return "", false
+	}
+	dir, err := tmux.PaneCurrentPath(target)
+	if err != nil {
+		respondError(c, err)
+		return "", false
+	}
+	return dir, true
+}
+
+// sessionRoot confines file access to the pane's working directory (see paneDir)
+func (h *TmuxHandlers) sessionRoot(c *gin.Context) (*files.Root, bool) {
+	dir, ok := h.paneDir(c)
+	if !ok {
+		return nil, false
+	}
+	root, err := files.NewRoot(dir)
+	if err != nil {
+		respondFileError(c, err)
+		return nil, false
+	}
+	return root, true
+}
+
+// respondFileError maps filesystem errors to structured error responses
+func respondFileError(c *gin.Context, err error) {
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxErr):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "upload exceeds " + strconv.Itoa(MaxUploadBytes) + " bytes",
+			"code":  "upload_too_large",
+		})
+	case errors.Is(err, os.ErrNotExist):
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found", "code": "file_not_found"})
+	case errors.Is(err, files.ErrOutsideRoot):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "path_outside_root"})
+	case errors.Is(err, files.ErrExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "file_exists"})
+	case errors.Is(err, files.ErrNotDirectory), errors.Is(err, files.ErrNotRegular), errors.Is(err, files.ErrInvalidName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "invalid_path"})
+	case errors.Is(err, os.ErrPermission):
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied", "code": "permission_denied"})
+	default:
+		respondError(c, err)
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/git"
+)
+
+// gitTimeout bounds the git commands run for a single request
+const gitTimeout = 20 * time.Second
+
+// GitStatus returns the branch, ahead/behind counts and changed files of the
+// repository a session is working in, with staged and unstaged diffs per file.
+// Query parameters: pane (default active pane), diffs=false to skip diffs.
+// GET /api/v1/tmux/sessions/:name/git
+func (h *TmuxHandlers) GitStatus(c *gin.Context) {
+	repo, ctx, cancel, ok := h.sessionRepo(c)
+	if !ok {
+		return
+	}
+	defer cancel()
+
+	status, err := repo.Status(ctx, c.Query("diffs") != "false")
+	if err != nil {
+		respondGitError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// GitDiff returns the diff of a single file, relative to the repository root.
+// Query parameters: path, staged=true for the staged side, pane.
+// GET /api/v1/tmux/sessions/:name/git/diff
+func (h *TmuxHandlers) GitDiff(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		respondBadRequest(c, "path is required")
+		return
+	}
+
+	repo, ctx, cancel, ok := h.sessionRepo(c)
+	if !ok {
+		return
+	}
+	defer cancel()
+
+	staged := c.Query("staged") == "true"
+	file, diff, err := repo.Diff(ctx, path, staged)
+	if err != nil {
+		respondGitError(c, err)
+		return
+	}
+	fileStatus := file.Unstaged
+	if staged {
+		fileStatus = file.Staged
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"path":      file.Path,
+		"orig_path": file.OrigPath,
+		"staged":    staged,
+		"status":    fileStatus,
+		"diff":      diff,
+	})
+}
+
+// sessionRepo opens the git repository containing the pane's working directory
+func (h *TmuxHandlers) sessionRepo(c *gin.Context) (*git.Repo, context.Context, context.CancelFunc, bool) {
+	dir, ok := h.paneDir(c)
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), gitTimeout)
+	repo, err := git.Open(ctx, dir)
+	if err != nil {
+		cancel()
+		respondGitError(c, err)
+		return nil, nil, nil, false
+	}
+	return repo, ctx, cancel, true
+}
+
+// respondGitError maps git errors to structured error responses
+func respondGitError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, git.ErrNotRepository):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "not_a_repository"})
+	case errors.Is(err, git.ErrFileNotChanged):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "file_not_changed"})
+	case errors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "git timed out", "code": "git_timeout"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "git_failed"})
+	}
+}
@@ -2,18 +2,42 @@ package api
 
 import (
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/config"
 )
 
-type Handlers struct{}
+type Handlers struct {
+	mu        sync.RWMutex
+	ui        config.UI
+	startedAt time.Time
+}
 
 func New() *Handlers {
-	return &Handlers{}
+	return &Handlers{ui: config.Defaults().UI, startedAt: time.Now()}
 }
 
 func (h *Handlers) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status": "ok",
+		"status":     "ok",
+		"started_at": h.startedAt,
+		"time":       time.Now(),
 	})
 }
+
+// SetUIPreferences replaces the dashboard preferences served to browsers
+func (h *Handlers) SetUIPreferences(ui config.UI) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ui = ui
+}
+
+// UIPreferences returns the dashboard preferences from the server config
+// GET /api/v1/ui/preferences
+func (h *Handlers) UIPreferences(c *gin.Context) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	c.JSON(http.StatusOK, h.ui)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/audit"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+// MaxInputBytes limits the size of a single text input request
+const MaxInputBytes = 64 * 1024
+
+type sendInputRequest struct {
+	Text      string `json:"text"`
+	Enter     bool   `json:"enter"`
+	Bracketed bool   `json:"bracketed_paste"`
+	Pane      string `json:"pane"`
+}
+
+// SendInput sends literal text to a writable session.
+// The body is either JSON ({"text", "enter", "bracketed_paste", "pane"}) or,
+// with Content-Type text/plain, the raw text with options as query parameters.
+// POST /api/v1/tmux/sessions/:name/input
+func (h *TmuxHandlers) SendInput(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxInputBytes)
+	req, err := parseInputRequest(c)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "input exceeds " + strconv.Itoa(MaxInputBytes) + " bytes",
+				"code":  "input_too_large",
+			})
+			return
+		}
+		respondBadRequest(c, err.Error())
+		return
+	}
+	if req.Text == "" && !req.Enter {
+		respondBadRequest(c, "text is required")
+		return
+	}
+
+	entry := audit.Entry{
+		Action:  "input",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{
+			"bytes":     len(req.Text),
+			"enter":     req.Enter,
+			"bracketed": req.Bracketed,
+			"pane":      req.Pane,
+		},
+	}
+
+	if !h.requireWritable(c, entry) {
+		return
+	}
+
+	target, err := tmux.PaneTarget(sessionName, req.Pane)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := tmux.SendText(target, req.Text, req.Enter, req.Bracketed); err != nil {
+		entry.Error = err.Error()
+		h.audit.Record(entry)
+		respondError(c, err)
+		return
+	}
+	h.audit.Record(entry)
+	h.manager.RecordInput(sessionName)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "sent",
+		"bytes":  len(req.Text),
+	})
+}
+
+// parseInputRequest reads a JSON or text/plain input request
+func parseInputRequest(c *gin.Context) (*sendInputRequest, error) {
+	if strings.HasPrefix(c.ContentType(), "text/plain") {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		enter, _ := strconv.ParseBool(c.Query("enter"))
+		bracketed, _ := strconv.ParseBool(c.Query("bracketed_paste"))
+		return &sendInputRequest{
+			Text:      string(body),
+			Enter:     enter,
+			Bracketed: bracketed,
+			Pane:      c.Query("pane"),
+		}, nil
+	}
+
+	var req sendInputRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/audit"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+type sendKeysRequest struct {
+	Keys []string `json:"keys" binding:"required"`
+	Pane string   `json:"pane"` // optional: "%3" or "window.pane"; defaults to the active pane
+}
+
+type quickActionsRequest struct {
+	Actions []tmux.QuickAction `json:"actions"`
+}
+
+// SendKeys sends allowlisted tmux keys to a writable session
+// POST /api/v1/tmux/sessions/:name/keys
+func (h *TmuxHandlers) SendKeys(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	var req sendKeysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+	for _, key := range req.Keys {
+		if !tmux.IsAllowedKey(key) {
+			respondBadRequest(c, "key not allowed: "+key)
+			return
+		}
+	}
+
+	entry := audit.Entry{
+		Action:  "keys",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{"keys": req.Keys, "pane": req.Pane},
+	}
+
+	if !h.requireWritable(c, entry) {
+		return
+	}
+
+	target, err := tmux.PaneTarget(sessionName, req.Pane)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := tmux.SendKeys(target, req.Keys...); err != nil {
+		entry.Error = err.Error()
+		h.audit.Record(entry)
+		respondError(c, err)
+		return
+	}
+	h.audit.Record(entry)
+	h.manager.RecordInput(sessionName)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "sent",
+	})
+}
+
+// GetQuickActions returns the one-tap button set for a session
+// GET /api/v1/tmux/sessions/:name/actions
+func (h *TmuxHandlers) GetQuickActions(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	actions, err := tmux.GetQuickActions(sessionName)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"actions":  actions,
+		"writable": tmux.IsWritable(sessionName),
+	})
+}
+
+// SetQuickActions configures the one-tap button set for a session.
+// An empty list restores the default buttons.
+// PUT /api/v1/tmux/sessions/:name/actions
+func (h *TmuxHandlers) SetQuickActions(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	var req quickActionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+
+	if err := tmux.SetQuickActions(sessionName, req.Actions); err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+	h.audit.Record(audit.Entry{
+		Action:  "set_actions",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{"actions": req.Actions},
+	})
+
+	h.GetQuickActions(c)
+}
+
+// requireWritable checks that the entry's session accepts input, recording
+// the denied attempt and writing an error response otherwise
+func (h *TmuxHandlers) requireWritable(c *gin.Context, entry audit.Entry) bool {
+	if tmux.IsWritable(entry.Session) {
+		return true
+	}
+	entry.Error = tmux.ErrSessionReadOnly.Error()
+	h.audit.Record(entry)
+	respondError(c, tmux.ErrSessionReadOnly)
+	return false
+}
+
+// requireSession validates the session name and checks it exists,
+// writing an error response and returning false otherwise
+func (h *TmuxHandlers) requireSession(c *gin.Context, sessionName string) bool {
+	if !tmux.IsValidSessionName(sessionName) {
+		respondError(c, tmux.ErrInvalidSessionName)
+		return false
+	}
+	if !tmux.SessionExists(sessionName) {
+		respondError(c, tmux.ErrSessionNotFound)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/audit"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+type createSessionRequest struct {
+	Name     string            `json:"name" binding:"required"`
+	Command  string            `json:"command"`
+	Cwd      string            `json:"cwd"`
+	Writable bool              `json:"writable"`
+	Env      map[string]string `json:"env"`
+}
+
+type updateSessionRequest struct {
+	Name     *string `json:"name"`
+	Writable *bool   `json:"writable"`
+}
+
+// CreateSession creates a new tmux session
+// POST /api/v1/tmux/sessions
+func (h *TmuxHandlers) CreateSession(c *gin.Context) {
+	var req createSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+
+	entry := audit.Entry{
+		Action:  "create_session",
+		Session: req.Name,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{
+			"command":  req.Command,
+			"cwd":      req.Cwd,
+			"writable": req.Writable,
+			"env":      envNames(req.Env),
+		},
+	}
+
+	err := tmux.CreateSessionWithOptions(tmux.SessionOptions{
+		Name:    req.Name,
+		Command: req.Command,
+		Cwd:     req.Cwd,
+		Env:     req.Env,
+	})
+	if err == nil {
+		if err = tmux.SetWritable(req.Name, req.Writable); err != nil {
+			// Don't leave a session running whose writable flag is unknown
+			if killErr := tmux.KillSession(req.Name); killErr != nil {
+				err = fmt.Errorf("%w; the session was left running: %v", err, killErr)
+			} else {
+				err = fmt.Errorf("%w; the session was killed", err)
+			}
+		}
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		h.audit.Record(entry)
+		respondError(c, err)
+		return
+	}
+	h.audit.Record(entry)
+
+	sess, err := h.manager.AttachSession(req.Name)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	h.manager.Refresh()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"session": sessionJSON(sess),
+	})
+}
+
+// UpdateSession renames a session and/or changes its writable flag
+// PATCH /api/v1/tmux/sessions/:name
+func (h *TmuxHandlers) UpdateSession(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	var req updateSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+	if req.Name == nil && req.Writable == nil {
+		respondBadRequest(c, "nothing to update: set name and/or writable")
+		return
+	}
+
+	details := map[string]interface{}{}
+	if req.Name != nil {
+		details["name"] = *req.Name
+	}
+	if req.Writable != nil {
+		details["writable"] = *req.Writable
+	}
+	entry := audit.Entry{
+		Action:  "update_session",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: details,
+	}
+
+	if req.Name != nil && *req.Name != sessionName {
+		if err := h.manager.RenameSession(sessionName, *req.Name); err != nil {
+			entry.Error = err.Error()
+			h.audit.Record(entry)
+			respondError(c, err)
+			return
+		}
+		// The new name may leave out the label of the session's tmux server
+		sessionName, _ = tmux.RenamedName(sessionName, *req.Name)
+	}
+	if req.Writable != nil {
+		if err := tmux.SetWritable(sessionName, *req.Writable); err != nil {
+			entry.Error = err.Error()
+			h.audit.Record(entry)
+			respondError(c, err)
+			return
+		}
+	}
+	h.audit.Record(entry)
+
+	sess, err := h.manager.AttachSession(sessionName)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	h.manager.Refresh()
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": sessionJSON(sess),
+	})
+}
+
+// DeleteSession kills a tmux session
+// DELETE /api/v1/tmux/sessions/:name
+func (h *TmuxHandlers) DeleteSession(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	entry := audit.Entry{
+		Action:  "kill_session",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+	}
+	if err := tmux.KillSession(sessionName); err != nil {
+		entry.Error = err.Error()
+		h.audit.Record(entry)
+		respondError(c, err)
+		return
+	}
+	h.audit.Record(entry)
+	h.manager.Refresh()
+
+	c.Status(http.StatusNoContent)
+}
+
+// envNames returns only the variable names, so values (often secrets) stay out of the audit log
+func envNames(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	return names
+}
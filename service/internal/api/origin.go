@@ -0,0 +1,81 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// SameOrigin returns middleware that rejects state-changing requests and
+// WebSocket upgrades sent by pages of other sites. Browsers name the page's
+// origin in the Origin header, which must match the host the request was
+// sent to; clients that are not browsers, like rvc commands, send none.
+func SameOrigin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isSafeMethod(c.Request.Method) && !websocket.IsWebSocketUpgrade(c.Request) {
+			c.Next()
+			return
+		}
+
+		crossSite := false
+		if origin := c.GetHeader("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			crossSite = err != nil || !sameHost(u.Host, c.Request.Host, c.GetHeader("X-Forwarded-Host"))
+		} else {
+			// Without an Origin header, e.g. from older browsers, rely on the
+			// fetch metadata
+			site := c.GetHeader("Sec-Fetch-Site")
+			crossSite = site == "cross-site" || site == "same-site"
+		}
+		if crossSite {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "cross-origin requests are not allowed",
+				"code":  "cross_origin",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireJSON returns middleware that rejects request bodies that are not
+// JSON. Pages of other sites can send text/plain or form bodies without
+// asking the server first, so endpoints that parse JSON must not accept them.
+func RequireJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength == 0 || isSafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+				"error": "request body must be application/json",
+				"code":  "unsupported_media_type",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// sameHost reports whether origin names the host the request was sent to,
+// directly or through a proxy that set X-Forwarded-Host
+func sameHost(origin, host, forwardedHost string) bool {
+	if origin == "" {
+		return false
+	}
+	if strings.EqualFold(origin, host) {
+		return true
+	}
+	forwarded, _, _ := strings.Cut(forwardedHost, ",")
+	return strings.EqualFold(origin, strings.TrimSpace(forwarded))
+}
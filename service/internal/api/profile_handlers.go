@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/audit"
+	"github.com/ibrahim/remote-vibecode/internal/profile"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+type startProfileRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// ListProfiles returns the session profiles defined on the server
+// GET /api/v1/profiles
+func (h *TmuxHandlers) ListProfiles(c *gin.Context) {
+	profiles, ok := h.loadProfiles(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"profiles": profiles,
+	})
+}
+
+// StartProfile creates a session from a profile
+// POST /api/v1/profiles/:profile/start
+func (h *TmuxHandlers) StartProfile(c *gin.Context) {
+	profileName := c.Param("profile")
+
+	var req startProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+
+	profiles, ok := h.loadProfiles(c)
+	if !ok {
+		return
+	}
+	prof, err := profiles.Get(profileName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "profile_not_found"})
+		return
+	}
+	if prof.Agent != "" && !h.manager.AgentRegistry().Has(prof.Agent) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "unknown adapter: " + prof.Agent,
+			"code":  "unknown_adapter",
+		})
+		return
+	}
+
+	entry := audit.Entry{
+		Action:  "start_profile",
+		Session: req.Name,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{
+			"profile":  profileName,
+			"command":  prof.Command,
+			"cwd":      prof.Cwd,
+			"writable": prof.Writable,
+			"env":      envNames(prof.Env),
+		},
+	}
+	if err := profile.Start(req.Name, prof); err != nil {
+		entry.Error = err.Error()
+		h.audit.Record(entry)
+		// A session that failed part-way through setup is still tracked,
+		// so it can be inspected and stopped from the dashboard
+		if !errors.Is(err, tmux.ErrSessionExists) && tmux.SessionExists(req.Name) {
+			h.manager.AttachSession(req.Name)
+			h.manager.Refresh()
+		}
+		respondError(c, err)
+		return
+	}
+	h.audit.Record(entry)
+
+	sess, err := h.manager.AttachSession(req.Name)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	h.manager.Refresh()
+	c.JSON(http.StatusCreated, gin.H{
+		"profile": profileName,
+		"session": sessionJSON(sess),
+	})
+}
+
+// loadProfiles reads the profiles file, writing an error response on failure
+func (h *TmuxHandlers) loadProfiles(c *gin.Context) (profile.Profiles, bool) {
+	path := h.profilesPath()
+	if path == "" {
+		return profile.Profiles{}, true
+	}
+	profiles, err := profile.Load(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "invalid_profiles"})
+		return nil, false
+	}
+	return profiles, true
+}
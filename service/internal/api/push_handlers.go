@@ -0,0 +1,142 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/push"
+)
+
+// PushHandlers provides Web Push subscription endpoints
+type PushHandlers struct {
+	store  *push.Store
+	sender *push.Sender
+}
+
+// NewPushHandlers creates a new push handlers instance
+func NewPushHandlers(store *push.Store, sender *push.Sender) *PushHandlers {
+	return &PushHandlers{
+		store:  store,
+		sender: sender,
+	}
+}
+
+type subscribeRequest struct {
+	Endpoint string                `json:"endpoint" binding:"required"`
+	Keys     push.SubscriptionKeys `json:"keys" binding:"required"`
+	Sessions []string              `json:"sessions"`
+	Events   []string              `json:"events"`
+}
+
+type filtersRequest struct {
+	Sessions []string `json:"sessions"`
+	Events   []string `json:"events"`
+}
+
+// PublicKey returns the VAPID public key for PushManager.subscribe
+// GET /api/v1/push/vapid-public-key
+func (h *PushHandlers) PublicKey(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"public_key": h.sender.PublicKey(),
+	})
+}
+
+// ListSubscriptions lists all push subscriptions
+// GET /api/v1/push/subscriptions
+func (h *PushHandlers) ListSubscriptions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"subscriptions": h.store.List(),
+	})
+}
+
+// Subscribe registers a browser push subscription
+// POST /api/v1/push/subscriptions
+func (h *PushHandlers) Subscribe(c *gin.Context) {
+	var req subscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Keys.P256dh == "" || req.Keys.Auth == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keys.p256dh and keys.auth are required"})
+		return
+	}
+
+	sub, err := h.store.Add(&push.Subscription{
+		Endpoint: req.Endpoint,
+		Keys:     req.Keys,
+		Sessions: req.Sessions,
+		Events:   req.Events,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"subscription": sub,
+	})
+}
+
+// UpdateFilters replaces the session and event filters of a subscription
+// PUT /api/v1/push/subscriptions/:id
+func (h *PushHandlers) UpdateFilters(c *gin.Context) {
+	var req filtersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.store.SetFilters(c.Param("id"), req.Sessions, req.Events)
+	if err != nil {
+		c.JSON(pushErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"subscription": sub,
+	})
+}
+
+// Unsubscribe removes a push subscription
+// DELETE /api/v1/push/subscriptions/:id
+func (h *PushHandlers) Unsubscribe(c *gin.Context) {
+	if err := h.store.Remove(c.Param("id")); err != nil {
+		c.JSON(pushErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SendTest delivers a test notification to a single subscription
+// POST /api/v1/push/subscriptions/:id/test
+func (h *PushHandlers) SendTest(c *gin.Context) {
+	sub, ok := h.store.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": push.ErrSubscriptionNotFound.Error()})
+		return
+	}
+
+	err := h.sender.SendEvent(c.Request.Context(), sub, push.Event{
+		Type:  push.EventTest,
+		Title: "rvc notifications enabled",
+		Body:  "You will be notified about your sessions here.",
+		URL:   "/",
+	})
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "sent",
+	})
+}
+
+func pushErrorStatus(err error) int {
+	if errors.Is(err, push.ErrSubscriptionNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/ibrahim/remote-vibecode/internal/audit"
+	"github.com/ibrahim/remote-vibecode/internal/session"
 	"github.com/ibrahim/remote-vibecode/internal/tmux"
 	"github.com/ibrahim/remote-vibecode/internal/ws"
 )
@@ -23,33 +26,57 @@ var sessionsUpgrader = websocket.Upgrader{
 type TmuxHandlers struct {
 	manager    *tmux.Manager
 	sessionHub *ws.SessionHub
+	audit      *audit.Logger
+
+	mu       sync.RWMutex
+	profiles string // path of profiles.yaml, re-read on every request
 }
 
 // NewTmuxHandlers creates a new tmux handlers instance
-func NewTmuxHandlers(manager *tmux.Manager, sessionHub *ws.SessionHub) *TmuxHandlers {
+func NewTmuxHandlers(manager *tmux.Manager, sessionHub *ws.SessionHub, auditLog *audit.Logger) *TmuxHandlers {
 	return &TmuxHandlers{
 		manager:    manager,
 		sessionHub: sessionHub,
+		audit:      auditLog,
 	}
 }
 
+// SetProfilesPath sets the YAML file that session profiles are loaded from
+func (h *TmuxHandlers) SetProfilesPath(path string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.profiles = path
+}
+
+// profilesPath returns the YAML file that session profiles are loaded from
+func (h *TmuxHandlers) profilesPath() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.profiles
+}
+
 // ListSessions lists all active tmux sessions
 // GET /api/v1/tmux/sessions
 func (h *TmuxHandlers) ListSessions(c *gin.Context) {
 	sessions := h.manager.ListSessions()
 
+	// tmux's own view of each session: creation time, attached clients, cwd
+	summaries := make(map[string]tmux.SessionSummary)
+	if list, err := tmux.ListSessionSummaries(); err == nil {
+		for _, s := range list {
+			summaries[s.Name] = s
+		}
+	}
+
 	result := make([]map[string]interface{}, 0, len(sessions))
 	for _, sess := range sessions {
-		result = append(result, map[string]interface{}{
-			"id":           sess.ID,
-			"session_name": sess.SessionName,
-			"window_name":  sess.WindowName,
-			"pane_id":      sess.PaneID,
-			"status":       sess.GetStatus(),
-			"created_at":   sess.CreatedAt,
-			"last_capture": sess.LastCapture,
-			"writable":     tmux.IsWritable(sess.SessionName),
-		})
+		info := sessionJSON(sess)
+		info["windows"] = h.manager.WindowInfos(sess.SessionName)
+		if summary, ok := summaries[sess.SessionName]; ok {
+			info["tmux"] = summary
+		}
+		info["recording"], _ = tmux.Recording(sess.SessionName)
+		result = append(result, info)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -57,44 +84,57 @@ func (h *TmuxHandlers) ListSessions(c *gin.Context) {
 	})
 }
 
+// sessionJSON returns the REST representation of a tracked session
+func sessionJSON(sess *session.TmuxSession) map[string]interface{} {
+	activity := sess.Activity()
+	return map[string]interface{}{
+		"id":           sess.ID,
+		"session_name": sess.SessionName,
+		"window_name":  sess.WindowName,
+		"pane_id":      sess.PaneID,
+		"status":       activity.Status,
+		"state":        activity.State,
+		"viewers":      activity.Viewers,
+		"created_at":   sess.CreatedAt,
+		"last_capture": activity.LastOutput,
+		"last_input":   activity.LastInput,
+		"writable":     tmux.IsWritable(sess.SessionName),
+	}
+}
+
 // SessionWebSocket handles WebSocket connection for session list updates
 // GET /api/v1/sessions/ws
 func (h *TmuxHandlers) SessionWebSocket(c *gin.Context) {
-	conn, err := sessionsUpgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		log.Printf("Failed to upgrade to WebSocket: %v", err)
-		return
-	}
+	SessionWebSocket(h.sessionHub, h.manager.SessionInfos)(c)
+}
 
-	client := &ws.SessionClient{
-		Conn: conn,
-		Hub:  h.sessionHub,
-		Send: make(chan []byte, 256),
-	}
+// SessionWebSocket returns a handler that sends the sessions of local,
+// merged with those of the hub's peers, and then every broadcast of hub
+func SessionWebSocket(hub *ws.SessionHub, local func() []ws.SessionInfo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conn, err := sessionsUpgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			log.Printf("Failed to upgrade to WebSocket: %v", err)
+			return
+		}
 
-	h.sessionHub.Register(client)
+		client := &ws.SessionClient{
+			Conn: conn,
+			Hub:  hub,
+			Send: make(chan []byte, 256),
+		}
 
-	// Send current sessions immediately
-	sessions := h.manager.ListSessions()
-	sessionInfos := make([]ws.SessionInfo, 0, len(sessions))
-	for _, sess := range sessions {
-		sessionInfos = append(sessionInfos, ws.SessionInfo{
-			ID:          sess.ID,
-			SessionName: sess.SessionName,
-			CreatedAt:   sess.CreatedAt.Unix(),
-			LastCapture: sess.LastCapture.Unix(),
-			Writable:    tmux.IsWritable(sess.SessionName),
-		})
-	}
+		hub.Register(client)
 
-	// Send initial session list
-	jsonData, _ := json.Marshal(map[string]interface{}{
-		"type":     "sessions",
-		"sessions": sessionInfos,
-	})
-	client.Send <- jsonData
+		// Send initial session list
+		jsonData, _ := json.Marshal(map[string]interface{}{
+			"type":     "sessions",
+			"sessions": hub.WithPeers(local()),
+		})
+		client.Send <- jsonData
 
-	// Start pumps
-	go client.ReadPump()
-	go client.WritePump()
+		// Start pumps
+		go client.ReadPump()
+		go client.WritePump()
+	}
 }
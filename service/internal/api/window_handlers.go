@@ -0,0 +1,253 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ibrahim/remote-vibecode/internal/audit"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+type newWindowRequest struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	Cwd     string `json:"cwd"`
+	Select  bool   `json:"select"`
+}
+
+type splitPaneRequest struct {
+	Pane       string `json:"pane"` // pane to split: index or "%id"; defaults to the active pane
+	Horizontal bool   `json:"horizontal"`
+	Size       int    `json:"size"` // percent
+	Command    string `json:"command"`
+	Cwd        string `json:"cwd"`
+}
+
+// ListWindows lists the windows and panes of a session
+// GET /api/v1/tmux/sessions/:name/windows
+func (h *TmuxHandlers) ListWindows(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	windows, err := tmux.ListWindows(sessionName)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"windows": windows,
+	})
+}
+
+// NewWindow creates a window in a writable session
+// POST /api/v1/tmux/sessions/:name/windows
+func (h *TmuxHandlers) NewWindow(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	var req newWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+
+	entry := audit.Entry{
+		Action:  "new_window",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{"name": req.Name, "command": req.Command, "cwd": req.Cwd},
+	}
+	if !h.requireWritable(c, entry) {
+		return
+	}
+
+	window, err := tmux.NewWindow(sessionName, tmux.WindowOptions{
+		Name:    req.Name,
+		Command: req.Command,
+		Cwd:     req.Cwd,
+		Select:  req.Select,
+	})
+	if !h.recordResult(c, entry, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"window": window,
+	})
+}
+
+// SelectWindow makes a window the current window of a writable session
+// POST /api/v1/tmux/sessions/:name/windows/:window/select
+func (h *TmuxHandlers) SelectWindow(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	entry := audit.Entry{
+		Action:  "select_window",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{"window": c.Param("window")},
+	}
+	if !h.requireWritable(c, entry) {
+		return
+	}
+
+	target, err := tmux.WindowTarget(sessionName, c.Param("window"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !h.recordResult(c, entry, tmux.SelectWindow(target)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "selected",
+	})
+}
+
+// ListPanes lists the panes of a window
+// GET /api/v1/tmux/sessions/:name/windows/:window/panes
+func (h *TmuxHandlers) ListPanes(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	target, err := tmux.WindowTarget(sessionName, c.Param("window"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	panes, err := tmux.ListPanes(target)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"panes": panes,
+	})
+}
+
+// SplitPane splits a pane of a window in a writable session
+// POST /api/v1/tmux/sessions/:name/windows/:window/panes
+func (h *TmuxHandlers) SplitPane(c *gin.Context) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	var req splitPaneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+
+	entry := audit.Entry{
+		Action:  "split_window",
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{
+			"window":     c.Param("window"),
+			"pane":       req.Pane,
+			"horizontal": req.Horizontal,
+			"command":    req.Command,
+			"cwd":        req.Cwd,
+		},
+	}
+	if !h.requireWritable(c, entry) {
+		return
+	}
+
+	target, err := tmux.WindowTarget(sessionName, c.Param("window"))
+	if err == nil && req.Pane != "" {
+		target, err = tmux.WindowPaneTarget(sessionName, target, req.Pane)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	pane, err := tmux.SplitWindow(target, tmux.SplitOptions{
+		Horizontal: req.Horizontal,
+		Size:       req.Size,
+		Command:    req.Command,
+		Cwd:        req.Cwd,
+	})
+	if !h.recordResult(c, entry, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"pane": pane,
+	})
+}
+
+// SelectPane makes a pane the active pane of its window in a writable session
+// POST /api/v1/tmux/sessions/:name/windows/:window/panes/:pane/select
+func (h *TmuxHandlers) SelectPane(c *gin.Context) {
+	h.paneAction(c, "select_pane", tmux.SelectPane)
+}
+
+// KillPane destroys a pane in a writable session
+// DELETE /api/v1/tmux/sessions/:name/windows/:window/panes/:pane
+func (h *TmuxHandlers) KillPane(c *gin.Context) {
+	h.paneAction(c, "kill_pane", tmux.KillPane)
+}
+
+// paneAction resolves the :window/:pane target of a writable session and runs action on it
+func (h *TmuxHandlers) paneAction(c *gin.Context, name string, action func(target string) error) {
+	sessionName := c.Param("name")
+	if !h.requireSession(c, sessionName) {
+		return
+	}
+
+	entry := audit.Entry{
+		Action:  name,
+		Session: sessionName,
+		Remote:  c.ClientIP(),
+		Details: map[string]interface{}{"window": c.Param("window"), "pane": c.Param("pane")},
+	}
+	if !h.requireWritable(c, entry) {
+		return
+	}
+
+	target, err := tmux.WindowTarget(sessionName, c.Param("window"))
+	if err == nil {
+		target, err = tmux.WindowPaneTarget(sessionName, target, c.Param("pane"))
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !h.recordResult(c, entry, action(target)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+// recordResult audits the outcome of a structural change. On failure it writes
+// the error response and returns false; on success it refreshes the session
+// list so hub clients see the new layout.
+func (h *TmuxHandlers) recordResult(c *gin.Context, entry audit.Entry, err error) bool {
+	if err != nil {
+		entry.Error = err.Error()
+		h.audit.Record(entry)
+		respondError(c, err)
+		return false
+	}
+	h.audit.Record(entry)
+	h.manager.Refresh()
+	return true
+}
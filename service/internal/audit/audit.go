@@ -0,0 +1,66 @@
+// Package audit records actions taken on sessions through the API
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry is a single audit record, written as one JSON line
+type Entry struct {
+	Time    time.Time              `json:"time"`
+	Action  string                 `json:"action"`
+	Session string                 `json:"session,omitempty"`
+	Remote  string                 `json:"remote,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+// Logger appends audit entries to a file
+type Logger struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Open opens (or creates) the audit log at path for appending
+func Open(path string) (*Logger, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Logger{file: file}, nil
+}
+
+// Record writes an entry to the audit log. A nil Logger discards entries.
+func (l *Logger) Record(entry Entry) {
+	if l == nil {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Failed to marshal audit entry: %v", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		log.Printf("Failed to write audit entry: %v", err)
+	}
+}
+
+// Close closes the audit log
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,221 @@
+// Package client is a small client for the rvc REST API
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+	"github.com/ibrahim/remote-vibecode/internal/ws"
+)
+
+// Client talks to a running rvc server
+type Client struct {
+	BaseURL string // e.g. http://127.0.0.1:7676
+	Token   string // access token, sent as a Bearer header
+
+	// HTTPClient is used for all requests; replace it to customise TLS or timeouts
+	HTTPClient *http.Client
+}
+
+// New creates a client for the server at baseURL
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// PinCertificate makes the client trust only the server certificate with
+// the given hex SHA-256 fingerprint, e.g. a self-signed one
+func (c *Client) PinCertificate(fingerprint string) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		// The chain is not verified; the fingerprint check below replaces it
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("server sent no certificate")
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			if !strings.EqualFold(hex.EncodeToString(sum[:]), fingerprint) {
+				return errors.New("server certificate does not match the pinned fingerprint")
+			}
+			return nil
+		},
+	}
+	c.HTTPClient.Transport = transport
+}
+
+// Session is a session tracked by the server
+type Session struct {
+	ID          string               `json:"id"`
+	SessionName string               `json:"session_name"`
+	Status      string               `json:"status"`
+	State       string               `json:"state"`
+	Viewers     int                  `json:"viewers"`
+	Writable    bool                 `json:"writable"`
+	CreatedAt   time.Time            `json:"created_at"`
+	LastCapture time.Time            `json:"last_capture"` // last output
+	LastInput   time.Time            `json:"last_input"`
+	Recording   string               `json:"recording"` // log file, empty when not recording
+	Windows     []ws.WindowInfo      `json:"windows"`
+	Tmux        *tmux.SessionSummary `json:"tmux"`
+}
+
+// LastActivity returns the time of the latest input or output
+func (s *Session) LastActivity() time.Time {
+	if s.LastInput.After(s.LastCapture) {
+		return s.LastInput
+	}
+	return s.LastCapture
+}
+
+// CreateSessionRequest describes a session to create with CreateSession
+type CreateSessionRequest struct {
+	Name     string            `json:"name"`
+	Command  string            `json:"command,omitempty"`
+	Cwd      string            `json:"cwd,omitempty"`
+	Writable bool              `json:"writable"`
+	Env      map[string]string `json:"env,omitempty"`
+}
+
+// Error is an error response from the server
+type Error struct {
+	Status  int
+	Code    string `json:"code"`
+	Message string `json:"error"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server returned %d", e.Status)
+	}
+	return e.Message
+}
+
+// Health is the server's health check response
+type Health struct {
+	Status    string    `json:"status"`
+	StartedAt time.Time `json:"started_at"`
+	Time      time.Time `json:"time"` // the server's clock
+}
+
+// Health checks that the server is up. It does not need the token.
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var health Health
+	if err := c.do(ctx, http.MethodGet, "/api/v1/health", nil, &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// ListSessions returns the sessions tracked by the server
+func (c *Client) ListSessions(ctx context.Context) ([]Session, error) {
+	var resp struct {
+		Sessions []Session `json:"sessions"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/tmux/sessions", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Sessions, nil
+}
+
+// CreateSession creates a session on the server
+func (c *Client) CreateSession(ctx context.Context, req CreateSessionRequest) (*Session, error) {
+	var resp struct {
+		Session *Session `json:"session"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/tmux/sessions", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Session, nil
+}
+
+// StartProfile creates a session from one of the server's profiles
+func (c *Client) StartProfile(ctx context.Context, profile, name string) (*Session, error) {
+	var resp struct {
+		Session *Session `json:"session"`
+	}
+	path := "/api/v1/profiles/" + url.PathEscape(profile) + "/start"
+	if err := c.do(ctx, http.MethodPost, path, map[string]string{"name": name}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Session, nil
+}
+
+// DeleteSession kills a session on the server
+func (c *Client) DeleteSession(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/tmux/sessions/"+url.PathEscape(name), nil, nil)
+}
+
+// LoginLink is a one-time link that logs a browser in
+type LoginLink struct {
+	Path      string    `json:"path"` // relative to the server URL
+	Session   string    `json:"session"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateLoginLink asks the server for a login link valid for ttl that opens
+// session, if not empty. A zero ttl uses the server's default.
+func (c *Client) CreateLoginLink(ctx context.Context, session string, ttl time.Duration) (*LoginLink, error) {
+	req := map[string]interface{}{"session": session, "ttl_seconds": int(ttl.Seconds())}
+	var link LoginLink
+	if err := c.do(ctx, http.MethodPost, "/api/v1/auth/links", req, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// do sends a JSON request and decodes a JSON response into out (if not nil).
+// Non-2xx responses are returned as *Error.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &Error{Status: resp.StatusCode}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(apiErr)
+		return apiErr
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
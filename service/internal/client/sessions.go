@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/ibrahim/remote-vibecode/internal/ws"
+)
+
+// SessionWatch receives the session lists a server broadcasts to its dashboards
+type SessionWatch struct {
+	conn *websocket.Conn
+}
+
+// WatchSessions subscribes to the server's session list updates
+func (c *Client) WatchSessions(ctx context.Context) (*SessionWatch, error) {
+	conn, _, err := c.DialWebSocket(ctx, "/api/v1/sessions/ws", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionWatch{conn: conn}, nil
+}
+
+// Next returns the next session list. It fails if none arrives within
+// timeout; servers broadcast when sessions change and at least every
+// ws.KeepaliveInterval.
+func (w *SessionWatch) Next(timeout time.Duration) ([]ws.SessionInfo, error) {
+	for {
+		_ = w.conn.SetReadDeadline(time.Now().Add(timeout))
+		_, data, err := w.conn.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		var msg struct {
+			Type     string           `json:"type"`
+			Sessions []ws.SessionInfo `json:"sessions"`
+		}
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "sessions" {
+			continue
+		}
+		if msg.Sessions == nil {
+			msg.Sessions = []ws.SessionInfo{}
+		}
+		return msg.Sessions, nil
+	}
+}
+
+// Close closes the subscription
+func (w *SessionWatch) Close() error {
+	return w.conn.Close()
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// gotty protocol message types, as spoken by the server's /gotty endpoint
+	gottyOutput    = '1'
+	gottyInput     = '1'
+	gottyPing      = '2'
+	gottyPong      = '3'
+	gottyResize    = '4'
+	gottyClipboard = '5'
+)
+
+// ErrSessionEnded is returned by ReadOutput when the session no longer exists
+var ErrSessionEnded = errors.New("session ended")
+
+// Terminal is a connection to a session's terminal
+type Terminal struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+// DialTerminal connects to the terminal of a tmux session on the server.
+// Input is ignored by the server unless the session is writable.
+func (c *Client) DialTerminal(ctx context.Context, session string) (*Terminal, error) {
+	conn, _, err := c.DialWebSocket(ctx, "/gotty/"+url.PathEscape(session), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Terminal{conn: conn}, nil
+}
+
+// DialWebSocket opens a WebSocket to path on the server, authenticated and
+// with the same TLS settings and dialer as the HTTP client. header is sent
+// with the handshake, whose response is returned. A rejected handshake is
+// returned as *Error.
+func (c *Client) DialWebSocket(ctx context.Context, path string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	u, err := url.Parse(c.BaseURL + path)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	default:
+		return nil, nil, fmt.Errorf("unsupported server URL scheme: %s", u.Scheme)
+	}
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 10 * time.Second,
+	}
+	if transport, ok := c.HTTPClient.Transport.(*http.Transport); ok {
+		dialer.Proxy = transport.Proxy
+		dialer.TLSClientConfig = transport.TLSClientConfig
+		dialer.NetDialContext = transport.DialContext
+	}
+
+	header = header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if c.Token != "" {
+		header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			apiErr := &Error{Status: resp.StatusCode}
+			_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(apiErr)
+			return nil, resp, apiErr
+		}
+		return nil, nil, err
+	}
+	return conn, resp, nil
+}
+
+// ReadOutput returns the next chunk of terminal output. Pings from the
+// server are answered; clipboard messages are skipped because the output
+// already carries the OSC 52 sequence for the local terminal.
+func (t *Terminal) ReadOutput() ([]byte, error) {
+	for {
+		_, data, err := t.conn.ReadMessage()
+		if err != nil {
+			// The server closes normally only when the session has ended
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return nil, ErrSessionEnded
+			}
+			return nil, err
+		}
+		if len(data) == 0 {
+			continue
+		}
+		switch data[0] {
+		case gottyOutput:
+			return base64.StdEncoding.DecodeString(string(data[1:]))
+		case gottyPing:
+			if err := t.send([]byte{gottyPong}); err != nil {
+				return nil, err
+			}
+		case gottyPong, gottyClipboard:
+		}
+	}
+}
+
+// Input sends keyboard input to the terminal
+func (t *Terminal) Input(p []byte) error {
+	return t.send(append([]byte{gottyInput}, p...))
+}
+
+// Resize tells the server the size of the local terminal
+func (t *Terminal) Resize(cols, rows int) error {
+	return t.send([]byte(fmt.Sprintf("%c%d,%d", gottyResize, cols, rows)))
+}
+
+// Ping keeps the connection alive
+func (t *Terminal) Ping() error {
+	return t.send([]byte{gottyPing})
+}
+
+// Close closes the connection
+func (t *Terminal) Close() error {
+	t.writeMu.Lock()
+	_ = t.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	t.writeMu.Unlock()
+	return t.conn.Close()
+}
+
+func (t *Terminal) send(msg []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.conn.WriteMessage(websocket.TextMessage, msg)
+}
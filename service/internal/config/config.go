@@ -0,0 +1,440 @@
+// Package config loads the rvc configuration from defaults, a YAML file and
+// environment variables. Command-line flags are layered on top by the caller.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/ibrahim/remote-vibecode/internal/paths"
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
+	"github.com/ibrahim/remote-vibecode/internal/ws"
+)
+
+// Config is the complete rvc configuration
+type Config struct {
+	Server        Server        `yaml:"server"`
+	Auth          Auth          `yaml:"auth"`
+	Discovery     Discovery     `yaml:"discovery"`
+	Agents        Files         `yaml:"agents"`
+	Profiles      Files         `yaml:"profiles"`
+	Clipboard     string        `yaml:"clipboard"`
+	Recording     Recording     `yaml:"recording"`
+	Notifications Notifications `yaml:"notifications"`
+	UI            UI            `yaml:"ui"`
+	Federation    Federation    `yaml:"federation"`
+	Relay         Relay         `yaml:"relay"`
+}
+
+// Server holds the listener settings, which only take effect on restart
+type Server struct {
+	Host string `yaml:"host"`
+	Port string `yaml:"port"`
+	TLS  TLS    `yaml:"tls"`
+}
+
+// TLS enables HTTPS when both files are set
+type TLS struct {
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+}
+
+// Enabled reports whether a certificate and key are configured
+func (t TLS) Enabled() bool {
+	return t.Cert != "" && t.Key != ""
+}
+
+// LocalURL returns the URL at which this machine reaches the server; a
+// wildcard host is reached over the loopback address
+func (s Server) LocalURL() string {
+	host := s.Host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	scheme := "http"
+	if s.TLS.Enabled() {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, s.Port))
+}
+
+// Auth holds the access token; empty disables authentication
+type Auth struct {
+	Token string `yaml:"token"`
+}
+
+// Discovery controls how tmux sessions are found and sampled
+type Discovery struct {
+	Interval     Duration `yaml:"interval"`
+	AutoAttach   []string `yaml:"auto_attach"` // glob patterns of session names to track
+	ProcInterval Duration `yaml:"proc_interval"`
+	Sockets      []Socket `yaml:"sockets"` // tmux servers watched besides the default one
+}
+
+// Socket is a tmux server on another socket than the default one. Its
+// sessions are named "session@name".
+type Socket struct {
+	Name string `yaml:"name"` // also the socket name (tmux -L) unless Path is set
+	Path string `yaml:"path"` // socket path (tmux -S)
+}
+
+// Client returns the tmux client that addresses the socket
+func (s Socket) Client() tmuxclient.Client {
+	if s.Path != "" {
+		return tmuxclient.Client{SocketPath: s.Path}
+	}
+	return tmuxclient.Client{SocketName: s.Name}
+}
+
+// TmuxServers returns the sockets as labelled tmux servers
+func (d Discovery) TmuxServers() []tmuxclient.Server {
+	servers := make([]tmuxclient.Server, 0, len(d.Sockets))
+	for _, s := range d.Sockets {
+		servers = append(servers, tmuxclient.Server{Label: s.Name, Client: s.Client()})
+	}
+	return servers
+}
+
+// Files points at an optional YAML file
+type Files struct {
+	File string `yaml:"file"`
+}
+
+// Recording controls where session recordings are written
+type Recording struct {
+	Dir string `yaml:"dir"` // empty = <state dir>/recordings
+}
+
+// Notifications controls Web Push delivery
+type Notifications struct {
+	VAPIDSubject string   `yaml:"vapid_subject"`
+	Events       []string `yaml:"events"` // event types to push; empty = all
+}
+
+// UI holds dashboard preferences served to the browser
+type UI struct {
+	FontSize   int    `yaml:"font_size" json:"font_size"`
+	FontFamily string `yaml:"font_family" json:"font_family"`
+	Scrollback int    `yaml:"scrollback" json:"scrollback"`
+}
+
+// Federation lists peer servers whose sessions this server's dashboard
+// shows and whose terminals it proxies
+type Federation struct {
+	Peers []Peer `yaml:"peers"`
+}
+
+// Peer is another rvc server
+type Peer struct {
+	Name  string `yaml:"name"` // shown as the host of its sessions
+	URL   string `yaml:"url"`
+	Token string `yaml:"token"` // the peer's access token
+	// TLSFingerprint is the hex SHA-256 of the peer's certificate, to trust
+	// a self-signed one
+	TLSFingerprint string `yaml:"tls_fingerprint"`
+}
+
+// Relay configures 'rvc relay', which accepts agents, and 'rvc agent',
+// which connects this machine's server to a relay
+type Relay struct {
+	Agents  []RelayAgent `yaml:"agents"`  // agents the relay accepts
+	Connect RelayConnect `yaml:"connect"` // the relay 'rvc agent' connects to
+}
+
+// RelayAgent is an agent that may connect to the relay
+type RelayAgent struct {
+	Name   string `yaml:"name"` // shown as the host of its sessions
+	Secret string `yaml:"secret"`
+}
+
+// RelayConnect is the relay that 'rvc agent' connects to
+type RelayConnect struct {
+	URL    string `yaml:"url"`
+	Name   string `yaml:"name"` // the agent's name on the relay
+	Secret string `yaml:"secret"`
+	// TLSFingerprint is the hex SHA-256 of the relay's certificate, to trust
+	// a self-signed one
+	TLSFingerprint string `yaml:"tls_fingerprint"`
+}
+
+// Duration is a time.Duration written as a string such as "2s" in YAML
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Defaults returns the built-in configuration
+func Defaults() *Config {
+	return &Config{
+		Server: Server{Host: "127.0.0.1", Port: "7676"},
+		Discovery: Discovery{
+			Interval:     Duration(2 * time.Second),
+			AutoAttach:   []string{"*"},
+			ProcInterval: Duration(5 * time.Second),
+		},
+		Clipboard:     string(ws.ClipboardOn),
+		Notifications: Notifications{VAPIDSubject: "https://github.com/ibrahimsn98/remote-vibecode"},
+		UI: UI{
+			FontSize:   14,
+			FontFamily: "SF Mono, Monaco, Consolas, monospace",
+			Scrollback: 1000,
+		},
+	}
+}
+
+// Path returns the config file to use and whether it was chosen explicitly:
+// the given path, $RVC_CONFIG, or ~/.config/rvc/config.yaml
+func Path(override string) (string, bool, error) {
+	if override != "" {
+		return override, true, nil
+	}
+	if env := os.Getenv("RVC_CONFIG"); env != "" {
+		return env, true, nil
+	}
+	path, err := paths.ConfigFile("config.yaml")
+	return path, false, err
+}
+
+// Load builds the configuration from the defaults, the config file (see Path)
+// and the environment, and returns it with the file's path. The default file
+// is optional; an explicitly chosen one must exist.
+func Load(override string) (*Config, string, error) {
+	path, explicit, err := Path(override)
+	if err != nil {
+		return nil, "", err
+	}
+	cfg := Defaults()
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.UnmarshalWithOptions(data, cfg, yaml.Strict()); err != nil {
+			return nil, path, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+	default:
+		return nil, path, err
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, path, err
+	}
+	for _, p := range []*string{&cfg.Server.TLS.Cert, &cfg.Server.TLS.Key, &cfg.Agents.File, &cfg.Profiles.File, &cfg.Recording.Dir} {
+		*p = expandHome(*p)
+	}
+	return cfg, path, nil
+}
+
+// expandHome replaces a leading ~ in a path with the home directory
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, p[1:])
+}
+
+// envVars maps environment variables to the settings they override
+var envVars = []struct {
+	name string
+	set  func(c *Config, v string) error
+}{
+	{"RVC_HOST", func(c *Config, v string) error { c.Server.Host = v; return nil }},
+	{"RVC_PORT", func(c *Config, v string) error { c.Server.Port = v; return nil }},
+	{"RVC_TLS_CERT", func(c *Config, v string) error { c.Server.TLS.Cert = v; return nil }},
+	{"RVC_TLS_KEY", func(c *Config, v string) error { c.Server.TLS.Key = v; return nil }},
+	{"RVC_TOKEN", func(c *Config, v string) error { c.Auth.Token = v; return nil }},
+	{"RVC_DISCOVERY_INTERVAL", func(c *Config, v string) error { return c.Discovery.Interval.UnmarshalText([]byte(v)) }},
+	{"RVC_AUTO_ATTACH", func(c *Config, v string) error { c.Discovery.AutoAttach = splitList(v); return nil }},
+	{"RVC_PROC_INTERVAL", func(c *Config, v string) error { return c.Discovery.ProcInterval.UnmarshalText([]byte(v)) }},
+	{"RVC_AGENTS", func(c *Config, v string) error { c.Agents.File = v; return nil }},
+	{"RVC_PROFILES", func(c *Config, v string) error { c.Profiles.File = v; return nil }},
+	{"RVC_CLIPBOARD", func(c *Config, v string) error { c.Clipboard = v; return nil }},
+	{"RVC_RECORDING_DIR", func(c *Config, v string) error { c.Recording.Dir = v; return nil }},
+	{"RVC_VAPID_SUBJECT", func(c *Config, v string) error { c.Notifications.VAPIDSubject = v; return nil }},
+	{"RVC_NOTIFY_EVENTS", func(c *Config, v string) error { c.Notifications.Events = splitList(v); return nil }},
+	{"RVC_RELAY_URL", func(c *Config, v string) error { c.Relay.Connect.URL = v; return nil }},
+	{"RVC_RELAY_NAME", func(c *Config, v string) error { c.Relay.Connect.Name = v; return nil }},
+	{"RVC_RELAY_SECRET", func(c *Config, v string) error { c.Relay.Connect.Secret = v; return nil }},
+}
+
+// applyEnv overrides settings from RVC_* environment variables that are set
+func (c *Config) applyEnv() error {
+	for _, env := range envVars {
+		v, ok := os.LookupEnv(env.name)
+		if !ok {
+			continue
+		}
+		if err := env.set(c, v); err != nil {
+			return fmt.Errorf("%s: %w", env.name, err)
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// peerNamePattern matches peer and agent names, which appear in URL paths
+var peerNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// minRelaySecret is the minimum length of a relay agent's secret
+const minRelaySecret = 16
+
+// Validate checks the configuration for invalid values, reporting all of them
+func (c *Config) Validate() error {
+	var errs []error
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port: invalid port %q", c.Server.Port))
+	}
+	if (c.Server.TLS.Cert == "") != (c.Server.TLS.Key == "") {
+		errs = append(errs, errors.New("server.tls: cert and key must be set together"))
+	}
+	for _, f := range []string{c.Server.TLS.Cert, c.Server.TLS.Key} {
+		if f != "" {
+			if _, err := os.Stat(f); err != nil {
+				errs = append(errs, fmt.Errorf("server.tls: %w", err))
+			}
+		}
+	}
+	if c.Discovery.Interval <= 0 {
+		errs = append(errs, errors.New("discovery.interval: must be positive"))
+	}
+	if c.Discovery.ProcInterval < 0 {
+		errs = append(errs, errors.New("discovery.proc_interval: must not be negative"))
+	}
+	for _, pattern := range c.Discovery.AutoAttach {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("discovery.auto_attach: invalid pattern %q", pattern))
+		}
+	}
+	sockets := make(map[string]bool)
+	for i, s := range c.Discovery.Sockets {
+		field := fmt.Sprintf("discovery.sockets[%d]", i)
+		switch {
+		case !tmuxclient.IsValidLabel(s.Name):
+			errs = append(errs, fmt.Errorf("%s.name: %q must be letters, digits, '_' or '-'", field, s.Name))
+		case sockets[s.Name]:
+			errs = append(errs, fmt.Errorf("%s.name: duplicate socket %q", field, s.Name))
+		}
+		sockets[s.Name] = true
+		if s.Path != "" && !filepath.IsAbs(s.Path) {
+			errs = append(errs, fmt.Errorf("%s.path: %q is not absolute", field, s.Path))
+		}
+	}
+	if _, err := ws.ParseClipboardPolicy(c.Clipboard); err != nil {
+		errs = append(errs, fmt.Errorf("clipboard: %w", err))
+	}
+	if c.UI.FontSize < 6 || c.UI.FontSize > 72 {
+		errs = append(errs, fmt.Errorf("ui.font_size: %d is outside 6-72", c.UI.FontSize))
+	}
+	if c.UI.Scrollback < 0 {
+		errs = append(errs, errors.New("ui.scrollback: must not be negative"))
+	}
+	names := make(map[string]bool)
+	for i, p := range c.Federation.Peers {
+		field := fmt.Sprintf("federation.peers[%d]", i)
+		switch {
+		case !peerNamePattern.MatchString(p.Name):
+			errs = append(errs, fmt.Errorf("%s.name: %q must be letters, digits, '.', '_' or '-'", field, p.Name))
+		case names[p.Name]:
+			errs = append(errs, fmt.Errorf("%s.name: duplicate peer %q", field, p.Name))
+		}
+		names[p.Name] = true
+		if !isHTTPURL(p.URL) {
+			errs = append(errs, fmt.Errorf("%s.url: %q is not an http or https URL", field, p.URL))
+		}
+	}
+	// Agents connected to a relay are shown like peers
+	for i, a := range c.Relay.Agents {
+		field := fmt.Sprintf("relay.agents[%d]", i)
+		switch {
+		case !peerNamePattern.MatchString(a.Name):
+			errs = append(errs, fmt.Errorf("%s.name: %q must be letters, digits, '.', '_' or '-'", field, a.Name))
+		case names[a.Name]:
+			errs = append(errs, fmt.Errorf("%s.name: duplicate peer or agent %q", field, a.Name))
+		}
+		names[a.Name] = true
+		if len(a.Secret) < minRelaySecret {
+			errs = append(errs, fmt.Errorf("%s.secret: must be at least %d characters", field, minRelaySecret))
+		}
+	}
+	if conn := c.Relay.Connect; conn != (RelayConnect{}) {
+		if !isHTTPURL(conn.URL) {
+			errs = append(errs, fmt.Errorf("relay.connect.url: %q is not an http or https URL", conn.URL))
+		}
+		if !peerNamePattern.MatchString(conn.Name) {
+			errs = append(errs, fmt.Errorf("relay.connect.name: %q must be letters, digits, '.', '_' or '-'", conn.Name))
+		}
+		if len(conn.Secret) < minRelaySecret {
+			errs = append(errs, fmt.Errorf("relay.connect.secret: must be at least %d characters", minRelaySecret))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isHTTPURL reports whether s is an absolute http or https URL
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Redacted returns a copy that is safe to print
+func (c *Config) Redacted() *Config {
+	out := *c
+	if out.Auth.Token != "" {
+		out.Auth.Token = "********"
+	}
+	out.Federation.Peers = append([]Peer(nil), c.Federation.Peers...)
+	for i := range out.Federation.Peers {
+		if out.Federation.Peers[i].Token != "" {
+			out.Federation.Peers[i].Token = "********"
+		}
+	}
+	out.Relay.Agents = append([]RelayAgent(nil), c.Relay.Agents...)
+	for i := range out.Relay.Agents {
+		out.Relay.Agents[i].Secret = "********"
+	}
+	if out.Relay.Connect.Secret != "" {
+		out.Relay.Connect.Secret = "********"
+	}
+	return &out
+}
+
+// Marshal renders the configuration as YAML
+func (c *Config) Marshal() ([]byte, error) {
+	return yaml.Marshal(c)
+}
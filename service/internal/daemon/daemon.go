@@ -0,0 +1,89 @@
+// Package daemon manages the runtime file and log of a background rvc server
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/ibrahim/remote-vibecode/internal/paths"
+)
+
+// ChildEnv is set in the environment of a server started by Start, so it
+// runs in the foreground instead of daemonizing again
+const ChildEnv = "RVC_DAEMON_CHILD"
+
+// RestartSignal asks a running server to restart itself in place
+const RestartSignal = syscall.SIGUSR2
+
+// ErrNotRunning is returned when no server is running
+var ErrNotRunning = errors.New("rvc server is not running")
+
+// LogFile returns the path of the log written by a background server
+func LogFile() (string, error) {
+	return paths.StateFile("server.log")
+}
+
+// Alive reports whether a process exists
+func Alive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// Start runs the current executable with args as a detached background
+// process whose output is appended to the log file. It returns the
+// started process and a channel that receives its exit error.
+func Start(args []string) (*os.Process, <-chan error, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, nil, err
+	}
+	logPath, err := LogFile()
+	if err != nil {
+		return nil, nil, err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, args...)
+	cmd.Env = append(os.Environ(), ChildEnv+"=1")
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// A new session detaches the server from the terminal and its signals
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to start the server: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	return cmd.Process, exited, nil
+}
+
+// Stop sends SIGTERM to the server and waits up to timeout for it to exit
+func Stop(pid int, timeout time.Duration) error {
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		return fmt.Errorf("failed to stop the server (pid %d): %w", pid, err)
+	}
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+		if !Alive(pid) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("server (pid %d) did not exit within %s", pid, timeout)
+}
+
+// Restart asks the server to restart itself; it keeps its pid
+func Restart(pid int) error {
+	if err := syscall.Kill(pid, RestartSignal); err != nil {
+		return fmt.Errorf("failed to restart the server (pid %d): %w", pid, err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package daemon
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ibrahim/remote-vibecode/internal/paths"
+)
+
+// Runtime describes the running server. The server writes it to the state
+// directory at startup so local commands can find and authenticate to it.
+type Runtime struct {
+	PID       int       `json:"pid"`
+	URL       string    `json:"url"`  // where this machine reaches the server
+	Addr      string    `json:"addr"` // the address the server listens on
+	StartedAt time.Time `json:"started_at"`
+	// TLSFingerprint is the SHA-256 of the server certificate in hex, so
+	// clients can trust a self-signed certificate; empty without TLS
+	TLSFingerprint string `json:"tls_fingerprint,omitempty"`
+	// ControlToken is accepted by the API in addition to the access token.
+	// It is regenerated on every start and only readable by the owner.
+	ControlToken string `json:"control_token"`
+}
+
+// RuntimeFile returns the path of the runtime file in the state directory
+func RuntimeFile() (string, error) {
+	return paths.StateFile("server.json")
+}
+
+// NewControlToken returns a random control token
+func NewControlToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Fingerprint returns the hex SHA-256 of a DER encoded certificate
+func Fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// WriteRuntime records rt as the running server, readable only by the
+// owner. It fails if another live server has written the file.
+func WriteRuntime(rt Runtime) error {
+	if err := CheckNotRunning(); err != nil {
+		return err
+	}
+	path, err := RuntimeFile()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rt, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write a private temporary file and rename it, so readers never see a
+	// partial file and the token is never world-readable
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// CheckNotRunning returns an error if a server other than the current
+// process is running
+func CheckNotRunning() error {
+	if rt, err := Running(); err == nil && rt.PID != os.Getpid() {
+		return fmt.Errorf("rvc server is already running (pid %d at %s); stop it with 'rvc server stop'", rt.PID, rt.URL)
+	}
+	return nil
+}
+
+// RemoveRuntime removes the runtime file if it still belongs to the current process
+func RemoveRuntime() {
+	path, err := RuntimeFile()
+	if err != nil {
+		return
+	}
+	if rt, err := readRuntime(path); err == nil && rt.PID == os.Getpid() {
+		_ = os.Remove(path)
+	}
+}
+
+// Running returns the runtime of the running server. A file left behind by
+// a server that died is removed and reported as ErrNotRunning.
+func Running() (*Runtime, error) {
+	path, err := RuntimeFile()
+	if err != nil {
+		return nil, err
+	}
+	rt, err := readRuntime(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !Alive(rt.PID) {
+		_ = os.Remove(path)
+		return nil, ErrNotRunning
+	}
+	return rt, nil
+}
+
+func readRuntime(path string) (*Runtime, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rt Runtime
+	if err := json.Unmarshal(data, &rt); err != nil || rt.PID <= 0 {
+		return nil, fmt.Errorf("invalid runtime file %s", path)
+	}
+	return &rt, nil
+}
@@ -0,0 +1,277 @@
+// Package federation shows the sessions of peer rvc servers on this server's
+// dashboard and proxies requests for them to the peer that runs them
+package federation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ibrahim/remote-vibecode/internal/client"
+	"github.com/ibrahim/remote-vibecode/internal/ws"
+)
+
+const (
+	// peerTimeout is how long a peer may stay silent before it is considered
+	// gone; servers repeat their session list every ws.KeepaliveInterval
+	peerTimeout = 3 * ws.KeepaliveInterval
+	// maxBackoff caps the delay between attempts to reach a peer
+	maxBackoff = 10 * time.Second
+)
+
+// proxiedPaths are the paths forwarded to peers: terminals, the session API
+// used by the dashboard and the health check. Entries ending in a slash
+// match every path below them.
+var proxiedPaths = []string{"/gotty/", "/api/v1/tmux/", "/api/v1/health"}
+
+// Peer is another rvc server whose sessions are shown on this one
+type Peer struct {
+	Name           string
+	URL            string
+	Token          string // the peer's access token
+	TLSFingerprint string // hex SHA-256 of its certificate; empty uses the system roots
+}
+
+// Federation follows the session lists of its peers and feeds them to the
+// session hub. Peers can be replaced at runtime.
+type Federation struct {
+	hub   *ws.SessionHub
+	mu    sync.RWMutex
+	peers map[string]*peer
+}
+
+// Dialer opens a connection to a peer's server
+type Dialer func(ctx context.Context) (net.Conn, error)
+
+// peer is a followed peer and the proxy that forwards requests to it
+type peer struct {
+	Peer
+	tunnel bool // added with Connect rather than configured
+	client *client.Client
+	proxy  *httputil.ReverseProxy
+	stop   context.CancelFunc
+}
+
+// New creates a Federation without peers
+func New(hub *ws.SessionHub) *Federation {
+	return &Federation{hub: hub, peers: make(map[string]*peer)}
+}
+
+// SetPeers replaces the configured peers. Peers whose settings are
+// unchanged keep their connection; peers added with Connect are kept.
+func (f *Federation) SetPeers(peers []Peer) {
+	wanted := make(map[string]Peer, len(peers))
+	for _, p := range peers {
+		wanted[p.Name] = p
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for name, p := range f.peers {
+		if w, ok := wanted[name]; p.tunnel || (ok && w == p.Peer) {
+			continue
+		}
+		f.removeLocked(p)
+	}
+	for _, cfg := range peers {
+		if p, ok := f.peers[cfg.Name]; ok {
+			if p.tunnel {
+				log.Printf("Peer %s: the name is used by a connected agent; ignoring the configured peer", cfg.Name)
+			}
+			continue
+		}
+		c := client.New(cfg.URL, cfg.Token)
+		if cfg.TLSFingerprint != "" {
+			c.PinCertificate(cfg.TLSFingerprint)
+		}
+		f.peers[cfg.Name] = f.start(cfg, c, false)
+	}
+}
+
+// Connect adds a peer whose server is reached through dial, e.g. an agent
+// connected to a relay. It fails if a peer of that name exists. The
+// returned function removes the peer again.
+func (f *Federation) Connect(name string, dial Dialer) (func(), error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.peers[name]; ok {
+		return nil, fmt.Errorf("peer %q already exists", name)
+	}
+
+	// The host only names the peer; every connection goes through dial
+	c := client.New("http://"+name, "")
+	c.HTTPClient.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dial(ctx)
+		},
+	}
+	p := f.start(Peer{Name: name, URL: c.BaseURL}, c, true)
+	f.peers[name] = p
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.peers[name] == p {
+			f.removeLocked(p)
+		}
+	}, nil
+}
+
+// Stop disconnects from all peers
+func (f *Federation) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.peers {
+		f.removeLocked(p)
+	}
+}
+
+// start begins following a peer through c
+func (f *Federation) start(cfg Peer, c *client.Client, tunnel bool) *peer {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &peer{Peer: cfg, tunnel: tunnel, client: c, stop: cancel}
+	p.proxy = newProxy(p)
+	go f.watch(ctx, p)
+	return p
+}
+
+// removeLocked stops following p and hides its sessions; f.mu must be held
+func (f *Federation) removeLocked(p *peer) {
+	p.stop()
+	delete(f.peers, p.Name)
+	f.hub.SetPeerSessions(p.Name, nil)
+}
+
+// newProxy returns a reverse proxy to the peer that replaces the caller's
+// credentials for this server with the peer's token
+func newProxy(p *peer) *httputil.ReverseProxy {
+	// The URL was checked when the config was validated
+	target, _ := url.Parse(p.client.BaseURL)
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.SetXForwarded()
+			r.Out.Header.Del("Cookie")
+			r.Out.Header.Del("Authorization")
+			// This server checked the origin; the peer would see its own host
+			r.Out.Header.Del("Origin")
+			if p.Token != "" {
+				r.Out.Header.Set("Authorization", "Bearer "+p.Token)
+			}
+			query := r.Out.URL.Query()
+			query.Del("token")
+			r.Out.URL.RawQuery = query.Encode()
+		},
+		Transport: p.client.HTTPClient.Transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("Peer %s: proxy error for %s: %v", p.Name, r.URL.Path, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"error":"peer is not reachable","code":"peer_unreachable"}`))
+		},
+	}
+}
+
+// Proxy forwards a request for a peer's session to that peer. Only the
+// proxiedPaths are forwarded.
+// ANY /peers/:peer/*path
+func (f *Federation) Proxy(c *gin.Context) {
+	f.mu.RLock()
+	p := f.peers[c.Param("peer")]
+	f.mu.RUnlock()
+	if p == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown peer", "code": "peer_not_found"})
+		return
+	}
+
+	target := path.Clean(c.Param("path"))
+	if !Proxied(target) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not available through a peer", "code": "not_found"})
+		return
+	}
+
+	req := c.Request.Clone(c.Request.Context())
+	req.URL.Path = target
+	req.URL.RawPath = ""
+	p.proxy.ServeHTTP(c.Writer, req)
+}
+
+// Proxied reports whether requests for a clean path are forwarded to peers
+func Proxied(target string) bool {
+	for _, p := range proxiedPaths {
+		if target == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(target, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// watch follows a peer's session list until ctx is done, reconnecting with
+// backoff. The peer's sessions are hidden while it cannot be reached.
+func (f *Federation) watch(ctx context.Context, p *peer) {
+	backoff := time.Second
+	for {
+		connected, err := f.follow(ctx, p)
+		f.update(p, nil)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = time.Second
+		}
+		log.Printf("Peer %s: %v; reconnecting in %s", p.Name, err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// follow relays one connection's session lists to the hub and reports
+// whether the connection was established
+func (f *Federation) follow(ctx context.Context, p *peer) (bool, error) {
+	watch, err := p.client.WatchSessions(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer watch.Close()
+	// Closing the connection ends Next once the peer is removed
+	stop := context.AfterFunc(ctx, func() { _ = watch.Close() })
+	defer stop()
+	log.Printf("Peer %s: connected to %s", p.Name, p.client.BaseURL)
+
+	for {
+		sessions, err := watch.Next(peerTimeout)
+		if err != nil {
+			return true, err
+		}
+		// A peer that is federated itself also lists its own peers' sessions
+		own := make([]ws.SessionInfo, 0, len(sessions))
+		for _, s := range sessions {
+			if s.Host == "" {
+				own = append(own, s)
+			}
+		}
+		f.update(p, own)
+	}
+}
+
+// update shows the sessions of p unless it has been removed or replaced
+func (f *Federation) update(p *peer, sessions []ws.SessionInfo) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.peers[p.Name] == p {
+		f.hub.SetPeerSessions(p.Name, sessions)
+	}
+}
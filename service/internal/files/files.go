@@ -0,0 +1,224 @@
+// Package files provides confined access to a session's working directory
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrOutsideRoot is returned for paths that resolve outside the root directory
+	ErrOutsideRoot = errors.New("path is outside the session directory")
+	// ErrNotDirectory is returned when a directory was expected
+	ErrNotDirectory = errors.New("not a directory")
+	// ErrNotRegular is returned when a regular file was expected
+	ErrNotRegular = errors.New("not a regular file")
+	// ErrExists is returned when an upload would replace an existing file
+	ErrExists = errors.New("file already exists")
+	// ErrInvalidName is returned for upload file names that are empty or contain separators
+	ErrInvalidName = errors.New("invalid file name")
+)
+
+// Entry describes a file in a directory listing
+type Entry struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"`
+	IsDir   bool      `json:"is_dir"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Root is a directory that all file access is confined to
+type Root struct {
+	dir string // absolute, symlinks resolved
+}
+
+// NewRoot creates a Root for an existing directory
+func NewRoot(dir string) (*Root, error) {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, ErrNotDirectory
+	}
+	return &Root{dir: resolved}, nil
+}
+
+// Dir returns the resolved root directory
+func (r *Root) Dir() string {
+	return r.dir
+}
+
+// Resolve maps a path relative to the root onto the filesystem. ".." cannot
+// climb above the root, and symlinks are followed and must stay inside it.
+func (r *Root) Resolve(rel string) (string, error) {
+	// Cleaning against "/" drops any leading ".." before joining
+	joined := filepath.Join(r.dir, filepath.Clean("/"+rel))
+	resolved, err := filepath.EvalSymlinks(joined)
+	if err != nil {
+		return "", err
+	}
+	if !r.contains(resolved) {
+		return "", ErrOutsideRoot
+	}
+	return resolved, nil
+}
+
+// Rel returns path relative to the root, for display
+func (r *Root) Rel(path string) string {
+	rel, err := filepath.Rel(r.dir, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+func (r *Root) contains(path string) bool {
+	return path == r.dir || strings.HasPrefix(path, r.dir+string(filepath.Separator))
+}
+
+// List returns the entries of a directory below the root, directories first
+func (r *Root) List(rel string) ([]Entry, error) {
+	dir, err := r.Resolve(rel)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, ErrNotDirectory
+	}
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(dirEntries))
+	for _, de := range dirEntries {
+		info, err := de.Info()
+		if err != nil {
+			continue // removed while listing
+		}
+		entries = append(entries, Entry{
+			Name:    de.Name(),
+			Size:    info.Size(),
+			Mode:    info.Mode().String(),
+			IsDir:   info.IsDir(),
+			ModTime: info.ModTime(),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+// Open opens a regular file below the root for reading
+func (r *Root) Open(rel string) (*os.File, os.FileInfo, error) {
+	path, err := r.Resolve(rel)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		file.Close()
+		return nil, nil, ErrNotRegular
+	}
+	return file, info, nil
+}
+
+// Create writes src to a new file called name in the directory dirRel below
+// the root and returns its path. Content goes to a temporary file first, so a
+// failed or oversized upload never leaves a partial file behind. Existing
+// files are only replaced when overwrite is set, and never through a symlink.
+func (r *Root) Create(dirRel, name string, src io.Reader, overwrite bool) (string, int64, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || strings.ContainsRune(name, 0) {
+		return "", 0, ErrInvalidName
+	}
+
+	dir, err := r.Resolve(dirRel)
+	if err != nil {
+		return "", 0, err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", 0, err
+	}
+	if !info.IsDir() {
+		return "", 0, ErrNotDirectory
+	}
+
+	dest := filepath.Join(dir, name)
+	if existing, err := os.Lstat(dest); err == nil {
+		if !overwrite {
+			return "", 0, ErrExists
+		}
+		if !existing.Mode().IsRegular() {
+			return "", 0, ErrNotRegular
+		}
+	}
+
+	tmp, err := os.CreateTemp(dir, ".rvc-upload-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // only the temporary name; no-op once renamed
+
+	written, err := io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", 0, err
+	}
+
+	if !overwrite {
+		// Link fails if the name was taken while we were writing
+		err := os.Link(tmp.Name(), dest)
+		if err == nil {
+			return dest, written, nil
+		}
+		if errors.Is(err, os.ErrExist) {
+			return "", 0, ErrExists
+		}
+		// Filesystems without hard links: fall back to a checked rename
+		if _, err := os.Lstat(dest); err == nil {
+			return "", 0, ErrExists
+		}
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return "", 0, err
+	}
+	return dest, written, nil
+}
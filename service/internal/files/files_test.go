@@ -0,0 +1,146 @@
+package files
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestRoot creates a root with a file inside it, a secret next to it and
+// symlinks pointing both ways:
+//
+//	outside/secret.txt
+//	root/sub/a.txt
+//	root/link-in -> sub
+//	root/link-out -> ../outside
+//	root/secret.txt -> ../outside/secret.txt
+func newTestRoot(t *testing.T) (*Root, string) {
+	t.Helper()
+	base := t.TempDir()
+	for _, dir := range []string{"outside", "root/sub"} {
+		if err := os.MkdirAll(filepath.Join(base, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, content := range map[string]string{"outside/secret.txt": "secret", "root/sub/a.txt": "a"} {
+		if err := os.WriteFile(filepath.Join(base, path), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{
+		"root/link-in":    "sub",
+		"root/link-out":   "../outside",
+		"root/secret.txt": "../outside/secret.txt",
+	} {
+		if err := os.Symlink(target, filepath.Join(base, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root, err := NewRoot(filepath.Join(base, "root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root, base
+}
+
+func TestResolve(t *testing.T) {
+	root, _ := newTestRoot(t)
+	tests := []struct {
+		rel     string
+		want    string // relative to the root
+		wantErr error
+	}{
+		{rel: "", want: "."},
+		{rel: ".", want: "."},
+		{rel: "sub/a.txt", want: "sub/a.txt"},
+		{rel: "/sub/a.txt", want: "sub/a.txt"},
+		{rel: "sub/../sub/a.txt", want: "sub/a.txt"},
+		{rel: "link-in/a.txt", want: "sub/a.txt"},
+		{rel: "..", want: "."},
+		{rel: "../../..", want: "."},
+		{rel: "../outside/secret.txt", wantErr: fs.ErrNotExist},
+		{rel: "sub/../../outside/secret.txt", wantErr: fs.ErrNotExist},
+		{rel: "link-out", wantErr: ErrOutsideRoot},
+		{rel: "link-out/secret.txt", wantErr: ErrOutsideRoot},
+		{rel: "secret.txt", wantErr: ErrOutsideRoot},
+		{rel: "missing.txt", wantErr: fs.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			got, err := root.Resolve(tt.rel)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resolve(%q) = %q, %v, want %v", tt.rel, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q): %v", tt.rel, err)
+			}
+			if rel := root.Rel(got); rel != tt.want {
+				t.Errorf("Resolve(%q) = %q, want %q below the root", tt.rel, rel, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name      string
+		dir       string
+		file      string
+		overwrite bool
+		want      string // relative to the root
+		wantErr   error
+	}{
+		{name: "new file", dir: "sub", file: "b.txt", want: "sub/b.txt"},
+		{name: "root directory", dir: "", file: "b.txt", want: "b.txt"},
+		{name: "dot dot directory", dir: "../..", file: "b.txt", want: "b.txt"},
+		{name: "through a symlink inside", dir: "link-in", file: "b.txt", want: "sub/b.txt"},
+		{name: "through a symlink outside", dir: "link-out", file: "b.txt", wantErr: ErrOutsideRoot},
+		{name: "directory is a file", dir: "sub/a.txt", file: "b.txt", wantErr: ErrNotDirectory},
+		{name: "empty name", dir: "sub", file: "", wantErr: ErrInvalidName},
+		{name: "dot dot name", dir: "sub", file: "..", wantErr: ErrInvalidName},
+		{name: "name with slash", dir: "sub", file: "../../outside/b.txt", wantErr: ErrInvalidName},
+		{name: "name with backslash", dir: "sub", file: `..\b.txt`, wantErr: ErrInvalidName},
+		{name: "name with NUL", dir: "sub", file: "b\x00.txt", wantErr: ErrInvalidName},
+		{name: "existing file", dir: "sub", file: "a.txt", wantErr: ErrExists},
+		{name: "overwrite", dir: "sub", file: "a.txt", overwrite: true, want: "sub/a.txt"},
+		{name: "existing symlink", dir: "", file: "secret.txt", wantErr: ErrExists},
+		{name: "overwrite a symlink", dir: "", file: "secret.txt", overwrite: true, wantErr: ErrNotRegular},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, base := newTestRoot(t)
+			path, n, err := root.Create(tt.dir, tt.file, strings.NewReader("new"), tt.overwrite)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Create = %q, %v, want %v", path, err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+				if rel := root.Rel(path); rel != tt.want || n != 3 {
+					t.Errorf("Create = %q, %d, want %q, 3", rel, n, tt.want)
+				}
+				if data, err := os.ReadFile(path); err != nil || string(data) != "new" {
+					t.Errorf("content = %q, %v, want \"new\"", data, err)
+				}
+			}
+
+			if data, err := os.ReadFile(filepath.Join(base, "outside/secret.txt")); err != nil || string(data) != "secret" {
+				t.Errorf("outside file changed: %q, %v", data, err)
+			}
+			leftovers, _ := filepath.Glob(filepath.Join(base, "root", "*", ".rvc-upload-*"))
+			top, _ := filepath.Glob(filepath.Join(base, "root", ".rvc-upload-*"))
+			if len(leftovers)+len(top) > 0 {
+				t.Errorf("temporary files left behind: %v", append(leftovers, top...))
+			}
+		})
+	}
+}
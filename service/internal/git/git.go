@@ -0,0 +1,257 @@
+// Package git reads the state of a working tree for change review
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// MaxDiffBytes caps the size of a single file diff; longer diffs are truncated
+const MaxDiffBytes = 512 * 1024
+
+// MaxDiffFiles caps how many files get diffs in a Status; the rest only carry
+// their status and can be fetched one by one with Diff
+const MaxDiffFiles = 100
+
+// ErrNotRepository is returned when a directory is not inside a git work tree
+var ErrNotRepository = errors.New("not a git repository")
+
+// ErrFileNotChanged is returned by Diff for a path without changes
+var ErrFileNotChanged = errors.New("file has no changes")
+
+// Status describes a working tree: its branch and the changed files
+type Status struct {
+	Root     string `json:"root"`
+	Branch   string `json:"branch"` // empty when HEAD is detached
+	Head     string `json:"head"`   // commit ID, empty before the first commit
+	Upstream string `json:"upstream,omitempty"`
+	Ahead    int    `json:"ahead"`
+	Behind   int    `json:"behind"`
+	Files    []File `json:"files"`
+	// DiffsTruncated is set when more than MaxDiffFiles files changed
+	DiffsTruncated bool `json:"diffs_truncated,omitempty"`
+}
+
+// File is a changed file. Staged and Unstaged hold the porcelain status
+// letters (M, A, D, R, C, T, U) or "" when there is no change on that side.
+type File struct {
+	Path       string `json:"path"`
+	OrigPath   string `json:"orig_path,omitempty"` // source of a rename or copy
+	Staged     string `json:"staged"`
+	Unstaged   string `json:"unstaged"`
+	Untracked  bool   `json:"untracked,omitempty"`
+	Conflicted bool   `json:"conflicted,omitempty"`
+
+	StagedDiff   *FileDiff `json:"staged_diff,omitempty"`
+	UnstagedDiff *FileDiff `json:"unstaged_diff,omitempty"`
+}
+
+// FileDiff is the unified diff of one side (staged or unstaged) of a file
+type FileDiff struct {
+	Patch     string `json:"patch"`
+	Binary    bool   `json:"binary,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// Repo is a git work tree
+type Repo struct {
+	Root string
+}
+
+// Open finds the work tree containing dir
+func Open(ctx context.Context, dir string) (*Repo, error) {
+	output, err := run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	return &Repo{Root: strings.TrimSpace(string(output))}, nil
+}
+
+// Status reads the branch and changed files. With diffs set, each file
+// carries its staged and unstaged diffs (up to MaxDiffFiles files).
+func (r *Repo) Status(ctx context.Context, diffs bool) (*Status, error) {
+	output, err := run(ctx, r.Root, "status", "--porcelain=v2", "--branch", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	status := parseStatus(output)
+	status.Root = r.Root
+	if !diffs {
+		return status, nil
+	}
+
+	for i := range status.Files {
+		if i == MaxDiffFiles {
+			status.DiffsTruncated = true
+			break
+		}
+		f := &status.Files[i]
+		if f.Staged != "" {
+			if f.StagedDiff, err = r.diff(ctx, f, true); err != nil {
+				return nil, err
+			}
+		}
+		if f.Unstaged != "" || f.Untracked {
+			if f.UnstagedDiff, err = r.diff(ctx, f, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return status, nil
+}
+
+// Diff returns the staged or unstaged diff of a single changed file, with
+// renames detected. path is relative to the repository root.
+func (r *Repo) Diff(ctx context.Context, path string, staged bool) (*File, *FileDiff, error) {
+	status, err := r.Status(ctx, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range status.Files {
+		f := &status.Files[i]
+		if f.Path != path && f.OrigPath != path {
+			continue
+		}
+		if staged && f.Staged == "" || !staged && f.Unstaged == "" && !f.Untracked {
+			return f, nil, ErrFileNotChanged
+		}
+		d, err := r.diff(ctx, f, staged)
+		return f, d, err
+	}
+	return nil, nil, ErrFileNotChanged
+}
+
+// diff runs git diff for one file. Both sides of a rename are passed as
+// pathspecs so -M can pair them up.
+func (r *Repo) diff(ctx context.Context, f *File, staged bool) (*FileDiff, error) {
+	var args []string
+	switch {
+	case f.Untracked:
+		// Untracked files have no index entry; diff them against nothing
+		args = []string{"diff", "--no-index", "--", os.DevNull, f.Path}
+	case staged:
+		args = []string{"diff", "--cached", "-M", "--", f.Path}
+	default:
+		args = []string{"diff", "-M", "--", f.Path}
+	}
+	if f.OrigPath != "" && !f.Untracked {
+		args = append(args, f.OrigPath)
+	}
+
+	output, err := run(ctx, r.Root, args...)
+	if err != nil {
+		// --no-index exits with 1 when the files differ, which they always do here
+		var exitErr *exec.ExitError
+		if !f.Untracked || !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return nil, err
+		}
+	}
+
+	d := &FileDiff{Patch: string(output)}
+	if len(d.Patch) > MaxDiffBytes {
+		d.Patch = d.Patch[:MaxDiffBytes]
+		d.Truncated = true
+	}
+	d.Binary = strings.Contains(d.Patch, "\nBinary files ") || strings.HasPrefix(d.Patch, "Binary files ")
+	return d, nil
+}
+
+// parseStatus parses `git status --porcelain=v2 --branch -z`
+func parseStatus(output []byte) *Status {
+	status := &Status{Files: []File{}}
+	records := strings.Split(string(output), "\x00")
+	for i := 0; i < len(records); i++ {
+		rec := records[i]
+		switch {
+		case strings.HasPrefix(rec, "# branch.oid "):
+			if oid := strings.TrimPrefix(rec, "# branch.oid "); oid != "(initial)" {
+				status.Head = oid
+			}
+		case strings.HasPrefix(rec, "# branch.head "):
+			if head := strings.TrimPrefix(rec, "# branch.head "); head != "(detached)" {
+				status.Branch = head
+			}
+		case strings.HasPrefix(rec, "# branch.upstream "):
+			status.Upstream = strings.TrimPrefix(rec, "# branch.upstream ")
+		case strings.HasPrefix(rec, "# branch.ab "):
+			var ahead, behind int
+			if _, err := fmt.Sscanf(strings.TrimPrefix(rec, "# branch.ab "), "+%d -%d", &ahead, &behind); err == nil {
+				status.Ahead, status.Behind = ahead, behind
+			}
+		case strings.HasPrefix(rec, "1 "):
+			// 1 XY sub mH mI mW hH hI path
+			if f := strings.SplitN(rec, " ", 9); len(f) == 9 {
+				status.Files = append(status.Files, changedFile(f[1], f[8], ""))
+			}
+		case strings.HasPrefix(rec, "2 "):
+			// 2 XY sub mH mI mW hH hI Xscore path, then the original path as its own record
+			if f := strings.SplitN(rec, " ", 10); len(f) == 10 && i+1 < len(records) {
+				i++
+				status.Files = append(status.Files, changedFile(f[1], f[9], records[i]))
+			}
+		case strings.HasPrefix(rec, "u "):
+			// u XY sub m1 m2 m3 mW h1 h2 h3 path
+			if f := strings.SplitN(rec, " ", 11); len(f) == 11 {
+				file := changedFile(f[1], f[10], "")
+				file.Conflicted = true
+				status.Files = append(status.Files, file)
+			}
+		case strings.HasPrefix(rec, "? "):
+			status.Files = append(status.Files, File{
+				Path:      strings.TrimPrefix(rec, "? "),
+				Unstaged:  "?",
+				Untracked: true,
+			})
+		}
+	}
+	return status
+}
+
+func changedFile(xy, path, origPath string) File {
+	f := File{Path: path, OrigPath: origPath}
+	if len(xy) == 2 {
+		f.Staged = statusLetter(xy[0])
+		f.Unstaged = statusLetter(xy[1])
+	}
+	return f
+}
+
+func statusLetter(c byte) string {
+	if c == '.' {
+		return ""
+	}
+	return string(c)
+}
+
+// run executes git in dir with settings that keep it from taking locks
+// the agent may need or running hooks configured in the repository
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	base := []string{"-C", dir, "-c", "core.quotePath=false", "-c", "core.fsmonitor=false"}
+	if len(args) > 0 && args[0] == "diff" {
+		args = append([]string{"diff", "--no-color", "--no-ext-diff", "--no-textconv"}, args[1:]...)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", append(base, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0", "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(stderr.String(), "not a git repository") {
+			return nil, ErrNotRepository
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// Keep the exit code reachable for callers, but say what git complained about
+			return output, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return output, nil
+}
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// records joins porcelain v2 records the way `git status -z` terminates them
+func records(recs ...string) []byte {
+	return []byte(strings.Join(recs, "\x00") + "\x00")
+}
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		output []byte
+		want   *Status
+	}{
+		{
+			name:   "empty",
+			output: nil,
+			want:   &Status{Files: []File{}},
+		},
+		{
+			name: "branch with upstream",
+			output: records(
+				"# branch.oid 1f2e3d4c5b6a79881f2e3d4c5b6a79881f2e3d4c",
+				"# branch.head main",
+				"# branch.upstream origin/main",
+				"# branch.ab +2 -1",
+			),
+			want: &Status{
+				Branch:   "main",
+				Head:     "1f2e3d4c5b6a79881f2e3d4c5b6a79881f2e3d4c",
+				Upstream: "origin/main",
+				Ahead:    2,
+				Behind:   1,
+				Files:    []File{},
+			},
+		},
+		{
+			name: "initial commit on a detached head",
+			output: records(
+				"# branch.oid (initial)",
+				"# branch.head (detached)",
+			),
+			want: &Status{Files: []File{}},
+		},
+		{
+			name: "changed files",
+			output: records(
+				"# branch.oid (initial)",
+				"# branch.head main",
+				"1 M. N... 100644 100644 100644 aaaa bbbb staged.go",
+				"1 .M N... 100644 100644 100644 aaaa aaaa dir/with space.go",
+				"1 AD N... 000000 100644 000000 0000 cccc added then deleted.txt",
+				"2 R. N... 100644 100644 100644 aaaa aaaa R100 new name.go",
+				"old name.go",
+				"u UU N... 100644 100644 100644 100644 aaaa bbbb cccc conflict.go",
+				"? notes/todo.md",
+			),
+			want: &Status{
+				Branch: "main",
+				Files: []File{
+					{Path: "staged.go", Staged: "M"},
+					{Path: "dir/with space.go", Unstaged: "M"},
+					{Path: "added then deleted.txt", Staged: "A", Unstaged: "D"},
+					{Path: "new name.go", OrigPath: "old name.go", Staged: "R"},
+					{Path: "conflict.go", Staged: "U", Unstaged: "U", Conflicted: true},
+					{Path: "notes/todo.md", Unstaged: "?", Untracked: true},
+				},
+			},
+		},
+		{
+			name: "malformed records",
+			output: records(
+				"# branch.ab garbage",
+				"1 M. N... too short",
+			),
+			want: &Status{Files: []File{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseStatus(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStatus =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
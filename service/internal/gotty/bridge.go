@@ -9,6 +9,8 @@ import (
 
 	"github.com/creack/pty"
 	"github.com/google/uuid"
+
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
 )
 
 // Session represents an active gotty terminal session
@@ -34,13 +36,21 @@ func NewManager() *Manager {
 	}
 }
 
-// AttachToTmuxSession attaches to an existing tmux session
+// AttachToTmuxSession attaches to an existing tmux session, which may be on a
+// labelled tmux server ("session@label")
 func (m *Manager) AttachToTmuxSession(tmuxSessionName string) (*Session, error) {
+	client, name, err := tmuxclient.Resolve(tmuxSessionName)
+	if err != nil {
+		return nil, err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Create command to attach to tmux session
-	cmd := exec.Command("tmux", "attach", "-t", tmuxSessionName)
+	cmd := client.Command("attach", "-t", name)
+	// The browser runs xterm.js; an xterm TERM also lets tmux forward OSC 52 clipboard writes
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
 
 	// Start PTY
 	ptyFile, err := pty.Start(cmd)
@@ -127,6 +137,14 @@ func (s *Session) Read(p []byte) (n int, err error) {
 	return s.Pty.Read(p)
 }
 
+// Resize sets the terminal size of the PTY; tmux follows it
+func (s *Session) Resize(cols, rows uint16) error {
+	if s.IsClosed() {
+		return io.ErrClosedPipe
+	}
+	return pty.Setsize(s.Pty, &pty.Winsize{Cols: cols, Rows: rows})
+}
+
 // Write writes to the PTY
 func (s *Session) Write(p []byte) (n int, err error) {
 	if s.IsClosed() {
@@ -0,0 +1,60 @@
+// Package paths resolves the on-disk locations used by rvc
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// StateDir returns the directory where rvc keeps runtime state, creating it if needed.
+// It honours $XDG_STATE_HOME and falls back to ~/.local/state/rvc.
+func StateDir() (string, error) {
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve home directory: %w", err)
+		}
+		base = filepath.Join(home, ".local", "state")
+	}
+
+	dir := filepath.Join(base, "rvc")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create state directory: %w", err)
+	}
+	return dir, nil
+}
+
+// StateFile returns the path of a file inside the state directory
+func StateFile(name string) (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// ConfigDir returns the directory holding user-edited rvc configuration.
+// It honours $XDG_CONFIG_HOME and falls back to ~/.config/rvc. The directory
+// is not created, since every file in it is optional.
+func ConfigDir() (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve home directory: %w", err)
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "rvc"), nil
+}
+
+// ConfigFile returns the path of a file inside the config directory
+func ConfigFile(name string) (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,116 @@
+//go:build linux
+
+package procinfo
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of CPU times in /proc. It is 100 on every
+// architecture Linux supports today and cannot be read without cgo.
+const clockTicks = 100
+
+// readProcesses reads every process from /proc, together with the CPU time
+// each one has used so far
+func readProcesses(now time.Time) (map[int]*Process, map[int]time.Duration, error) {
+	boot, err := bootTime()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pageSize := int64(os.Getpagesize())
+	processes := make(map[int]*Process, len(entries))
+	cpu := make(map[int]time.Duration, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join("/proc", entry.Name())
+		stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue // exited while scanning
+		}
+		p, used, ok := parseStat(pid, stat, boot, pageSize)
+		if !ok {
+			continue
+		}
+		if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+			p.Args = strings.TrimSpace(string(bytes.ReplaceAll(cmdline, []byte{0}, []byte{' '})))
+		}
+		p.Runtime = int64(now.Sub(p.StartTime).Seconds())
+		processes[pid] = p
+		cpu[pid] = used
+	}
+	return processes, cpu, nil
+}
+
+// parseStat parses /proc/<pid>/stat. The command is in parentheses and may
+// itself contain spaces and parentheses, so fields are counted from the last ')'.
+func parseStat(pid int, stat []byte, boot time.Time, pageSize int64) (*Process, time.Duration, bool) {
+	open := bytes.IndexByte(stat, '(')
+	end := bytes.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return nil, 0, false
+	}
+	f := strings.Fields(string(stat[end+1:]))
+	// f[0] state, f[1] ppid, f[2] pgrp, f[5] tpgid, f[11] utime, f[12] stime, f[19] starttime, f[21] rss
+	if len(f) < 22 {
+		return nil, 0, false
+	}
+
+	utime, _ := strconv.ParseInt(f[11], 10, 64)
+	stime, _ := strconv.ParseInt(f[12], 10, 64)
+	start, _ := strconv.ParseInt(f[19], 10, 64)
+	rss, _ := strconv.ParseInt(f[21], 10, 64)
+
+	p := &Process{
+		PID:       pid,
+		PPID:      atoi(f[1]),
+		Command:   string(stat[open+1 : end]),
+		State:     f[0],
+		RSS:       rss * pageSize,
+		StartTime: boot.Add(time.Duration(start) * time.Second / clockTicks),
+		pgrp:      atoi(f[2]),
+		tpgid:     atoi(f[5]),
+	}
+	used := time.Duration(utime+stime) * time.Second / clockTicks
+	return p, used, true
+}
+
+// bootTime reads the system boot time from /proc/stat
+func bootTime() (time.Time, error) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			secs, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+	return time.Time{}, scanner.Err()
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
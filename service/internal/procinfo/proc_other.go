@@ -0,0 +1,64 @@
+//go:build !linux
+
+package procinfo
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readProcesses lists processes with ps where /proc is not available.
+// ps reports CPU usage itself, so no CPU times are returned.
+func readProcesses(now time.Time) (map[int]*Process, map[int]time.Duration, error) {
+	output, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,pgid=,tpgid=,rss=,pcpu=,etime=,state=,comm=").Output()
+	if err != nil {
+		return nil, nil, fmt.Errorf("ps failed: %w", err)
+	}
+
+	processes := make(map[int]*Process)
+	for _, line := range strings.Split(string(output), "\n") {
+		f := strings.Fields(line)
+		if len(f) < 9 {
+			continue
+		}
+		runtime := parseElapsed(f[6])
+		rss, _ := strconv.ParseInt(f[4], 10, 64)
+		cpu, _ := strconv.ParseFloat(f[5], 64)
+		p := &Process{
+			PID:        atoi(f[0]),
+			PPID:       atoi(f[1]),
+			Command:    strings.Join(f[8:], " "),
+			State:      f[7],
+			CPUPercent: cpu,
+			RSS:        rss * 1024,
+			StartTime:  now.Add(-runtime),
+			Runtime:    int64(runtime.Seconds()),
+			pgrp:       atoi(f[2]),
+			tpgid:      atoi(f[3]),
+		}
+		processes[p.PID] = p
+	}
+	return processes, nil, nil
+}
+
+// parseElapsed parses the ps etime format [[dd-]hh:]mm:ss
+func parseElapsed(s string) time.Duration {
+	var days int
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		days = atoi(d)
+		s = rest
+	}
+	var secs int
+	for _, part := range strings.Split(s, ":") {
+		secs = secs*60 + atoi(part)
+	}
+	return time.Duration(days*86400+secs) * time.Second
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,154 @@
+// Package procinfo samples process trees and resource usage for tmux panes
+package procinfo
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Process is a process and, in a tree, its descendants
+type Process struct {
+	PID        int        `json:"pid"`
+	PPID       int        `json:"ppid"`
+	Command    string     `json:"command"`
+	Args       string     `json:"args,omitempty"`
+	State      string     `json:"state"`
+	CPUPercent float64    `json:"cpu_percent"`
+	RSS        int64      `json:"rss"` // resident memory in bytes
+	StartTime  time.Time  `json:"start_time"`
+	Runtime    int64      `json:"runtime"` // seconds since start
+	Children   []*Process `json:"children,omitempty"`
+
+	pgrp  int // process group
+	tpgid int // foreground process group of the process's terminal
+}
+
+// PaneStats summarizes the processes running in a pane
+type PaneStats struct {
+	Foreground    string   `json:"foreground"` // command of the terminal's foreground process
+	ForegroundPID int      `json:"foreground_pid"`
+	CPUPercent    float64  `json:"cpu_percent"` // sum over the whole tree
+	RSS           int64    `json:"rss"`         // sum over the whole tree, in bytes
+	Runtime       int64    `json:"runtime"`     // seconds since the pane's shell started
+	Processes     int      `json:"processes"`
+	Tree          *Process `json:"tree"`
+}
+
+// Snapshot is the process table at one point in time
+type Snapshot struct {
+	Time      time.Time
+	processes map[int]*Process
+	children  map[int][]int
+}
+
+func newSnapshot(now time.Time, processes map[int]*Process) *Snapshot {
+	s := &Snapshot{Time: now, processes: processes, children: make(map[int][]int)}
+	for pid, p := range processes {
+		s.children[p.PPID] = append(s.children[p.PPID], pid)
+	}
+	for _, pids := range s.children {
+		sort.Ints(pids)
+	}
+	return s
+}
+
+// Tree returns a copy of the process rooted at pid with its descendants,
+// or nil if it is not running
+func (s *Snapshot) Tree(pid int) *Process {
+	p, ok := s.processes[pid]
+	if !ok {
+		return nil
+	}
+	node := *p
+	node.Children = nil
+	for _, child := range s.children[pid] {
+		if c := s.Tree(child); c != nil {
+			node.Children = append(node.Children, c)
+		}
+	}
+	return &node
+}
+
+// PaneStats summarizes the tree of a pane's root process (#{pane_pid}).
+// The foreground process is the leader of the terminal's foreground process
+// group, falling back to the deepest descendant in that group.
+func (s *Snapshot) PaneStats(panePID int) *PaneStats {
+	tree := s.Tree(panePID)
+	if tree == nil {
+		return nil
+	}
+
+	stats := &PaneStats{Runtime: tree.Runtime, Tree: tree}
+	var foreground *Process
+	var walk func(p *Process)
+	walk = func(p *Process) {
+		stats.Processes++
+		stats.CPUPercent = math.Round((stats.CPUPercent+p.CPUPercent)*10) / 10
+		stats.RSS += p.RSS
+		if tree.tpgid > 0 && p.pgrp == tree.tpgid && (foreground == nil || foreground.PID != tree.tpgid) {
+			foreground = p
+		}
+		for _, c := range p.Children {
+			walk(c)
+		}
+	}
+	walk(tree)
+
+	if foreground == nil {
+		foreground = tree
+	}
+	stats.Foreground = foreground.Command
+	stats.ForegroundPID = foreground.PID
+	return stats
+}
+
+// Sampler takes successive snapshots and derives CPU usage from the
+// CPU time consumed between them
+type Sampler struct {
+	mu       sync.Mutex
+	lastCPU  map[int]cpuSample // pid -> CPU time at the previous sample
+	lastTime time.Time
+}
+
+type cpuSample struct {
+	start time.Time // distinguishes reused PIDs
+	cpu   time.Duration
+}
+
+// NewSampler creates a new process sampler
+func NewSampler() *Sampler {
+	return &Sampler{lastCPU: make(map[int]cpuSample)}
+}
+
+// Sample reads the process table. CPU percentages are relative to the
+// previous call and are zero on the first one.
+func (s *Sampler) Sample() (*Snapshot, error) {
+	now := time.Now()
+	processes, cpu, err := readProcesses(now)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := now.Sub(s.lastTime)
+	current := make(map[int]cpuSample, len(processes))
+	for pid, p := range processes {
+		used, measured := cpu[pid]
+		if !measured {
+			continue // the platform reports CPU percentages directly
+		}
+		current[pid] = cpuSample{start: p.StartTime, cpu: used}
+		prev, ok := s.lastCPU[pid]
+		if ok && prev.start.Equal(p.StartTime) && elapsed > 0 {
+			p.CPUPercent = math.Round(float64(used-prev.cpu)/float64(elapsed)*1000) / 10
+		}
+	}
+	s.lastCPU = current
+	s.lastTime = now
+
+	return newSnapshot(now, processes), nil
+}
@@ -0,0 +1,203 @@
+// Package profile loads named session profiles and starts sessions from them
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/goccy/go-yaml"
+	"github.com/ibrahim/remote-vibecode/internal/paths"
+	"github.com/ibrahim/remote-vibecode/internal/tmux"
+)
+
+// DefaultName is the profile used by `rvc start` when none is given
+const DefaultName = "default"
+
+// ErrNotFound is returned when a profile is not defined
+var ErrNotFound = errors.New("profile not found")
+
+// Profile describes a session to start. The first window runs Command (or a
+// shell with Prompt); Windows adds further windows, each split into Panes.
+//
+//	profiles:
+//	  agent:
+//	    command: claude
+//	    cwd: ~/src
+//	    env: {EDITOR: vim}
+//	    writable: true
+//	    agent: claude
+//	    recording: true
+//	    windows:
+//	      - name: tests
+//	        command: make watch
+//	        panes:
+//	          - {command: htop, horizontal: true, size: 30}
+//	    triggers:
+//	      - {name: trust, match: 'Do you trust the files', keys: ["1"]}
+//	      - {name: done, match: 'Task complete', notify: true}
+type Profile struct {
+	Command   string            `json:"command,omitempty" yaml:"command"`
+	Prompt    string            `json:"prompt,omitempty" yaml:"prompt"`
+	Cwd       string            `json:"cwd,omitempty" yaml:"cwd"`
+	Env       map[string]string `json:"env,omitempty" yaml:"env"`
+	Writable  bool              `json:"writable" yaml:"writable"`
+	Agent     string            `json:"agent,omitempty" yaml:"agent"`
+	Recording bool              `json:"recording" yaml:"recording"`
+	Windows   []Window          `json:"windows,omitempty" yaml:"windows"`
+	Triggers  []tmux.Trigger    `json:"triggers,omitempty" yaml:"triggers"`
+}
+
+// Window is an extra window created when a profile starts
+type Window struct {
+	Name    string `json:"name,omitempty" yaml:"name"`
+	Command string `json:"command,omitempty" yaml:"command"`
+	Cwd     string `json:"cwd,omitempty" yaml:"cwd"`
+	Panes   []Pane `json:"panes,omitempty" yaml:"panes"`
+}
+
+// Pane is an extra pane split off a profile window
+type Pane struct {
+	Command    string `json:"command,omitempty" yaml:"command"`
+	Cwd        string `json:"cwd,omitempty" yaml:"cwd"`
+	Horizontal bool   `json:"horizontal,omitempty" yaml:"horizontal"`
+	Size       int    `json:"size,omitempty" yaml:"size"` // percent, 0 = half
+}
+
+// Profiles maps profile names to their definitions
+type Profiles map[string]Profile
+
+// DefaultPath returns ~/.config/rvc/profiles.yaml (honouring $XDG_CONFIG_HOME)
+func DefaultPath() (string, error) {
+	return paths.ConfigFile("profiles.yaml")
+}
+
+// Load reads the profiles defined in a YAML file. A missing file yields no
+// profiles rather than an error.
+func Load(path string) (Profiles, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Profiles{}, nil
+		}
+		return nil, err
+	}
+
+	var file struct {
+		Profiles Profiles `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if file.Profiles == nil {
+		file.Profiles = Profiles{}
+	}
+	for name, p := range file.Profiles {
+		for _, t := range p.Triggers {
+			if err := t.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: profile %s: %w", path, name, err)
+			}
+		}
+	}
+	return file.Profiles, nil
+}
+
+// Get returns a profile by name
+func (ps Profiles) Get(name string) (Profile, error) {
+	p, ok := ps[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return p, nil
+}
+
+// Names returns the profile names in sorted order
+func (ps Profiles) Names() []string {
+	names := make([]string, 0, len(ps))
+	for name := range ps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start creates a session from a profile: the session itself, the status
+// line, extra windows and panes, the writable flag, agent adapter, triggers
+// and recording. If a later step fails the session is left running and the
+// error is returned.
+func Start(sessionName string, p Profile) error {
+	if err := tmux.CreateSessionWithOptions(tmux.SessionOptions{
+		Name:    sessionName,
+		Prompt:  p.Prompt,
+		Command: p.Command,
+		Cwd:     p.Cwd,
+		Env:     p.Env,
+	}); err != nil {
+		return err
+	}
+
+	if err := setup(sessionName, p); err != nil {
+		if !tmux.SessionExists(sessionName) {
+			return fmt.Errorf("session %s exited during setup; its command may have failed", sessionName)
+		}
+		return err
+	}
+	return nil
+}
+
+// setup configures a freshly created session from its profile
+func setup(sessionName string, p Profile) error {
+	if err := tmux.SetStatusLine(sessionName); err != nil {
+		return err
+	}
+	if err := tmux.SetWritable(sessionName, p.Writable); err != nil {
+		return err
+	}
+
+	for _, w := range p.Windows {
+		cwd := w.Cwd
+		if cwd == "" {
+			cwd = p.Cwd
+		}
+		window, err := tmux.NewWindow(sessionName, tmux.WindowOptions{Name: w.Name, Command: w.Command, Cwd: cwd})
+		if err != nil {
+			return err
+		}
+		for _, pane := range w.Panes {
+			paneCwd := pane.Cwd
+			if paneCwd == "" {
+				paneCwd = cwd
+			}
+			if _, err := tmux.SplitWindow(window.ID, tmux.SplitOptions{
+				Horizontal: pane.Horizontal,
+				Size:       pane.Size,
+				Command:    pane.Command,
+				Cwd:        paneCwd,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	if len(p.Windows) > 0 {
+		// Leave the session on its first window
+		if err := tmux.SelectWindow(sessionName + ":^"); err != nil {
+			return err
+		}
+	}
+
+	if p.Agent != "" {
+		if err := tmux.SetAgent(sessionName, p.Agent); err != nil {
+			return err
+		}
+	}
+	if err := tmux.SetTriggers(sessionName, p.Triggers); err != nil {
+		return err
+	}
+	if p.Recording {
+		if _, err := tmux.StartRecording(sessionName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
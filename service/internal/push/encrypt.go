@@ -0,0 +1,107 @@
+package push
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// recordSize is the aes128gcm record size advertised in the content header
+	recordSize = 4096
+	// MaxPayloadSize is the largest plaintext that fits into a single record
+	// once the 86 byte header, padding delimiter and GCM tag are accounted for
+	MaxPayloadSize = recordSize - 86 - 1 - 16
+)
+
+// encrypt encrypts a payload for a subscription following RFC 8291 (aes128gcm)
+func encrypt(payload []byte, p256dh, authSecret string) ([]byte, error) {
+	if len(payload) > MaxPayloadSize {
+		return nil, fmt.Errorf("payload too large: %d bytes (max %d)", len(payload), MaxPayloadSize)
+	}
+
+	uaPublicRaw, err := b64.DecodeString(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	auth, err := b64.DecodeString(authSecret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+
+	// Ephemeral application server key, used once per message
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublicRaw := asPrivate.PublicKey().Bytes()
+
+	sharedSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("ECDH failed: %w", err)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public)
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublicRaw...)
+	keyInfo = append(keyInfo, asPublicRaw...)
+	ikm, err := hkdfBytes(sharedSecret, auth, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	cek, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Single record: payload followed by the 0x02 last-record delimiter
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	// Header: salt (16) || rs (4) || idlen (1) || keyid (as_public)
+	body := make([]byte, 0, 21+len(asPublicRaw)+len(plaintext)+gcm.Overhead())
+	body = append(body, salt...)
+	body = binary.BigEndian.AppendUint32(body, recordSize)
+	body = append(body, byte(len(asPublicRaw)))
+	body = append(body, asPublicRaw...)
+	body = gcm.Seal(body, nonce, plaintext, nil)
+
+	return body, nil
+}
+
+// hkdfBytes runs HKDF-SHA256 and reads length bytes of output
+func hkdfBytes(secret, salt, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, fmt.Errorf("HKDF failed: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,155 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// EventTest is the event type used for test notifications
+const EventTest = "test"
+
+// Event is a session event that can be delivered as a notification
+type Event struct {
+	Type    string    `json:"type"`
+	Session string    `json:"session,omitempty"`
+	Title   string    `json:"title"`
+	Body    string    `json:"body,omitempty"`
+	URL     string    `json:"url,omitempty"`
+	Time    time.Time `json:"timestamp"`
+}
+
+// Sender encrypts and delivers push messages to subscribed browsers
+type Sender struct {
+	keys  *VAPIDKeys
+	store *Store
+
+	mu      sync.RWMutex
+	subject string   // VAPID "sub" claim: a mailto: or https: contact URI
+	events  []string // event types delivered by Notify; empty = all
+
+	// HTTPClient is used to reach push services; replace it to target a mock service
+	HTTPClient *http.Client
+	// TTL is how long the push service should keep undelivered messages
+	TTL time.Duration
+}
+
+// NewSender creates a new push sender
+func NewSender(keys *VAPIDKeys, store *Store, subject string) *Sender {
+	return &Sender{
+		keys:       keys,
+		store:      store,
+		subject:    subject,
+		HTTPClient: &http.Client{Timeout: 15 * time.Second},
+		TTL:        12 * time.Hour,
+	}
+}
+
+// SetSubject changes the contact URI sent to push services
+func (s *Sender) SetSubject(subject string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subject = subject
+}
+
+// SetEvents limits Notify to the given event types; an empty list allows all.
+// Subscriptions can narrow this further with their own filters.
+func (s *Sender) SetEvents(events []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = events
+}
+
+// PublicKey returns the VAPID public key browsers need as applicationServerKey
+func (s *Sender) PublicKey() string {
+	return s.keys.PublicKey
+}
+
+// Notify delivers an event to every subscription whose filters match it.
+// Delivery happens in the background; expired subscriptions are removed.
+func (s *Sender) Notify(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	s.mu.RLock()
+	allowed := matchFilter(s.events, ev.Type)
+	s.mu.RUnlock()
+	if !allowed {
+		return
+	}
+
+	for _, sub := range s.store.List() {
+		if !sub.Matches(ev) {
+			continue
+		}
+		go func(sub *Subscription) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := s.SendEvent(ctx, sub, ev); err != nil {
+				log.Printf("Push delivery to %s failed: %v", sub.ID[:8], err)
+			}
+		}(sub)
+	}
+}
+
+// SendEvent delivers a single event to a subscription
+func (s *Sender) SendEvent(ctx context.Context, sub *Subscription, ev Event) error {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return s.Send(ctx, sub, payload)
+}
+
+// Send encrypts payload for the subscription and posts it to its push service
+func (s *Sender) Send(ctx context.Context, sub *Subscription, payload []byte) error {
+	body, err := encrypt(payload, sub.Keys.P256dh, sub.Keys.Auth)
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	subject := s.subject
+	s.mu.RUnlock()
+	auth, err := s.keys.authorization(sub.Endpoint, subject, time.Now().Add(12*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build push request: %w", err)
+	}
+	req.Header.Set("Authorization", auth)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(s.TTL.Seconds())))
+	req.Header.Set("Urgency", "high")
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("push request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		// The browser unsubscribed or the subscription expired
+		_ = s.store.Remove(sub.ID)
+		return fmt.Errorf("subscription expired (status %d), removed", resp.StatusCode)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push service returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// browser is the user agent side of a push subscription
+type browser struct {
+	private *ecdh.PrivateKey
+	auth    []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	t.Helper()
+	private, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	if _, err := rand.Read(auth); err != nil {
+		t.Fatal(err)
+	}
+	return &browser{private: private, auth: auth}
+}
+
+func (b *browser) keys() SubscriptionKeys {
+	return SubscriptionKeys{
+		P256dh: b64.EncodeToString(b.private.PublicKey().Bytes()),
+		Auth:   b64.EncodeToString(b.auth),
+	}
+}
+
+// decrypt reverses RFC 8291 the way a browser does
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	if len(body) < 21 {
+		t.Fatalf("body too short: %d bytes", len(body))
+	}
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != recordSize {
+		t.Errorf("record size = %d, want %d", rs, recordSize)
+	}
+	idlen := int(body[20])
+	asPublicRaw := body[21 : 21+idlen]
+	record := body[21+idlen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicRaw)
+	if err != nil {
+		t.Fatalf("invalid key id: %v", err)
+	}
+	sharedSecret, err := b.private.ECDH(asPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyInfo := append([]byte("WebPush: info\x00"), b.private.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, asPublicRaw...)
+	ikm, err := hkdfBytes(sharedSecret, b.auth, keyInfo, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cek, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := gcm.Open(nil, nonce, record, nil)
+	if err != nil {
+		t.Fatalf("failed to decrypt record: %v", err)
+	}
+	if len(plaintext) == 0 || plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatalf("record does not end with the last-record delimiter")
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+// pushService is a mock push service that records the last request
+type pushService struct {
+	*httptest.Server
+	status int
+
+	header http.Header
+	body   []byte
+}
+
+func newPushService(t *testing.T, status int) *pushService {
+	t.Helper()
+	ps := &pushService{status: status}
+	ps.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ps.header = r.Header.Clone()
+		ps.body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(ps.status)
+	}))
+	t.Cleanup(ps.Close)
+	return ps
+}
+
+func newTestSender(t *testing.T, endpoint string, b *browser) (*Sender, *Subscription) {
+	t.Helper()
+	keys, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(filepath.Join(t.TempDir(), "push.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := store.Add(&Subscription{Endpoint: endpoint + "/push/abc", Keys: b.keys()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSender(keys, store, "mailto:admin@example.com"), sub
+}
+
+// verifyVAPID checks the "vapid t=..., k=..." Authorization header of RFC 8292
+func verifyVAPID(t *testing.T, header, publicKey, audience, subject string) {
+	t.Helper()
+	params, ok := strings.CutPrefix(header, "vapid ")
+	if !ok {
+		t.Fatalf("Authorization = %q, want the vapid scheme", header)
+	}
+	var token, key string
+	for _, param := range strings.Split(params, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		switch name {
+		case "t":
+			token = value
+		case "k":
+			key = value
+		}
+	}
+	if key != publicKey {
+		t.Errorf("k = %q, want the VAPID public key %q", key, publicKey)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("t = %q, want a JWT", token)
+	}
+	var jwtHeader map[string]string
+	decodeJSON(t, parts[0], &jwtHeader)
+	if jwtHeader["alg"] != "ES256" {
+		t.Errorf("alg = %q, want ES256", jwtHeader["alg"])
+	}
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	decodeJSON(t, parts[1], &claims)
+	if claims.Aud != audience {
+		t.Errorf("aud = %q, want %q", claims.Aud, audience)
+	}
+	if claims.Sub != subject {
+		t.Errorf("sub = %q, want %q", claims.Sub, subject)
+	}
+	if exp := time.Unix(claims.Exp, 0); !exp.After(time.Now()) || exp.After(time.Now().Add(24*time.Hour)) {
+		t.Errorf("exp = %v, want within the next 24 hours", exp)
+	}
+
+	pub, err := b64.DecodeString(key)
+	if err != nil || len(pub) != 65 {
+		t.Fatalf("invalid k: %v", err)
+	}
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		t.Fatalf("invalid signature: %v", err)
+	}
+	verifier := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(pub[1:33]),
+		Y:     new(big.Int).SetBytes(pub[33:65]),
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !ecdsa.Verify(verifier, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		t.Error("VAPID signature does not verify with k")
+	}
+}
+
+func decodeJSON(t *testing.T, segment string, v interface{}) {
+	t.Helper()
+	data, err := b64.DecodeString(segment)
+	if err != nil {
+		t.Fatalf("invalid JWT segment %q: %v", segment, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("invalid JWT segment %q: %v", data, err)
+	}
+}
+
+func TestSendEvent(t *testing.T) {
+	ps := newPushService(t, http.StatusCreated)
+	b := newBrowser(t)
+	sender, sub := newTestSender(t, ps.URL, b)
+
+	ev := Event{
+		Type:    "awaiting_input",
+		Session: "api",
+		Title:   "api is waiting for input",
+		Time:    time.Unix(1700000000, 0).UTC(),
+	}
+	if err := sender.SendEvent(context.Background(), sub, ev); err != nil {
+		t.Fatalf("SendEvent: %v", err)
+	}
+
+	verifyVAPID(t, ps.header.Get("Authorization"), sender.PublicKey(), ps.URL, "mailto:admin@example.com")
+	if got := ps.header.Get("Content-Encoding"); got != "aes128gcm" {
+		t.Errorf("Content-Encoding = %q, want aes128gcm", got)
+	}
+	if ps.header.Get("TTL") == "" {
+		t.Error("TTL header is missing")
+	}
+
+	want, _ := json.Marshal(ev)
+	if got := b.decrypt(t, ps.body); !bytes.Equal(got, want) {
+		t.Errorf("decrypted payload = %s, want %s", got, want)
+	}
+	if _, ok := sender.store.Get(sub.ID); !ok {
+		t.Error("subscription was removed after a successful delivery")
+	}
+}
+
+func TestSendRemovesExpiredSubscription(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusGone} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			ps := newPushService(t, status)
+			sender, sub := newTestSender(t, ps.URL, newBrowser(t))
+
+			if err := sender.Send(context.Background(), sub, []byte("{}")); err == nil {
+				t.Fatal("Send succeeded, want an error")
+			}
+			if _, ok := sender.store.Get(sub.ID); ok {
+				t.Errorf("subscription was kept after status %d", status)
+			}
+		})
+	}
+}
+
+func TestSendKeepsSubscriptionOnServerError(t *testing.T) {
+	ps := newPushService(t, http.StatusInternalServerError)
+	sender, sub := newTestSender(t, ps.URL, newBrowser(t))
+
+	if err := sender.Send(context.Background(), sub, []byte("{}")); err == nil {
+		t.Fatal("Send succeeded, want an error")
+	}
+	if _, ok := sender.store.Get(sub.ID); !ok {
+		t.Error("subscription was removed after a server error")
+	}
+}
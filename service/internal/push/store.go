@@ -0,0 +1,173 @@
+package push
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription is a browser push subscription with optional event filters
+type Subscription struct {
+	ID        string           `json:"id"`
+	Endpoint  string           `json:"endpoint"`
+	Keys      SubscriptionKeys `json:"keys"`
+	Sessions  []string         `json:"sessions,omitempty"` // empty = all sessions
+	Events    []string         `json:"events,omitempty"`   // empty = all event types
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// SubscriptionKeys are the client keys from PushSubscription.toJSON()
+type SubscriptionKeys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// Matches reports whether the subscription wants to receive an event
+func (s *Subscription) Matches(ev Event) bool {
+	return matchFilter(s.Sessions, ev.Session) && matchFilter(s.Events, ev.Type)
+}
+
+func matchFilter(filter []string, value string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if f == value || f == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Store keeps push subscriptions and persists them to a JSON file.
+// Stored subscriptions are never modified, only replaced, so the pointers
+// returned by List and Get can be read without holding the lock.
+type Store struct {
+	mu   sync.RWMutex
+	path string
+	subs map[string]*Subscription // subscription ID -> Subscription
+}
+
+// NewStore creates a store backed by the file at path, loading existing subscriptions
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		subs: make(map[string]*Subscription),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read push subscriptions: %w", err)
+	}
+
+	var subs []*Subscription
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("failed to parse push subscriptions: %w", err)
+	}
+	for _, sub := range subs {
+		s.subs[sub.ID] = sub
+	}
+	return s, nil
+}
+
+// Add stores a subscription. A subscription with the same endpoint is replaced
+// in place so re-subscribing from the same browser keeps its ID.
+func (s *Store) Add(sub *Subscription) (*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Keep a copy the caller cannot modify
+	copied := *sub
+	sub = &copied
+	for _, existing := range s.subs {
+		if existing.Endpoint == sub.Endpoint {
+			sub.ID = existing.ID
+			sub.CreatedAt = existing.CreatedAt
+			break
+		}
+	}
+	if sub.ID == "" {
+		sub.ID = uuid.New().String()
+		sub.CreatedAt = time.Now()
+	}
+
+	s.subs[sub.ID] = sub
+	return sub, s.save()
+}
+
+// Get retrieves a subscription by ID
+func (s *Store) Get(id string) (*Subscription, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subs[id]
+	return sub, ok
+}
+
+// SetFilters replaces the session and event filters of a subscription
+func (s *Store) SetFilters(id string, sessions, events []string) (*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.subs[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	sub := *existing
+	sub.Sessions = sessions
+	sub.Events = events
+	s.subs[id] = &sub
+	return &sub, s.save()
+}
+
+// Remove deletes a subscription
+func (s *Store) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[id]; !ok {
+		return ErrSubscriptionNotFound
+	}
+	delete(s.subs, id)
+	return s.save()
+}
+
+// List returns all subscriptions
+func (s *Store) List() []*Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		result = append(result, sub)
+	}
+	return result
+}
+
+// save writes the subscriptions to disk (caller must hold the lock)
+func (s *Store) save() error {
+	subs := make([]*Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+
+	data, err := json.MarshalIndent(subs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save push subscriptions: %w", err)
+	}
+	return nil
+}
+
+// ErrSubscriptionNotFound is returned for unknown subscription IDs
+var ErrSubscriptionNotFound = errors.New("push subscription not found")
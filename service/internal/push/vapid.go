@@ -0,0 +1,138 @@
+// Package push delivers Web Push notifications for session events
+package push
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"time"
+)
+
+// VAPIDKeys holds the application server key pair used to sign push requests
+type VAPIDKeys struct {
+	PublicKey  string `json:"public_key"`  // uncompressed P-256 point, base64url
+	PrivateKey string `json:"private_key"` // raw P-256 scalar, base64url
+
+	signer *ecdsa.PrivateKey
+}
+
+// GenerateVAPIDKeys creates a new VAPID key pair
+func GenerateVAPIDKeys() (*VAPIDKeys, error) {
+	priv, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate VAPID key: %w", err)
+	}
+
+	keys := &VAPIDKeys{
+		PublicKey:  b64.EncodeToString(priv.PublicKey().Bytes()),
+		PrivateKey: b64.EncodeToString(priv.Bytes()),
+	}
+	if err := keys.init(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// LoadOrCreateVAPIDKeys reads the key pair stored at path, generating and saving
+// a new one when the file does not exist yet
+func LoadOrCreateVAPIDKeys(path string) (*VAPIDKeys, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		keys, err := GenerateVAPIDKeys()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.MarshalIndent(keys, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to save VAPID keys: %w", err)
+		}
+		return keys, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read VAPID keys: %w", err)
+	}
+
+	var keys VAPIDKeys
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse VAPID keys: %w", err)
+	}
+	if err := keys.init(); err != nil {
+		return nil, err
+	}
+	return &keys, nil
+}
+
+// init rebuilds the ECDSA signing key from the encoded private scalar
+func (k *VAPIDKeys) init() error {
+	raw, err := b64.DecodeString(k.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	priv, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	pub := priv.PublicKey().Bytes()
+	if b64.EncodeToString(pub) != k.PublicKey {
+		return fmt.Errorf("VAPID public key does not match private key")
+	}
+
+	k.signer = &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:65]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}
+	return nil
+}
+
+// authorization builds the "vapid" Authorization header value for a push endpoint
+func (k *VAPIDKeys) authorization(endpoint, subject string, expiry time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid push endpoint: %w", err)
+	}
+
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": expiry.Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := b64.EncodeToString(header) + "." + b64.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, k.signer, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+
+	// JWS ES256 signatures are the fixed-width concatenation r || s
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	token := unsigned + "." + b64.EncodeToString(sig)
+	return fmt.Sprintf("vapid t=%s, k=%s", token, k.PublicKey), nil
+}
+
+// b64 is the unpadded URL-safe encoding used throughout the Web Push specs
+var b64 = base64.RawURLEncoding
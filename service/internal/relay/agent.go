@@ -0,0 +1,205 @@
+package relay
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/yamux"
+
+	"github.com/ibrahim/remote-vibecode/internal/client"
+	"github.com/ibrahim/remote-vibecode/internal/federation"
+)
+
+const (
+	// sessionsPath is the session list stream the relay follows
+	sessionsPath = "/api/v1/sessions/ws"
+	// maxBackoff caps the delay between attempts to reach the relay
+	maxBackoff = 30 * time.Second
+)
+
+// Agent connects an rvc server to a relay and serves the relay's requests
+// for its terminals and session API
+type Agent struct {
+	RelayURL       string
+	Name           string
+	Secret         string
+	TLSFingerprint string // hex SHA-256 of the relay's certificate; empty uses the system roots
+
+	// Server returns a client for the rvc server to expose. It is called for
+	// every request, so a restarted server is picked up.
+	Server func() (*client.Client, error)
+
+	mu       sync.Mutex
+	proxyFor string // BaseURL and token of the server that proxy forwards to
+	proxy    *httputil.ReverseProxy
+}
+
+// Run keeps the agent connected to the relay until ctx is done,
+// reconnecting with backoff. It fails if authentication fails before the
+// agent ever connected, which means it is misconfigured.
+func (a *Agent) Run(ctx context.Context) error {
+	relay := client.New(a.RelayURL, "")
+	if a.TLSFingerprint != "" {
+		relay.PinCertificate(a.TLSFingerprint)
+	}
+
+	backoff := time.Second
+	everConnected := false
+	for {
+		connected, err := a.serve(ctx, relay)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			everConnected = true
+			backoff = time.Second
+		} else if errors.Is(err, ErrAuthentication) && !everConnected {
+			return err
+		}
+		log.Printf("Relay %s: %v; reconnecting in %s", a.RelayURL, err, backoff)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// serve runs one connection to the relay and reports whether the relay
+// accepted it
+func (a *Agent) serve(ctx context.Context, relay *client.Client) (bool, error) {
+	conn, err := a.handshake(ctx, relay)
+	if err != nil {
+		return false, err
+	}
+	session, err := yamux.Server(newWSConn(conn), yamuxConfig())
+	if err != nil {
+		_ = conn.Close()
+		return false, err
+	}
+	defer session.Close()
+	stop := context.AfterFunc(ctx, func() { _ = session.Close() })
+	defer stop()
+	log.Printf("Relay %s: connected as %s", a.RelayURL, a.Name)
+
+	srv := &http.Server{
+		Handler:           http.HandlerFunc(a.forward),
+		ReadHeaderTimeout: handshakeTimeout,
+	}
+	err = srv.Serve(session)
+	return true, fmt.Errorf("connection lost: %w", err)
+}
+
+// handshake dials the relay and runs the mutual authentication: the relay
+// proves that it knows the secret in its handshake response, the agent in
+// its first message
+func (a *Agent) handshake(ctx context.Context, relay *client.Client) (*websocket.Conn, error) {
+	agentNonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	header.Set(agentHeader, a.Name)
+	header.Set(nonceHeader, agentNonce)
+	conn, resp, err := relay.DialWebSocket(ctx, ConnectPath, header)
+	if err != nil {
+		var apiErr *client.Error
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized {
+			return nil, fmt.Errorf("%w: the relay does not know the agent %q", ErrAuthentication, a.Name)
+		}
+		return nil, err
+	}
+
+	relayNonce := resp.Header.Get(nonceHeader)
+	want := proof(a.Secret, "relay", a.Name, agentNonce, relayNonce)
+	if relayNonce == "" || !hmac.Equal([]byte(resp.Header.Get(proofHeader)), []byte(want)) {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%w: the relay did not prove that it knows the secret; check the URL and secret", ErrAuthentication)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(proof(a.Secret, "agent", a.Name, relayNonce, agentNonce))); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	_, msg, err := conn.ReadMessage()
+	if err != nil || string(msg) != acceptedMessage {
+		_ = conn.Close()
+		if err == nil || websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+			return nil, fmt.Errorf("%w: the relay rejected the secret", ErrAuthentication)
+		}
+		return nil, err
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+	return conn, nil
+}
+
+// forward passes a request from the relay to the rvc server. Like a
+// federation peer, the agent only serves terminals and the session API.
+func (a *Agent) forward(w http.ResponseWriter, r *http.Request) {
+	target := path.Clean(r.URL.Path)
+	if !federation.Proxied(target) && target != sessionsPath {
+		writeError(w, http.StatusNotFound, "not available through a relay", "not_found")
+		return
+	}
+	server, err := a.Server()
+	if err != nil || server == nil {
+		writeError(w, http.StatusBadGateway, "the rvc server is not running", "peer_unreachable")
+		return
+	}
+	r.URL.Path = target
+	r.URL.RawPath = ""
+	a.proxyTo(server).ServeHTTP(w, r)
+}
+
+// proxyTo returns a reverse proxy to server, reusing the previous one while
+// the server is the same
+func (a *Agent) proxyTo(server *client.Client) *httputil.ReverseProxy {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := server.BaseURL + "\x00" + server.Token
+	if a.proxy != nil && a.proxyFor == key {
+		return a.proxy
+	}
+
+	// The URL comes from the runtime file or the command line
+	base, _ := url.Parse(server.BaseURL)
+	a.proxyFor = key
+	a.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(base)
+			r.Out.Header.Del("Cookie")
+			r.Out.Header.Del("Authorization")
+			// The relay checked the origin; the server would see its own host
+			r.Out.Header.Del("Origin")
+			if server.Token != "" {
+				r.Out.Header.Set("Authorization", "Bearer "+server.Token)
+			}
+		},
+		Transport: server.HTTPClient.Transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("Relay: proxy error for %s: %v", r.URL.Path, err)
+			writeError(w, http.StatusBadGateway, "the rvc server is not reachable", "peer_unreachable")
+		},
+	}
+	return a.proxy
+}
+
+// writeError writes an error in the API's JSON format
+func writeError(w http.ResponseWriter, status int, message, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message, "code": code})
+}
@@ -0,0 +1,239 @@
+// Package relay lets rvc servers that cannot accept connections, e.g. behind
+// NAT, be reached through a public relay. An agent next to the server dials
+// out to the relay over one WebSocket, which carries multiplexed streams;
+// the relay adds the agent as a federation peer and opens a stream for each
+// request to its terminals or session API.
+//
+// Agent and relay prove to each other that they know the agent's shared
+// secret before any stream is opened.
+package relay
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/yamux"
+
+	"github.com/ibrahim/remote-vibecode/internal/federation"
+)
+
+const (
+	// ConnectPath is the relay endpoint that agents dial
+	ConnectPath = "/relay/connect"
+
+	// Handshake headers: the agent sends its name and a nonce, the relay
+	// answers with its own nonce and its proof
+	agentHeader = "X-Rvc-Agent"
+	nonceHeader = "X-Rvc-Nonce"
+	proofHeader = "X-Rvc-Proof"
+
+	// handshakeTimeout bounds the agent's proof and the relay's verdict
+	handshakeTimeout = 10 * time.Second
+	// nonceSize is the number of random bytes in a nonce
+	nonceSize = 32
+	// acceptedMessage is the relay's verdict on a valid agent proof
+	acceptedMessage = "accepted"
+)
+
+// ErrAuthentication is returned when agent and relay do not agree on the
+// agent's name and secret
+var ErrAuthentication = errors.New("authentication with the relay failed")
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  32 << 10,
+	WriteBufferSize: 32 << 10,
+	// Agents are not browsers; they authenticate with their secret
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// Relay accepts agent connections and shows each connected agent's sessions
+// through the federation
+type Relay struct {
+	peers *federation.Federation
+
+	mu      sync.Mutex
+	secrets map[string]string  // agent name -> shared secret
+	tunnels map[string]*tunnel // connected agents
+}
+
+// tunnel is an agent's connection
+type tunnel struct {
+	session *yamux.Session
+	remove  func() // removes the agent from the federation
+}
+
+// close ends the connection and hides the agent's sessions
+func (t *tunnel) close() {
+	t.remove()
+	_ = t.session.Close()
+}
+
+// New creates a Relay that accepts no agents
+func New(peers *federation.Federation) *Relay {
+	return &Relay{
+		peers:   peers,
+		secrets: make(map[string]string),
+		tunnels: make(map[string]*tunnel),
+	}
+}
+
+// SetAgents replaces the accepted agents, given as name -> secret. Agents
+// that were removed or whose secret changed are disconnected.
+func (r *Relay) SetAgents(agents map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, t := range r.tunnels {
+		if secret, ok := agents[name]; !ok || secret != r.secrets[name] {
+			log.Printf("Agent %s: disconnecting, its configuration changed", name)
+			t.close()
+			delete(r.tunnels, name)
+		}
+	}
+	r.secrets = make(map[string]string, len(agents))
+	for name, secret := range agents {
+		r.secrets[name] = secret
+	}
+}
+
+// Stop disconnects all agents
+func (r *Relay) Stop() {
+	r.SetAgents(nil)
+}
+
+// Connect authenticates an agent and serves its connection. A new
+// connection of an agent replaces the previous one.
+// GET /relay/connect
+func (r *Relay) Connect(c *gin.Context) {
+	name := c.GetHeader(agentHeader)
+	agentNonce := c.GetHeader(nonceHeader)
+	r.mu.Lock()
+	secret, ok := r.secrets[name]
+	r.mu.Unlock()
+	if !ok {
+		log.Printf("Agent %q from %s: unknown agent", name, c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unknown agent", "code": "unauthorized"})
+		return
+	}
+	if b, err := hex.DecodeString(agentNonce); err != nil || len(b) != nonceSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid nonce", "code": "invalid_request"})
+		return
+	}
+
+	relayNonce, err := newNonce()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create a nonce", "code": "internal_error"})
+		return
+	}
+	header := http.Header{}
+	header.Set(nonceHeader, relayNonce)
+	header.Set(proofHeader, proof(secret, "relay", name, agentNonce, relayNonce))
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, header)
+	if err != nil {
+		log.Printf("Agent %s: failed to upgrade to WebSocket: %v", name, err)
+		return
+	}
+
+	// The agent proves itself in its first message
+	_ = conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	typ, msg, err := conn.ReadMessage()
+	if err != nil || typ != websocket.TextMessage || !hmac.Equal(msg, []byte(proof(secret, "agent", name, relayNonce, agentNonce))) {
+		log.Printf("Agent %s from %s: authentication failed", name, c.ClientIP())
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "authentication failed"),
+			time.Now().Add(time.Second))
+		_ = conn.Close()
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(acceptedMessage)); err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	session, err := yamux.Client(newWSConn(conn), yamuxConfig())
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	if err := r.add(name, secret, session); err != nil {
+		log.Printf("Agent %s: %v", name, err)
+		_ = session.Close()
+		return
+	}
+	log.Printf("Agent %s: connected from %s", name, c.ClientIP())
+}
+
+// add registers an agent's connection with the federation, replacing its
+// previous one, unless the agent was removed during the handshake
+func (r *Relay) add(name, secret string, session *yamux.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.secrets[name] != secret {
+		return errors.New("the agent was removed during the handshake")
+	}
+	if old := r.tunnels[name]; old != nil {
+		log.Printf("Agent %s: replacing the previous connection", name)
+		old.close()
+		delete(r.tunnels, name)
+	}
+
+	remove, err := r.peers.Connect(name, func(ctx context.Context) (net.Conn, error) {
+		return session.Open()
+	})
+	if err != nil {
+		return err
+	}
+	t := &tunnel{session: session, remove: remove}
+	r.tunnels[name] = t
+
+	go func() {
+		<-session.CloseChan()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.tunnels[name] == t {
+			log.Printf("Agent %s: disconnected", name)
+			t.remove()
+			delete(r.tunnels, name)
+		}
+	}()
+	return nil
+}
+
+// yamuxConfig returns the stream multiplexing settings shared by both ends
+func yamuxConfig() *yamux.Config {
+	cfg := yamux.DefaultConfig()
+	cfg.LogOutput = log.Writer()
+	return cfg
+}
+
+// newNonce returns a random hex nonce
+func newNonce() (string, error) {
+	b := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// proof returns the HMAC with which one side (role) shows that it knows the
+// agent's secret; it covers both nonces, the other side's first, so neither
+// side's proof can be replayed
+func proof(secret, role, name, theirNonce, ourNonce string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(role + "\x00" + name + "\x00" + theirNonce + "\x00" + ourNonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
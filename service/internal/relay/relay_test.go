@@ -0,0 +1,155 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/ibrahim/remote-vibecode/internal/client"
+	"github.com/ibrahim/remote-vibecode/internal/federation"
+	"github.com/ibrahim/remote-vibecode/internal/ws"
+)
+
+func TestProof(t *testing.T) {
+	base := proof("secret", "agent", "laptop", "n1", "n2")
+	if again := proof("secret", "agent", "laptop", "n1", "n2"); again != base {
+		t.Fatalf("proof is not deterministic: %s, %s", base, again)
+	}
+
+	// Changing any input must change the proof
+	tests := []struct {
+		name                        string
+		secret, role, agent, n1, n2 string
+	}{
+		{"secret", "other", "agent", "laptop", "n1", "n2"},
+		{"role", "secret", "relay", "laptop", "n1", "n2"},
+		{"name", "secret", "agent", "desktop", "n1", "n2"},
+		{"their nonce", "secret", "agent", "laptop", "n3", "n2"},
+		{"our nonce", "secret", "agent", "laptop", "n1", "n3"},
+		{"swapped nonces", "secret", "agent", "laptop", "n2", "n1"},
+		{"shifted separator", "secret", "agent", "laptop\x00n1", "", "n2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := proof(tt.secret, tt.role, tt.agent, tt.n1, tt.n2); got == base {
+				t.Errorf("proof with a different %s equals the original", tt.name)
+			}
+		})
+	}
+}
+
+// newTestRelay serves a relay that accepts the agent "laptop" with secret
+// "secret"
+func newTestRelay(t *testing.T) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	peers := federation.New(ws.NewSessionHub())
+	r := New(peers)
+	r.SetAgents(map[string]string{"laptop": "secret"})
+
+	router := gin.New()
+	router.GET(ConnectPath, r.Connect)
+	srv := httptest.NewServer(router)
+	t.Cleanup(func() {
+		r.Stop()
+		peers.Stop()
+		srv.Close()
+	})
+	return srv
+}
+
+func TestHandshake(t *testing.T) {
+	srv := newTestRelay(t)
+	tests := []struct {
+		name    string
+		agent   string
+		secret  string
+		wantErr string
+	}{
+		{name: "accepted", agent: "laptop", secret: "secret"},
+		{name: "unknown agent", agent: "desktop", secret: "secret", wantErr: "does not know the agent"},
+		{name: "wrong secret", agent: "laptop", secret: "guess", wantErr: "did not prove"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Agent{RelayURL: srv.URL, Name: tt.agent, Secret: tt.secret}
+			conn, err := a.handshake(context.Background(), client.New(srv.URL, ""))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("handshake: %v", err)
+				}
+				conn.Close()
+				return
+			}
+			if err == nil {
+				conn.Close()
+				t.Fatal("handshake succeeded, want an error")
+			}
+			if !errors.Is(err, ErrAuthentication) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("handshake = %v, want an authentication error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// An agent that does not know the secret cannot reuse the relay's proof or
+// guess its own
+func TestRelayRejectsAgentProof(t *testing.T) {
+	srv := newTestRelay(t)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + ConnectPath
+	agentNonce, err := newNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"replayed relay proof", "wrong secret", "binary message"} {
+		t.Run(name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(agentHeader, "laptop")
+			header.Set(nonceHeader, agentNonce)
+			conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+
+			relayNonce := resp.Header.Get(nonceHeader)
+			typ, msg := websocket.TextMessage, []byte(resp.Header.Get(proofHeader))
+			switch name {
+			case "wrong secret":
+				msg = []byte(proof("guess", "agent", "laptop", relayNonce, agentNonce))
+			case "binary message":
+				typ, msg = websocket.BinaryMessage, []byte(proof("secret", "agent", "laptop", relayNonce, agentNonce))
+			}
+			if err := conn.WriteMessage(typ, msg); err != nil {
+				t.Fatal(err)
+			}
+			if _, msg, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+				t.Errorf("relay answered %q, %v, want a policy violation close", msg, err)
+			}
+		})
+	}
+}
+
+func TestRelayRejectsInvalidNonce(t *testing.T) {
+	srv := newTestRelay(t)
+	for _, nonce := range []string{"", "abc", strings.Repeat("zz", nonceSize)} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+ConnectPath, nil)
+		req.Header.Set(agentHeader, "laptop")
+		req.Header.Set(nonceHeader, nonce)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("nonce %q: status %d, want %d", nonce, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+}
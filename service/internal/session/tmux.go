@@ -17,18 +17,49 @@ type TmuxSession struct {
 	CreatedAt   time.Time
 	LastCapture time.Time // last output capture time
 	CapturePos  int       // position in tmux buffer for incremental capture (line count)
+	LastInput   time.Time // last input sent to the session
+	State       string    // derived activity state: "busy", "awaiting_input", "idle"
+	Viewers     int       // number of web terminals attached
 
 	mu       sync.RWMutex
 	stopChan chan struct{} // channel to stop capture loop
 }
 
+// Activity states derived from output and input timestamps
+const (
+	StateBusy          = "busy"           // output seen within BusyWindow
+	StateAwaitingInput = "awaiting_input" // output stopped after the last input
+	StateIdle          = "idle"           // nothing happened for IdleAfter
+)
+
+var (
+	// BusyWindow is how recently output must have been seen for a session to count as busy
+	BusyWindow = 3 * time.Second
+	// IdleAfter is how long a session must be quiet before it is considered idle
+	IdleAfter = 10 * time.Minute
+	// EchoGrace is how soon after the last input output counts as the
+	// terminal echoing the keystrokes rather than the program responding.
+	// tmux reports activity in whole seconds, so it must exceed one second.
+	EchoGrace = 2 * time.Second
+)
+
+// Activity is a point-in-time snapshot of a session's activity tracking
+type Activity struct {
+	Status     string
+	State      string
+	LastOutput time.Time
+	LastInput  time.Time
+	Viewers    int
+}
+
 // NewTmuxSession creates a new tmux session
 func NewTmuxSession(sessionName, windowName string) *TmuxSession {
 	return &TmuxSession{
 		ID:          uuid.New().String(),
 		SessionName: sessionName,
 		WindowName:  windowName,
-		Status:      "attached",
+		Status:      "detached",
+		State:       StateIdle,
 		CreatedAt:   time.Now(),
 		CapturePos:  0, // Start from beginning
 		stopChan:    make(chan struct{}),
@@ -54,6 +85,87 @@ func (s *TmuxSession) SetStatus(status string) {
 	s.Status = status
 }
 
+// RecordOutput records that the session produced output at t
+func (s *TmuxSession) RecordOutput(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.After(s.LastCapture) {
+		s.LastCapture = t
+	}
+}
+
+// RecordInput records that input was sent to the session at t
+func (s *TmuxSession) RecordInput(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.After(s.LastInput) {
+		s.LastInput = t
+	}
+}
+
+// AddViewer registers an attached web terminal and marks the session attached
+func (s *TmuxSession) AddViewer() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Viewers++
+	s.Status = "attached"
+}
+
+// RemoveViewer unregisters a web terminal, marking the session detached when none remain
+func (s *TmuxSession) RemoveViewer() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Viewers > 0 {
+		s.Viewers--
+	}
+	if s.Viewers == 0 {
+		s.Status = "detached"
+	}
+}
+
+// UpdateState re-derives the activity state at now and reports whether it changed
+func (s *TmuxSession) UpdateState(now time.Time) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := deriveState(now, s.LastCapture, s.LastInput)
+	changed := state != s.State
+	s.State = state
+	return state, changed
+}
+
+// Activity returns a snapshot of the session's activity tracking
+func (s *TmuxSession) Activity() Activity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Activity{
+		Status:     s.Status,
+		State:      s.State,
+		LastOutput: s.LastCapture,
+		LastInput:  s.LastInput,
+		Viewers:    s.Viewers,
+	}
+}
+
+// deriveState maps output and input timestamps to an activity state
+func deriveState(now, lastOutput, lastInput time.Time) string {
+	// Output right after the last keystroke is mostly its echo, which
+	// says nothing about what the program is doing
+	echo := !lastOutput.Before(lastInput) && lastOutput.Sub(lastInput) < EchoGrace
+	switch {
+	case lastOutput.IsZero() || now.Sub(lastOutput) >= IdleAfter || echo:
+		return StateIdle
+	case now.Sub(lastOutput) < BusyWindow:
+		return StateBusy
+	case lastOutput.After(lastInput):
+		// Output continued past our last keystroke and then stopped:
+		// the program is most likely waiting for us
+		return StateAwaitingInput
+	default:
+		return StateIdle
+	}
+}
+
 // GetCapturePosition returns the current capture position
 func (s *TmuxSession) GetCapturePosition() int {
 	s.mu.RLock()
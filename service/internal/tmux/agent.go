@@ -0,0 +1,153 @@
+package tmux
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ibrahim/remote-vibecode/internal/agent"
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
+)
+
+// ListSessionAgents returns the agent adapter configured for each session
+// (the @rvc-agent user option), omitting sessions without one
+func ListSessionAgents() (map[string]string, error) {
+	result := make(map[string]string)
+	err := eachServer(func(label, output string) {
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			name, adapter, ok := strings.Cut(line, fieldSep)
+			if ok && adapter != "" {
+				result[tmuxclient.Qualify(name, label)] = adapter
+			}
+		}
+	}, "list-sessions", "-F", formatFields("#{session_name}", "#{@rvc-agent}"))
+	if err != nil {
+		return nil, fmt.Errorf("list-sessions failed: %w", err)
+	}
+	return result, nil
+}
+
+// GetAgent returns the agent adapter configured for a session, or ""
+func GetAgent(sessionName string) (string, error) {
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return "", err
+	}
+	output, err := c.Command("show-option", "-t", name, "-qv", "@rvc-agent").Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to read @rvc-agent: %v", ErrCommandFailed, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// SetAgent sets the agent adapter used to detect prompts in a session.
+// An empty name turns detection off.
+func SetAgent(sessionName, adapter string) error {
+	if adapter == "" {
+		return runTarget(sessionName, "set-option", "-u", "@rvc-agent")
+	}
+	return runTarget(sessionName, "set-option", "@rvc-agent", adapter)
+}
+
+// CapturePane returns the visible screen of a pane target as plain text
+func CapturePane(target string) (string, error) {
+	c, t, err := resolve(target)
+	if err != nil {
+		return "", err
+	}
+	output, err := c.Command("capture-pane", "-p", "-t", t).Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: capture-pane failed: %v", ErrCommandFailed, err)
+	}
+	return string(output), nil
+}
+
+// SetAgentRegistry sets the adapters used to detect pending decisions
+func (m *Manager) SetAgentRegistry(registry *agent.Registry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.agents = registry
+}
+
+// AgentRegistry returns the adapters used to detect pending decisions
+func (m *Manager) AgentRegistry() *agent.Registry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.agents
+}
+
+// Decision returns the adapter configured for a session and the decision
+// currently pending on its active pane, checking the screen right away
+func (m *Manager) Decision(sessionName string) (string, *agent.Decision, error) {
+	adapter, err := GetAgent(sessionName)
+	if err != nil || adapter == "" {
+		return adapter, nil, err
+	}
+	decision, err := m.detectDecision(sessionName, adapter)
+	return adapter, decision, err
+}
+
+// detectDecision captures a session's active pane, runs its adapter and
+// caches the result. A decision keeps its detection time while it stays on screen.
+func (m *Manager) detectDecision(sessionName, adapter string) (*agent.Decision, error) {
+	registry := m.AgentRegistry()
+	if registry == nil {
+		return nil, nil
+	}
+
+	screen, err := CapturePane(sessionName)
+	if err != nil {
+		return nil, err
+	}
+	decision := registry.Detect(adapter, screen)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if prev := m.decisions[sessionName]; prev != nil && decision != nil && prev.ID == decision.ID {
+		decision.DetectedAt = prev.DetectedAt
+	}
+	if decision == nil {
+		delete(m.decisions, sessionName)
+	} else {
+		m.decisions[sessionName] = decision
+	}
+	return decision, nil
+}
+
+// updateDecisions re-runs the adapters of all sessions that have one and
+// emits an event for every newly pending decision
+func (m *Manager) updateDecisions() {
+	agents, err := ListSessionAgents()
+	if err != nil {
+		agents = map[string]string{}
+	}
+
+	var pending []*agent.Decision
+	var pendingSessions []string
+	for sessionName, adapter := range agents {
+		if _, tracked := m.GetSessionByName(sessionName); !tracked {
+			continue
+		}
+		m.mu.RLock()
+		prev := m.decisions[sessionName]
+		m.mu.RUnlock()
+
+		decision, err := m.detectDecision(sessionName, adapter)
+		if err == nil && decision != nil && (prev == nil || prev.ID != decision.ID) {
+			pending = append(pending, decision)
+			pendingSessions = append(pendingSessions, sessionName)
+		}
+	}
+
+	m.mu.Lock()
+	m.sessionAgents = agents
+	for sessionName := range m.decisions {
+		if _, ok := agents[sessionName]; !ok {
+			delete(m.decisions, sessionName)
+		}
+	}
+	m.mu.Unlock()
+
+	for i, decision := range pending {
+		m.emit(EventDecisionPending, pendingSessions[i], decision.Prompt)
+	}
+}
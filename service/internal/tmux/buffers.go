@@ -0,0 +1,186 @@
+package tmux
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
+)
+
+// MaxBufferBytes caps the size of a paste buffer set through SetBuffer
+const MaxBufferBytes = 1 << 20
+
+// Buffer describes a tmux paste buffer
+type Buffer struct {
+	Name    string    `json:"name"`
+	Size    int       `json:"size"`
+	Created time.Time `json:"created"`
+	Sample  string    `json:"sample"` // first characters of the content, as shown by tmux
+}
+
+var bufferNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// bufferFormat lists the fields parsed by parseBuffers
+var bufferFormat = formatFields("#{buffer_name}", "#{buffer_size}", "#{buffer_created}", "#{buffer_sample}")
+
+// IsValidBufferName checks that a paste buffer name is safe to pass to tmux.
+// Names of buffers on a labelled server end in "@label".
+func IsValidBufferName(name string) bool {
+	name, label, labelled := strings.Cut(name, "@")
+	return bufferNamePattern.MatchString(name) && (!labelled || tmuxclient.IsValidLabel(label))
+}
+
+// ListBuffers returns the paste buffers of every watched tmux server, most
+// recent first
+func ListBuffers() ([]Buffer, error) {
+	buffers := []Buffer{}
+	err := eachServer(func(label, output string) {
+		buffers = append(buffers, parseBuffers(output, label)...)
+	}, "list-buffers", "-F", bufferFormat)
+	if err != nil {
+		// A server without buffers still lists fine, so this is a real failure
+		return nil, fmt.Errorf("%w: list-buffers failed: %v", ErrCommandFailed, err)
+	}
+	sort.SliceStable(buffers, func(i, j int) bool {
+		return buffers[i].Created.After(buffers[j].Created)
+	})
+	return buffers, nil
+}
+
+// parseBuffers parses list-buffers output in bufferFormat of the server with
+// label, qualifying the buffer names with it
+func parseBuffers(output, label string) []Buffer {
+	buffers := []Buffer{}
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		f := strings.SplitN(line, fieldSep, 4)
+		if len(f) != 4 {
+			continue
+		}
+		buffers = append(buffers, Buffer{
+			Name:    tmuxclient.Qualify(f[0], label),
+			Size:    atoi(f[1]),
+			Created: parseUnixTime(f[2]),
+			Sample:  f[3],
+		})
+	}
+	return buffers
+}
+
+// resolveBuffer returns the tmux server of a buffer name and the name as that
+// server knows it. Buffer names may contain dots, so the label is everything
+// after the "@".
+func resolveBuffer(name string) (tmuxclient.Client, string, error) {
+	bare, label, _ := strings.Cut(name, "@")
+	c, err := tmuxclient.Lookup(label)
+	if err != nil {
+		return c, "", fmt.Errorf("%w: %v", ErrServerNotFound, err)
+	}
+	return c, bare, nil
+}
+
+// ShowBuffer returns the full content of a paste buffer
+func ShowBuffer(name string) ([]byte, error) {
+	if !IsValidBufferName(name) {
+		return nil, ErrInvalidBuffer
+	}
+	c, bare, err := resolveBuffer(name)
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd := c.Command("show-buffer", "-b", bare)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(stderr.String(), "no buffer") {
+			return nil, ErrBufferNotFound
+		}
+		return nil, fmt.Errorf("%w: show-buffer failed: %v\nOutput: %s", ErrCommandFailed, err, stderr.String())
+	}
+	return output, nil
+}
+
+// SetBuffer stores data in a paste buffer, creating or replacing it. An empty
+// name lets the default tmux server pick one; the name of the buffer is
+// returned either way.
+func SetBuffer(name string, data []byte) (string, error) {
+	if name != "" && !IsValidBufferName(name) {
+		return "", ErrInvalidBuffer
+	}
+	if len(data) > MaxBufferBytes {
+		return "", fmt.Errorf("%w: buffer exceeds %d bytes", ErrInvalidOptions, MaxBufferBytes)
+	}
+	c, bare, err := resolveBuffer(name)
+	if err != nil {
+		return "", err
+	}
+
+	// load-buffer reads from stdin, so content never passes through argv
+	args := []string{"load-buffer"}
+	if bare != "" {
+		args = append(args, "-b", bare)
+	}
+	cmd := c.Command(append(args, "-")...)
+	cmd.Stdin = bytes.NewReader(data)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("%w: load-buffer failed: %v\nOutput: %s", ErrCommandFailed, err, string(output))
+	}
+
+	if name == "" {
+		// The newest automatic buffer is listed first
+		output, err := c.Command("list-buffers", "-F", bufferFormat).Output()
+		if err != nil {
+			return "", fmt.Errorf("%w: list-buffers failed: %v", ErrCommandFailed, err)
+		}
+		buffers := parseBuffers(string(output), "")
+		if len(buffers) == 0 {
+			return "", ErrBufferNotFound
+		}
+		name = buffers[0].Name
+	}
+	return name, nil
+}
+
+// DeleteBuffer removes a paste buffer
+func DeleteBuffer(name string) error {
+	if !IsValidBufferName(name) {
+		return ErrInvalidBuffer
+	}
+	if _, err := ShowBuffer(name); err != nil {
+		return err
+	}
+	c, bare, err := resolveBuffer(name)
+	if err != nil {
+		return err
+	}
+	return runTmux(c, "delete-buffer", "-b", bare)
+}
+
+// PasteBuffer pastes a buffer into a target (session, window or pane), using
+// bracketed paste when the application asked for it. A buffer on another
+// tmux server than the target is copied over for the paste.
+func PasteBuffer(name, target string) error {
+	if !IsValidBufferName(name) {
+		return ErrInvalidBuffer
+	}
+	data, err := ShowBuffer(name)
+	if err != nil {
+		return err
+	}
+	bc, bare, err := resolveBuffer(name)
+	if err != nil {
+		return err
+	}
+	c, t, err := resolve(target)
+	if err != nil {
+		return err
+	}
+	if bc != c {
+		return pasteText(c, t, string(data))
+	}
+	return runTmux(c, "paste-buffer", "-p", "-b", bare, "-t", t)
+}
@@ -2,60 +2,262 @@ package tmux
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
 )
 
 // SessionExists checks if a tmux session with the given name exists
 func SessionExists(sessionName string) bool {
-	cmd := exec.Command("tmux", "has-session", "-t", sessionName)
-	return cmd.Run() == nil
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return false
+	}
+	return c.Command("has-session", "-t", name).Run() == nil
 }
 
 // GetSessionInfo retrieves information about a tmux session
 func GetSessionInfo(sessionName string) (map[string]string, error) {
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return nil, err
+	}
+	_, label := tmuxclient.Split(sessionName)
+
 	// Get session ID, window ID, and pane ID
-	sessionID, err := exec.Command("tmux", "display-message", "-p", "-t", sessionName, "#{session_id}").Output()
+	sessionID, err := c.Command("display-message", "-p", "-t", name, "#{session_id}").Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session info: %w", err)
 	}
-	windowID, err := exec.Command("tmux", "display-message", "-p", "-t", sessionName, "#{window_id}").Output()
+	windowID, err := c.Command("display-message", "-p", "-t", name, "#{window_id}").Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get window info: %w", err)
 	}
-	paneID, err := exec.Command("tmux", "display-message", "-p", "-t", sessionName, "#{pane_id}").Output()
+	paneID, err := c.Command("display-message", "-p", "-t", name, "#{pane_id}").Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pane info: %w", err)
 	}
 	return map[string]string{
-		"session_id": strings.TrimSpace(string(sessionID)),
-		"window_id":  strings.TrimSpace(string(windowID)),
-		"pane_id":    strings.TrimSpace(string(paneID)),
+		"session_id": tmuxclient.Qualify(strings.TrimSpace(string(sessionID)), label),
+		"window_id":  tmuxclient.Qualify(strings.TrimSpace(string(windowID)), label),
+		"pane_id":    tmuxclient.Qualify(strings.TrimSpace(string(paneID)), label),
 	}, nil
 }
 
-// ListSessions returns a list of all tmux session names
+// ListSessions returns a list of all tmux session names on every watched server
 func ListSessions() ([]string, error) {
-	cmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}")
-	output, err := cmd.Output()
+	sessions := []string{}
+	err := eachServer(func(label, output string) {
+		for _, name := range strings.Split(strings.TrimSpace(output), "\n") {
+			if name != "" {
+				sessions = append(sessions, tmuxclient.Qualify(name, label))
+			}
+		}
+	}, "list-sessions", "-F", "#{session_name}")
 	if err != nil {
-		// If no sessions exist, tmux returns error
-		if strings.Contains(err.Error(), "no server running") || strings.Contains(err.Error(), "failed to") {
+		// tmux exits non-zero when no server is running, e.g. after the
+		// last session ended
+		if !SessionsRunning() {
 			return []string{}, nil
 		}
 		return nil, fmt.Errorf("list-sessions failed: %w", err)
 	}
+	return sessions, nil
+}
 
-	sessions := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(sessions) == 1 && sessions[0] == "" {
-		return []string{}, nil
+// SessionActivity holds the activity timestamps tmux keeps for a session
+type SessionActivity struct {
+	Session time.Time // latest #{client_activity} of attached terminals: last input
+	Window  time.Time // latest #{window_activity} across windows: last pane output
+}
+
+// ListSessionActivity returns activity timestamps for all sessions, using two
+// tmux calls per watched server. Input is taken from the clients rather than
+// #{session_activity}, which rvc's control client bumps when it attaches.
+func ListSessionActivity() (map[string]SessionActivity, error) {
+	result := make(map[string]SessionActivity)
+	err := eachServer(func(label, output string) {
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			fields := splitFields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			name := tmuxclient.Qualify(fields[0], label)
+			activity := result[name]
+			if windowActivity := parseUnixTime(fields[1]); windowActivity.After(activity.Window) {
+				activity.Window = windowActivity
+			}
+			result[name] = activity
+		}
+	}, "list-windows", "-a", "-F", formatFields("#{session_name}", "#{window_activity}"))
+	if err != nil {
+		return nil, fmt.Errorf("list-windows failed: %w", err)
 	}
-	return sessions, nil
+
+	_ = eachServer(func(label, output string) {
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			fields := splitFields(line)
+			if len(fields) != 3 || fields[1] == controlTermName {
+				continue
+			}
+			name := tmuxclient.Qualify(fields[0], label)
+			activity, ok := result[name]
+			if clientActivity := parseUnixTime(fields[2]); ok && clientActivity.After(activity.Session) {
+				activity.Session = clientActivity
+				result[name] = activity
+			}
+		}
+	}, "list-clients", "-F", formatFields("#{client_session}", "#{client_termname}", "#{client_activity}"))
+	return result, nil
+}
+
+// SessionSummary describes a session as reported by tmux itself
+type SessionSummary struct {
+	Name     string    `json:"name"`
+	Created  time.Time `json:"created"`
+	Attached int       `json:"attached"` // number of attached tmux clients
+	Windows  int       `json:"windows"`
+	Writable bool      `json:"writable"`
+	Command  string    `json:"command"` // current command of the active pane
+	Cwd      string    `json:"cwd"`     // working directory of the active pane
+}
+
+// summaryFormat lists the fields parsed by parseSummaries
+var summaryFormat = formatFields("#{session_name}", "#{session_created}", "#{session_attached}", "#{session_windows}", "#{@rvc-writable}",
+	"#{pane_current_command}", "#{pane_current_path}")
+
+// ListSessionSummaries returns a summary of every session, using two tmux
+// calls per watched server
+func ListSessionSummaries() ([]SessionSummary, error) {
+	summaries := []SessionSummary{}
+	err := eachServer(func(label, output string) {
+		for _, summary := range parseSummaries(output) {
+			summary.Name = tmuxclient.Qualify(summary.Name, label)
+			summaries = append(summaries, summary)
+		}
+	}, "list-sessions", "-F", summaryFormat)
+	if err != nil {
+		// tmux exits non-zero when no server is running
+		if !SessionsRunning() {
+			return []SessionSummary{}, nil
+		}
+		return nil, fmt.Errorf("%w: list-sessions failed: %v", ErrCommandFailed, err)
+	}
+
+	// rvc's own control clients are not terminals
+	control := make(map[string]int)
+	_ = eachServer(func(label, output string) {
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			name, termName, _ := strings.Cut(line, fieldSep)
+			if termName == controlTermName {
+				control[tmuxclient.Qualify(name, label)]++
+			}
+		}
+	}, "list-clients", "-F", formatFields("#{client_session}", "#{client_termname}"))
+	for i := range summaries {
+		summaries[i].Attached = max(summaries[i].Attached-control[summaries[i].Name], 0)
+	}
+	return summaries, nil
+}
+
+// GetSessionSummary returns the summary of one session
+func GetSessionSummary(sessionName string) (*SessionSummary, error) {
+	if !IsValidSessionName(sessionName) {
+		return nil, ErrInvalidSessionName
+	}
+	summaries, err := ListSessionSummaries()
+	if err != nil {
+		return nil, err
+	}
+	for i := range summaries {
+		if summaries[i].Name == sessionName {
+			return &summaries[i], nil
+		}
+	}
+	return nil, ErrSessionNotFound
+}
+
+// SessionsRunning reports whether a watched tmux server with sessions is reachable
+func SessionsRunning() bool {
+	for _, s := range tmuxclient.Servers() {
+		if s.Command("has-session").Run() == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSummaries parses list-sessions output in summaryFormat
+func parseSummaries(output string) []SessionSummary {
+	summaries := []SessionSummary{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.SplitN(line, fieldSep, 7)
+		if len(fields) != 7 {
+			continue
+		}
+		summaries = append(summaries, SessionSummary{
+			Name:     fields[0],
+			Created:  parseUnixTime(fields[1]),
+			Attached: atoi(fields[2]),
+			Windows:  atoi(fields[3]),
+			Writable: fields[4] == "1",
+			Command:  fields[5],
+			Cwd:      fields[6],
+		})
+	}
+	return summaries
+}
+
+// parseUnixTime parses a tmux timestamp format (seconds since the epoch)
+func parseUnixTime(value string) time.Time {
+	secs, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || secs == 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+// Version returns the output of tmux -V, e.g. "tmux 3.3a"
+func Version() (string, error) {
+	output, err := tmuxclient.Default().Command("-V").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GlobalOption returns the value of a global session option of the default server
+func GlobalOption(name string) (string, error) {
+	output, err := tmuxclient.Default().Command("show-options", "-gv", name).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ServerOption returns the value of a server option of the default server
+func ServerOption(name string) (string, error) {
+	output, err := tmuxclient.Default().Command("show-options", "-sv", name).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
 }
 
-// IsValidSessionName checks if a session name is valid (for security)
+// IsValidSessionName checks if a session name is valid (for security). Names
+// of sessions on a labelled server end in "@label".
 func IsValidSessionName(name string) bool {
-	if name == "" {
+	name, label, labelled := strings.Cut(name, "@")
+	if name == "" || (labelled && !tmuxclient.IsValidLabel(label)) {
 		return false
 	}
 
@@ -78,27 +280,64 @@ func CreateSession(sessionName string) error {
 // CreateSessionWithPrompt creates a new tmux session with a custom prompt
 // The prompt is set via shell initialization, avoiding visible commands
 func CreateSessionWithPrompt(sessionName, prompt string) error {
+	return CreateSessionWithOptions(SessionOptions{Name: sessionName, Prompt: prompt})
+}
+
+// SessionOptions describes a session to create
+type SessionOptions struct {
+	Name    string
+	Prompt  string            // custom shell prompt, ignored when Command is set
+	Command string            // command to run instead of the default shell
+	Cwd     string            // working directory (absolute, or relative to ~)
+	Env     map[string]string // extra environment variables for the session
+}
+
+// envNamePattern matches valid environment variable names
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// CreateSessionWithOptions creates a new tmux session running a shell or a custom command
+func CreateSessionWithOptions(opts SessionOptions) error {
+	sessionName := opts.Name
 	if !IsValidSessionName(sessionName) {
-		return fmt.Errorf("invalid session name: %s", sessionName)
+		return fmt.Errorf("%w: %s", ErrInvalidSessionName, sessionName)
 	}
 	if SessionExists(sessionName) {
-		return fmt.Errorf("session '%s' already exists", sessionName)
+		return fmt.Errorf("%w: %s", ErrSessionExists, sessionName)
+	}
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return err
 	}
 
-	// Detect shell
-	shell := "zsh"
-	detectCmd := exec.Command("sh", "-c", "echo $SHELL")
-	if output, err := detectCmd.Output(); err == nil {
-		shellPath := strings.TrimSpace(string(output))
-		if strings.Contains(shellPath, "bash") {
-			shell = "bash"
-		} else if strings.Contains(shellPath, "zsh") {
-			shell = "zsh"
-		} else if strings.Contains(shellPath, "fish") {
-			shell = "fish"
+	args := []string{"new-session", "-d", "-s", name}
+
+	if opts.Cwd != "" {
+		cwd, err := ResolveDir(opts.Cwd)
+		if err != nil {
+			return err
 		}
+		args = append(args, "-c", cwd)
+	}
+
+	envNames := make([]string, 0, len(opts.Env))
+	for name := range opts.Env {
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("%w: invalid environment variable name %q", ErrInvalidOptions, name)
+		}
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+	for _, name := range envNames {
+		args = append(args, "-e", name+"="+opts.Env[name])
 	}
 
+	if opts.Command != "" {
+		args = append(args, opts.Command)
+		return runNewSession(c, args)
+	}
+	prompt := opts.Prompt
+	shell := DefaultShell()
+
 	// Build initial command that sets prompt and starts shell
 	var initialCmd string
 	if prompt != "" {
@@ -119,30 +358,118 @@ func CreateSessionWithPrompt(sessionName, prompt string) error {
 	}
 
 	// Create session with initial command
-	cmd := exec.Command("tmux", "new-session", "-d", "-s", sessionName, initialCmd)
+	return runNewSession(c, append(args, initialCmd))
+}
+
+// DefaultShell returns the shell new sessions run: bash, zsh or fish
+// according to $SHELL, and zsh for anything else
+func DefaultShell() string {
+	shell := "zsh"
+	detectCmd := exec.Command("sh", "-c", "echo $SHELL")
+	if output, err := detectCmd.Output(); err == nil {
+		shellPath := strings.TrimSpace(string(output))
+		if strings.Contains(shellPath, "bash") {
+			shell = "bash"
+		} else if strings.Contains(shellPath, "zsh") {
+			shell = "zsh"
+		} else if strings.Contains(shellPath, "fish") {
+			shell = "fish"
+		}
+	}
+	return shell
+}
+
+func runNewSession(c tmuxclient.Client, args []string) error {
+	cmd := c.Command(args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("failed to create session: %w\nOutput: %s", err, string(output))
+		return fmt.Errorf("%w: failed to create session: %v\nOutput: %s", ErrCommandFailed, err, string(output))
 	}
 	return nil
 }
 
+// ResolveDir expands a leading ~ and checks that the result is an existing directory
+func ResolveDir(dir string) (string, error) {
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+		}
+		dir = filepath.Join(home, strings.TrimPrefix(dir, "~"))
+	}
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("%w: working directory must be absolute: %s", ErrInvalidOptions, dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%w: not a directory: %s", ErrInvalidOptions, dir)
+	}
+	return dir, nil
+}
+
 // KillSession kills (deletes) a tmux session
 func KillSession(sessionName string) error {
 	if !IsValidSessionName(sessionName) {
-		return fmt.Errorf("invalid session name: %s", sessionName)
+		return fmt.Errorf("%w: %s", ErrInvalidSessionName, sessionName)
 	}
 	if !SessionExists(sessionName) {
-		return fmt.Errorf("session '%s' does not exist", sessionName)
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionName)
+	}
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return err
 	}
-	cmd := exec.Command("tmux", "kill-session", "-t", sessionName)
+	cmd := c.Command("kill-session", "-t", name)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("failed to kill session: %w\nOutput: %s", err, string(output))
+		return fmt.Errorf("%w: failed to kill session: %v\nOutput: %s", ErrCommandFailed, err, string(output))
 	}
 	return nil
 }
 
+// RenameSession renames a tmux session. A session stays on its tmux server,
+// so newName must have oldName's label or none (see RenamedName).
+func RenameSession(oldName, newName string) error {
+	if !IsValidSessionName(oldName) {
+		return fmt.Errorf("%w: %s", ErrInvalidSessionName, oldName)
+	}
+	if !IsValidSessionName(newName) {
+		return fmt.Errorf("%w: %s", ErrInvalidSessionName, newName)
+	}
+	newName, err := RenamedName(oldName, newName)
+	if err != nil {
+		return err
+	}
+	if !SessionExists(oldName) {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, oldName)
+	}
+	if SessionExists(newName) {
+		return fmt.Errorf("%w: %s", ErrSessionExists, newName)
+	}
+	c, name, err := resolve(oldName)
+	if err != nil {
+		return err
+	}
+	bareName, _ := tmuxclient.Split(newName)
+	cmd := c.Command("rename-session", "-t", name, bareName)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: failed to rename session: %v\nOutput: %s", ErrCommandFailed, err, string(output))
+	}
+	return nil
+}
+
+// RenamedName returns the full name a session named oldName gets when it is
+// renamed to newName, which may leave out the label of the session's server
+func RenamedName(oldName, newName string) (string, error) {
+	_, oldLabel := tmuxclient.Split(oldName)
+	bareName, newLabel := tmuxclient.Split(newName)
+	if newLabel != "" && newLabel != oldLabel {
+		return "", fmt.Errorf("%w: %s cannot move to another tmux server", ErrInvalidSessionName, oldName)
+	}
+	return tmuxclient.Qualify(bareName, oldLabel), nil
+}
+
 // AttachSession attaches the current terminal to an existing tmux session
 // This should be called when the process will replace itself with tmux
 func AttachSession(sessionName string) error {
@@ -164,29 +491,81 @@ func SendCommand(sessionName, command string) error {
 	if !SessionExists(sessionName) {
 		return fmt.Errorf("session '%s' does not exist", sessionName)
 	}
-	cmd := exec.Command("tmux", "send-keys", "-t", sessionName, command, "Enter")
-	output, err := cmd.CombinedOutput()
+	return SendText(sessionName, command, true, false)
+}
+
+// SendText sends literal text to a tmux target so that special characters and
+// words that look like key names arrive unchanged. With bracketed set, the text
+// goes through a temporary paste buffer and is pasted with bracketed-paste
+// markers when the application has enabled them. With enter set, an Enter key
+// is sent after the text.
+func SendText(target, text string, enter, bracketed bool) error {
+	c, t, err := resolve(target)
 	if err != nil {
-		return fmt.Errorf("failed to send command to session: %w\nOutput: %s", err, string(output))
+		return err
+	}
+	if text != "" {
+		var err error
+		if bracketed {
+			err = pasteText(c, t, text)
+		} else {
+			var output []byte
+			output, err = c.Command("send-keys", "-l", "-t", t, "--", text).CombinedOutput()
+			if err != nil {
+				err = fmt.Errorf("failed to send text: %w\nOutput: %s", err, string(output))
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if enter {
+		output, err := c.Command("send-keys", "-t", t, "Enter").CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to send Enter: %w\nOutput: %s", err, string(output))
+		}
 	}
 	return nil
 }
 
-// SetStatusLine sets the tmux status line configuration for a session
+// pasteText loads text into a one-off tmux buffer on target's server and
+// pastes it into target, deleting the buffer afterwards
+func pasteText(c tmuxclient.Client, target, text string) error {
+	bufferName := "rvc-input-" + uuid.New().String()[:8]
+
+	load := c.Command("load-buffer", "-b", bufferName, "-")
+	load.Stdin = strings.NewReader(text)
+	if output, err := load.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to load paste buffer: %w\nOutput: %s", err, string(output))
+	}
+
+	output, err := c.Command("paste-buffer", "-d", "-p", "-b", bufferName, "-t", target).CombinedOutput()
+	if err != nil {
+		_ = c.Command("delete-buffer", "-b", bufferName).Run()
+		return fmt.Errorf("failed to paste buffer: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// SetStatusLine gives a session the rvc status line. The options are set on
+// the session only, so other sessions keep the user's own status line.
 func SetStatusLine(sessionName string) error {
-	statusConfig := []string{
-		"set-option -g status-left '#[bg=green]#[fg=black] RVC #[default] #{session_name} '",
-		"set-option -g status-right '%H:%M %d-%b-%y'",
-		"set-option -g status-bg '#1a1a2e'",
-		"set-option -g status-fg '#eee8aa'",
-		"set-option -g status-interval 1",
+	statusConfig := [][2]string{
+		{"status-left", "#[bg=green]#[fg=black] RVC #[default] #{session_name} "},
+		{"status-right", "%H:%M %d-%b-%y"},
+		{"status-style", "bg=#1a1a2e,fg=#eee8aa"},
+		{"status-interval", "1"},
+	}
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return err
 	}
 
-	for _, cfg := range statusConfig {
-		cmd := exec.Command("tmux", strings.Fields(cfg)...)
-		cmd.Args = append([]string{"tmux"}, strings.Fields(cfg)...)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to set status line: %w", err)
+	for _, opt := range statusConfig {
+		cmd := c.Command("set-option", "-t", name, opt[0], opt[1])
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to set %s: %w\nOutput: %s", opt[0], err, string(output))
 		}
 	}
 	return nil
@@ -198,16 +577,43 @@ func SetWritable(sessionName string, writable bool) error {
 	if writable {
 		value = "1"
 	}
-	cmd := exec.Command("tmux", "set-option", "-t", sessionName, "@rvc-writable", value)
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return err
+	}
+	cmd := c.Command("set-option", "-t", name, "@rvc-writable", value)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to set writable flag: %w", err)
 	}
 	return nil
 }
 
+// ListWritableFlags returns the @rvc-writable option of every session; the
+// value is empty for sessions where it is not set
+func ListWritableFlags() (map[string]string, error) {
+	flags := make(map[string]string)
+	err := eachServer(func(label, output string) {
+		// Only trim newlines: an unset option leaves a trailing separator
+		for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+			name, value, ok := strings.Cut(line, fieldSep)
+			if ok {
+				flags[tmuxclient.Qualify(name, label)] = value
+			}
+		}
+	}, "list-sessions", "-F", formatFields("#{session_name}", "#{@rvc-writable}"))
+	if err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
 // IsWritable checks if a session is writable (returns false if not set)
 func IsWritable(sessionName string) bool {
-	cmd := exec.Command("tmux", "show-option", "-t", sessionName, "-qv", "@rvc-writable")
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return false
+	}
+	cmd := c.Command("show-option", "-t", name, "-qv", "@rvc-writable")
 	output, err := cmd.Output()
 	if err != nil {
 		return false // Not set = read-only
@@ -0,0 +1,331 @@
+package tmux
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
+)
+
+// Discovery follows each watched tmux server through a control-mode client
+// (tmux -C) instead of polling it. tmux notifies the client when sessions,
+// windows or panes come and go, and a subscription reports activity and
+// changes to rvc's session options about once a second, so an idle server
+// costs nothing. A server with sessions but no control client, e.g. one whose
+// tmux is too old for subscriptions, is polled as before; one without
+// sessions is checked for new ones on every tick.
+
+const (
+	// controlTermName tells rvc's control clients apart in tmux list-clients
+	controlTermName = "rvc-control"
+	// controlFlags keep the control client from receiving pane output or
+	// resizing the windows of the session it attaches to
+	controlFlags = "read-only,ignore-size,no-output"
+	// controlSubscription is the subscription whose value changes whenever a
+	// session's activity, windows, panes or rvc options change. tmux checks
+	// it once a second.
+	controlSubscription = "rvc::#{S:#{session_name}\t#{session_activity}\t#{@rvc-writable}\t#{@rvc-agent}\t#{@rvc-triggers}\t" +
+		"#{W:#{window_activity}#{P:,#{pane_current_command}}/}|}"
+	// controlDebounce coalesces bursts of notifications, e.g. one for every
+	// window of a killed session, into one scan
+	controlDebounce = 50 * time.Millisecond
+	// maxControlBackoff caps the delay between attempts to attach a control
+	// client that failed
+	maxControlBackoff = time.Minute
+)
+
+// controlScanNotifications are the notifications after which the sessions,
+// windows or panes may have changed
+var controlScanNotifications = map[string]bool{
+	"%sessions-changed":        true,
+	"%session-renamed":         true,
+	"%session-window-changed":  true,
+	"%window-add":              true,
+	"%window-close":            true,
+	"%window-renamed":          true,
+	"%window-pane-changed":     true,
+	"%layout-change":           true,
+	"%unlinked-window-add":     true,
+	"%unlinked-window-close":   true,
+	"%unlinked-window-renamed": true,
+}
+
+// controlMonitor runs a control client for every watched tmux server
+type controlMonitor struct {
+	changed chan struct{} // signals the discovery loop to rescan
+
+	mu           sync.Mutex
+	clients      map[tmuxclient.Client]*controlClient
+	withSessions map[string]bool // labels of the servers that had sessions at the last scan
+	activity     time.Time       // last subscription change on any server
+}
+
+func newControlMonitor() *controlMonitor {
+	return &controlMonitor{
+		changed: make(chan struct{}, 1),
+		clients: make(map[tmuxclient.Client]*controlClient),
+	}
+}
+
+// sync starts a control client for every watched server and stops those
+// of servers that are no longer watched. Clients that are not connected
+// retry when their server has sessions, since tmux only lets a client
+// attach to an existing session.
+func (cm *controlMonitor) sync(withSessions map[string]bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.withSessions = withSessions
+
+	watched := make(map[tmuxclient.Client]bool)
+	for _, s := range tmuxclient.Servers() {
+		watched[s.Client] = true
+		cc := cm.clients[s.Client]
+		if cc == nil {
+			cc = &controlClient{
+				server:  s,
+				monitor: cm,
+				retry:   make(chan struct{}, 1),
+				stop:    make(chan struct{}),
+			}
+			cm.clients[s.Client] = cc
+			go cc.run()
+		}
+		if withSessions[s.Label] {
+			cc.kick()
+		}
+	}
+	for c, cc := range cm.clients {
+		if !watched[c] {
+			close(cc.stop)
+			delete(cm.clients, c)
+		}
+	}
+}
+
+// needsScan reports whether a watched server has to be polled: one that
+// has sessions but no subscribed control client, or one without sessions
+// that gained some since the last scan. Servers followed in control mode
+// report their changes themselves.
+func (cm *controlMonitor) needsScan() bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if len(cm.clients) == 0 {
+		return true
+	}
+	for _, cc := range cm.clients {
+		if cc.isLive() {
+			continue
+		}
+		if cm.withSessions[cc.server.Label] || cc.server.Command("has-session").Run() == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// activeSince reports whether a subscription changed after t
+func (cm *controlMonitor) activeSince(t time.Time) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.activity.After(t)
+}
+
+// recordActivity notes a subscription change
+func (cm *controlMonitor) recordActivity() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.activity = time.Now()
+}
+
+// notify asks the discovery loop to rescan
+func (cm *controlMonitor) notify() {
+	select {
+	case cm.changed <- struct{}{}:
+	default:
+	}
+}
+
+// stop ends all control clients
+func (cm *controlMonitor) stop() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	for c, cc := range cm.clients {
+		close(cc.stop)
+		delete(cm.clients, c)
+	}
+}
+
+// controlClient follows one tmux server in control mode
+type controlClient struct {
+	server  tmuxclient.Server
+	monitor *controlMonitor
+	retry   chan struct{} // asks a disconnected client to attach again
+	stop    chan struct{}
+
+	mu         sync.Mutex
+	connected  bool
+	subscribed bool
+}
+
+// kick asks the client to attach unless it is connected
+func (cc *controlClient) kick() {
+	if cc.isConnected() {
+		return
+	}
+	select {
+	case cc.retry <- struct{}{}:
+	default:
+	}
+}
+
+func (cc *controlClient) isConnected() bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.connected
+}
+
+func (cc *controlClient) isLive() bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.connected && cc.subscribed
+}
+
+func (cc *controlClient) setState(connected, subscribed bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.connected = connected
+	cc.subscribed = subscribed
+}
+
+// run attaches whenever it is kicked until the client is stopped. The
+// discovery loop kicks it after scans that find sessions on its server.
+func (cc *controlClient) run() {
+	var lastErr string
+	backoff := time.Second
+	for {
+		select {
+		case <-cc.stop:
+			return
+		case <-cc.retry:
+		}
+		err := cc.follow()
+		cc.setState(false, false)
+		if err == nil {
+			// The server changed while the client was attached, e.g. the
+			// session it was attached to was closed
+			lastErr = ""
+			backoff = time.Second
+			cc.monitor.notify()
+			continue
+		}
+
+		// Only log changes, since a failing client is retried
+		if err.Error() != lastErr {
+			log.Printf("tmux control mode is unavailable for %s, polling it instead: %v", cc.server, err)
+			lastErr = err.Error()
+		}
+		select {
+		case <-cc.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxControlBackoff)
+	}
+}
+
+// follow attaches a control client and reads its notifications until it
+// exits or the client is stopped. A client that attached returns nil when
+// it exits, e.g. because its session was closed.
+func (cc *controlClient) follow() error {
+	cmd := cc.server.Command("-C", "attach-session", "-f", controlFlags)
+	cmd.Env = append(os.Environ(), "TERM="+controlTermName)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Closing stdin makes the client exit
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-cc.stop:
+		case <-done:
+		}
+		_ = stdin.Close()
+	}()
+
+	// Replies are framed by %begin and %end or %error. The attach itself is
+	// answered first; replies to commands rvc sent are flagged 1. Commands
+	// sent before the attach completed would find no client to act on.
+	attached := false
+	var failure string
+	inReply, ours := false, false
+	var reply []string
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		kind, rest, _ := strings.Cut(line, " ")
+		if inReply {
+			if kind != "%end" && kind != "%error" {
+				reply = append(reply, line)
+				continue
+			}
+			inReply = false
+			ok := kind == "%end"
+			switch {
+			case ours:
+				cc.setState(true, ok)
+				if !ok {
+					log.Printf("tmux control mode: %s does not support subscriptions (%s), polling it instead", cc.server, strings.Join(reply, " "))
+				}
+			case ok:
+				attached = true
+				cc.setState(true, false)
+				_, _ = io.WriteString(stdin, "refresh-client -B '"+controlSubscription+"'\n")
+			default:
+				failure = strings.Join(reply, " ") // e.g. "no sessions"
+			}
+			reply = nil
+			continue
+		}
+
+		switch {
+		case kind == "%begin":
+			fields := strings.Fields(rest)
+			inReply = true
+			ours = len(fields) == 3 && fields[2] == "1"
+		case kind == "%subscription-changed":
+			cc.monitor.recordActivity()
+		case controlScanNotifications[kind]:
+			cc.monitor.notify()
+		}
+	}
+	err = cmd.Wait()
+	if attached {
+		return nil
+	}
+	if failure == "" {
+		failure = strings.TrimSpace(stderr.String())
+	}
+	if failure == "" && err != nil {
+		failure = err.Error()
+	}
+	return fmt.Errorf("attach failed: %s", failure)
+}
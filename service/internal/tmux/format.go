@@ -0,0 +1,19 @@
+package tmux
+
+import "strings"
+
+// fieldSep separates the fields of the -F formats rvc parses. It is the ASCII
+// unit separator, which does not occur in session, window or buffer names or
+// in paths, unlike a tab. tmux only passes it through to clients that use
+// UTF-8, which tmuxclient forces with -u.
+const fieldSep = "\x1f"
+
+// formatFields joins format fields, e.g. "#{session_name}", with fieldSep
+func formatFields(fields ...string) string {
+	return strings.Join(fields, fieldSep)
+}
+
+// splitFields splits a line of output of a format built with formatFields
+func splitFields(line string) []string {
+	return strings.Split(line, fieldSep)
+}
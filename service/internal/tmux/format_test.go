@@ -0,0 +1,325 @@
+package tmux
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
+)
+
+// line joins the fields of one line of -F output
+func line(fields ...string) string {
+	return strings.Join(fields, fieldSep) + "\n"
+}
+
+func TestParsePanes(t *testing.T) {
+	pane := func(session, window, name, paneID, path string) string {
+		return line(session, window, "1", name, "1", "b25d,80x24,0,0,1", paneID, "0", "1", "zsh", path, "4242", "80", "24")
+	}
+	tests := []struct {
+		name   string
+		output string
+		label  string
+		want   map[string][]Window
+	}{
+		{
+			name:   "empty",
+			output: "",
+			want:   map[string][]Window{},
+		},
+		{
+			name: "windows and panes",
+			output: pane("my_api", "@1", "build logs", "%1", "/home/me/src dir") +
+				pane("my_api", "@1", "build logs", "%2", "/tmp") +
+				pane("web", "@2", "web", "%3", "/"),
+			want: map[string][]Window{
+				"my_api": {{ID: "@1", Index: 1, Name: "build logs", Active: true, Layout: "b25d,80x24,0,0,1", Panes: []Pane{
+					{ID: "%1", Active: true, Command: "zsh", Path: "/home/me/src dir", PID: 4242, Width: 80, Height: 24},
+					{ID: "%2", Active: true, Command: "zsh", Path: "/tmp", PID: 4242, Width: 80, Height: 24},
+				}}},
+				"web": {{ID: "@2", Index: 1, Name: "web", Active: true, Layout: "b25d,80x24,0,0,1", Panes: []Pane{
+					{ID: "%3", Active: true, Command: "zsh", Path: "/", PID: 4242, Width: 80, Height: 24},
+				}}},
+			},
+		},
+		{
+			name:   "labelled server",
+			output: pane("api", "@1", "api", "%1", "/"),
+			label:  "work",
+			want: map[string][]Window{
+				"api@work": {{ID: "@1@work", Index: 1, Name: "api", Active: true, Layout: "b25d,80x24,0,0,1", Panes: []Pane{
+					{ID: "%1@work", Active: true, Command: "zsh", Path: "/", PID: 4242, Width: 80, Height: 24},
+				}}},
+			},
+		},
+		{
+			// What tmux prints for a client without a UTF-8 locale and without -u
+			name:   "control characters replaced",
+			output: strings.ReplaceAll(pane("api", "@1", "api", "%1", "/"), fieldSep, "_"),
+			want:   map[string][]Window{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePanes(tt.output, tt.label); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePanes =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSummaries(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []SessionSummary
+	}{
+		{
+			name:   "empty",
+			output: "",
+			want:   []SessionSummary{},
+		},
+		{
+			name: "sessions",
+			output: line("my_api", "1700000000", "2", "3", "1", "claude", "/home/me/src dir") +
+				line("web", "0", "0", "1", "", "zsh", "/"),
+			want: []SessionSummary{
+				{Name: "my_api", Created: time.Unix(1700000000, 0), Attached: 2, Windows: 3, Writable: true, Command: "claude", Cwd: "/home/me/src dir"},
+				{Name: "web", Windows: 1, Command: "zsh", Cwd: "/"},
+			},
+		},
+		{
+			name:   "separator in the path",
+			output: line("api", "1700000000", "0", "1", "0", "zsh", "/odd"+fieldSep+"dir"),
+			want: []SessionSummary{
+				{Name: "api", Created: time.Unix(1700000000, 0), Windows: 1, Command: "zsh", Cwd: "/odd" + fieldSep + "dir"},
+			},
+		},
+		{
+			name:   "control characters replaced",
+			output: "api_1700000000_0_1_0_zsh_/\n",
+			want:   []SessionSummary{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSummaries(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSummaries =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBuffers(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		label  string
+		want   []Buffer
+	}{
+		{
+			name:   "empty",
+			output: "",
+			want:   []Buffer{},
+		},
+		{
+			name:   "buffers",
+			output: line("buffer0", "11", "1700000000", "hello world") + line("notes.md", "3", "1700000001", "a\tb"),
+			want: []Buffer{
+				{Name: "buffer0", Size: 11, Created: time.Unix(1700000000, 0), Sample: "hello world"},
+				{Name: "notes.md", Size: 3, Created: time.Unix(1700000001, 0), Sample: "a\tb"},
+			},
+		},
+		{
+			name:   "empty sample on a labelled server",
+			output: line("buffer1", "0", "1700000000", ""),
+			label:  "work",
+			want:   []Buffer{{Name: "buffer1@work", Created: time.Unix(1700000000, 0)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseBuffers(tt.output, tt.label); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBuffers =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestServer starts a private tmux server and makes it the default one.
+// Clients run without a UTF-8 locale and outside tmux, which tmux takes for
+// UTF-8, so -F output has its control characters mangled unless run with -u.
+func newTestServer(t *testing.T) tmuxclient.Client {
+	t.Helper()
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux is not installed")
+	}
+	t.Setenv("LC_ALL", "C")
+	t.Setenv("LANG", "C")
+	t.Setenv("TMUX", "") // restored after the test
+	os.Unsetenv("TMUX")
+
+	c := tmuxclient.Client{SocketPath: filepath.Join(t.TempDir(), "tmux.sock")}
+	prev := tmuxclient.Default()
+	tmuxclient.SetDefault(c)
+	t.Cleanup(func() {
+		_ = c.Command("kill-server").Run()
+		tmuxclient.SetDefault(prev)
+	})
+	return c
+}
+
+// tmuxRun runs a tmux command on the test server and fails the test on errors
+func tmuxRun(t *testing.T, c tmuxclient.Client, args ...string) {
+	t.Helper()
+	if output, err := c.Command(args...).CombinedOutput(); err != nil {
+		t.Fatalf("tmux %s: %v: %s", strings.Join(args, " "), err, output)
+	}
+}
+
+func TestParsersOnServer(t *testing.T) {
+	c := newTestServer(t)
+	dir := filepath.Join(t.TempDir(), "src dir")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	dir, _ = filepath.EvalSymlinks(dir)
+
+	tmuxRun(t, c, "-f", "/dev/null", "new-session", "-d", "-s", "my_api", "-n", "build logs", "-c", dir, "-x", "80", "-y", "24", "sh")
+	tmuxRun(t, c, "split-window", "-d", "-t", "my_api:build logs", "-c", dir, "sh")
+	tmuxRun(t, c, "new-window", "-d", "-t", "my_api", "-c", dir, "sh")
+	tmuxRun(t, c, "new-session", "-d", "-s", "web", "-c", dir, "sh")
+	tmuxRun(t, c, "set-option", "-t", "my_api", "@rvc-writable", "1")
+	tmuxRun(t, c, "set-option", "-t", "my_api", "@rvc-agent", "claude")
+	tmuxRun(t, c, "set-option", "-t", "my_api", "@rvc-triggers", `[{"name":"go on","match":"Continue\\?","keys":["Enter"]}]`)
+	tmuxRun(t, c, "set-buffer", "-b", "notes", "hello world")
+
+	t.Run("ListSessionSummaries", func(t *testing.T) {
+		summaries, err := ListSessionSummaries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]SessionSummary)
+		for _, s := range summaries {
+			if s.Created.IsZero() {
+				t.Errorf("%s: Created is zero", s.Name)
+			}
+			s.Created = time.Time{}
+			got[s.Name] = s
+		}
+		want := map[string]SessionSummary{
+			"my_api": {Name: "my_api", Windows: 2, Writable: true, Command: "sh", Cwd: dir},
+			"web":    {Name: "web", Windows: 1, Command: "sh", Cwd: dir},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ListSessionSummaries =\n%+v\nwant\n%+v", got, want)
+		}
+	})
+
+	t.Run("ListWindows", func(t *testing.T) {
+		windows, err := ListWindows("my_api")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(windows) != 2 {
+			t.Fatalf("ListWindows = %+v, want 2 windows", windows)
+		}
+		if w := windows[0]; w.Name != "build logs" || !w.Active || len(w.Panes) != 2 || !strings.HasPrefix(w.ID, "@") {
+			t.Errorf("first window = %+v, want the active \"build logs\" window with 2 panes", w)
+		}
+		for _, p := range windows[0].Panes {
+			if p.Path != dir || p.Command != "sh" || p.PID == 0 || p.Width == 0 || !strings.HasPrefix(p.ID, "%") {
+				t.Errorf("pane = %+v, want sh in %q", p, dir)
+			}
+		}
+		if _, err := WindowTarget("my_api", windows[1].ID); err != nil {
+			t.Errorf("WindowTarget(%s): %v", windows[1].ID, err)
+		}
+		if _, err := PaneTarget("my_api", windows[0].Panes[1].ID); err != nil {
+			t.Errorf("PaneTarget(%s): %v", windows[0].Panes[1].ID, err)
+		}
+		if _, err := PaneTarget("web", windows[0].Panes[1].ID); err != ErrPaneNotFound {
+			t.Errorf("PaneTarget of another session's pane = %v, want ErrPaneNotFound", err)
+		}
+	})
+
+	t.Run("ListAllWindows", func(t *testing.T) {
+		all, err := ListAllWindows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all["my_api"]) != 2 || len(all["web"]) != 1 {
+			t.Errorf("ListAllWindows = %+v, want 2 windows in my_api and 1 in web", all)
+		}
+	})
+
+	t.Run("ListWritableFlags", func(t *testing.T) {
+		flags, err := ListWritableFlags()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]string{"my_api": "1", "web": ""}; !reflect.DeepEqual(flags, want) {
+			t.Errorf("ListWritableFlags = %q, want %q", flags, want)
+		}
+	})
+
+	t.Run("ListSessionAgents", func(t *testing.T) {
+		agents, err := ListSessionAgents()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]string{"my_api": "claude"}; !reflect.DeepEqual(agents, want) {
+			t.Errorf("ListSessionAgents = %q, want %q", agents, want)
+		}
+	})
+
+	t.Run("ListSessionTriggers", func(t *testing.T) {
+		triggers, err := ListSessionTriggers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string][]Trigger{"my_api": {{Name: "go on", Match: `Continue\?`, Keys: []string{"Enter"}}}}
+		if !reflect.DeepEqual(triggers, want) {
+			t.Errorf("ListSessionTriggers = %+v, want %+v", triggers, want)
+		}
+	})
+
+	t.Run("ListSessionActivity", func(t *testing.T) {
+		activity, err := ListSessionActivity()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"my_api", "web"} {
+			if a, ok := activity[name]; !ok || a.Window.IsZero() {
+				t.Errorf("activity of %s = %+v, %v, want a window activity", name, a, ok)
+			}
+		}
+	})
+
+	t.Run("ListBuffers", func(t *testing.T) {
+		buffers, err := ListBuffers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(buffers) != 1 || buffers[0].Name != "notes" || buffers[0].Size != 11 || buffers[0].Sample != "hello world" || buffers[0].Created.IsZero() {
+			t.Errorf("ListBuffers = %+v, want the buffer notes", buffers)
+		}
+	})
+
+	t.Run("no server", func(t *testing.T) {
+		tmuxRun(t, c, "kill-server")
+		sessions, err := ListSessions()
+		if err != nil || len(sessions) != 0 {
+			t.Errorf("ListSessions = %q, %v, want no sessions", sessions, err)
+		}
+		summaries, err := ListSessionSummaries()
+		if err != nil || len(summaries) != 0 {
+			t.Errorf("ListSessionSummaries = %+v, %v, want no sessions", summaries, err)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package tmux
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
+)
+
+// allowedKeys are the named tmux keys that may be sent through the API
+var allowedKeys = map[string]bool{
+	"Enter": true, "Escape": true, "Tab": true, "BTab": true, "BSpace": true, "Space": true,
+	"Up": true, "Down": true, "Left": true, "Right": true,
+	"Home": true, "End": true, "PageUp": true, "PageDown": true, "PPage": true, "NPage": true,
+	"IC": true, "DC": true,
+	"F1": true, "F2": true, "F3": true, "F4": true, "F5": true, "F6": true,
+	"F7": true, "F8": true, "F9": true, "F10": true, "F11": true, "F12": true,
+}
+
+// ctrlKeyPattern matches Ctrl+letter combinations such as C-c
+var ctrlKeyPattern = regexp.MustCompile(`^C-[a-z]$`)
+
+// IsAllowedKey checks whether a key may be sent with SendKeys: a named key from
+// the allowlist, a Ctrl+letter combination, or a single printable character.
+// The tmux command separator ";" is never allowed.
+func IsAllowedKey(key string) bool {
+	if allowedKeys[key] || ctrlKeyPattern.MatchString(key) {
+		return true
+	}
+	if len(key) != 1 {
+		return false
+	}
+	c := key[0]
+	return c > ' ' && c < 0x7f && c != ';'
+}
+
+// SendKeys sends named keys to a tmux target (session, window or pane).
+// Keys must be validated with IsAllowedKey first.
+func SendKeys(target string, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		if !IsAllowedKey(key) {
+			return fmt.Errorf("key not allowed: %q", key)
+		}
+	}
+
+	c, t, err := resolve(target)
+	if err != nil {
+		return err
+	}
+	args := append([]string{"send-keys", "-t", t}, keys...)
+	output, err := c.Command(args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to send keys: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+var (
+	paneIDPattern    = regexp.MustCompile(`^%[0-9]+(@[A-Za-z0-9_-]{1,64})?$`)
+	paneIndexPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+// PaneTarget builds a tmux target for a pane inside a session. The pane may be
+// empty (active pane), a pane ID such as "%3" (or "%3@label" in a session on a
+// labelled server), or "window.pane" indexes.
+func PaneTarget(sessionName, pane string) (string, error) {
+	if !IsValidSessionName(sessionName) {
+		return "", ErrInvalidSessionName
+	}
+
+	switch {
+	case pane == "":
+		return sessionName, nil
+	case paneIndexPattern.MatchString(pane):
+		return sessionName + ":" + pane, nil
+	case paneIDPattern.MatchString(pane):
+		// Pane IDs are global to the tmux server, so make sure it belongs to
+		// this session. An ID without a label is on the session's server.
+		bareName, label := tmuxclient.Split(sessionName)
+		bareID, idLabel := tmuxclient.Split(pane)
+		if idLabel == "" {
+			idLabel = label
+		}
+		pane = tmuxclient.Qualify(bareID, idLabel)
+		if idLabel != label || describe(pane, formatFields("#{pane_id}", "#{session_name}")) != bareID+fieldSep+bareName {
+			return "", ErrPaneNotFound
+		}
+		return pane, nil
+	default:
+		return "", ErrInvalidPane
+	}
+}
+
+// QuickAction is a one-tap button that sends a sequence of keys
+type QuickAction struct {
+	Label string   `json:"label"`
+	Keys  []string `json:"keys"`
+}
+
+// DefaultQuickActions is the button set used when a session has none configured
+var DefaultQuickActions = []QuickAction{
+	{Label: "y", Keys: []string{"y"}},
+	{Label: "n", Keys: []string{"n"}},
+	{Label: "Enter", Keys: []string{"Enter"}},
+	{Label: "Esc", Keys: []string{"Escape"}},
+	{Label: "↑", Keys: []string{"Up"}},
+	{Label: "↓", Keys: []string{"Down"}},
+	{Label: "Tab", Keys: []string{"Tab"}},
+	{Label: "Ctrl-C", Keys: []string{"C-c"}},
+}
+
+// GetQuickActions returns the quick-action buttons configured for a session
+// (stored in the @rvc-actions user option), or the defaults if none are set
+func GetQuickActions(sessionName string) ([]QuickAction, error) {
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.Command("show-option", "-t", name, "-qv", "@rvc-actions").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quick actions: %w", err)
+	}
+
+	value := strings.TrimSpace(string(output))
+	if value == "" {
+		return DefaultQuickActions, nil
+	}
+
+	var actions []QuickAction
+	if err := json.Unmarshal([]byte(value), &actions); err != nil {
+		return nil, fmt.Errorf("invalid @rvc-actions option: %w", err)
+	}
+	return actions, nil
+}
+
+// SetQuickActions stores the quick-action buttons for a session.
+// An empty list restores the defaults.
+func SetQuickActions(sessionName string, actions []QuickAction) error {
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return err
+	}
+	if len(actions) == 0 {
+		cmd := c.Command("set-option", "-t", name, "-u", "@rvc-actions")
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to reset quick actions: %w", err)
+		}
+		return nil
+	}
+
+	for _, action := range actions {
+		if action.Label == "" || len(action.Keys) == 0 {
+			return fmt.Errorf("quick action needs a label and at least one key")
+		}
+		for _, key := range action.Keys {
+			if !IsAllowedKey(key) {
+				return fmt.Errorf("key not allowed: %q", key)
+			}
+		}
+	}
+
+	data, err := json.Marshal(actions)
+	if err != nil {
+		return err
+	}
+	cmd := c.Command("set-option", "-t", name, "@rvc-actions", string(data))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to set quick actions: %w", err)
+	}
+	return nil
+}
@@ -2,32 +2,79 @@ package tmux
 
 import (
 	"log"
+	"path"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/ibrahim/remote-vibecode/internal/agent"
+	"github.com/ibrahim/remote-vibecode/internal/procinfo"
 	"github.com/ibrahim/remote-vibecode/internal/session"
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
 	"github.com/ibrahim/remote-vibecode/internal/ws"
 )
 
 // Manager manages tmux session tracking and discovery
 type Manager struct {
-	mu                 sync.RWMutex
+	mu     sync.RWMutex
+	scanMu sync.Mutex // serializes scans with renames, which change tmux and the maps in two steps
+
 	sessions           map[string]*session.TmuxSession // session ID -> TmuxSession
 	sessionByName      map[string]*session.TmuxSession // session name -> TmuxSession
 	discoveryInterval  time.Duration
+	discoveryChanged   chan struct{} // signals the discovery loop to pick up a new interval
 	stopDiscovery      chan struct{}
-	autoAttachPatterns []string       // Session name patterns to auto-attach (e.g., "claude", "tmux-*")
-	sessionHub         *ws.SessionHub // Hub for broadcasting session updates
+	autoAttachPatterns []string                       // glob patterns of session names to track; "*" tracks all
+	sessionHub         *ws.SessionHub                 // Hub for broadcasting session updates
+	control            *controlMonitor                // follows the tmux servers in control mode
+	windows            map[string][]Window            // session name -> windows, refreshed on every scan
+	writable           map[string]bool                // session name -> writable, refreshed on every scan
+	paneStats          map[string]*procinfo.PaneStats // pane ID -> processes, refreshed by the process sampler
+	agents             *agent.Registry
+	sessionAgents      map[string]string            // session name -> adapter, refreshed on every scan
+	decisions          map[string]*agent.Decision   // session name -> pending decision
+	triggerMatches     map[string]map[string]string // session name -> trigger name -> text it last matched
+	eventHandlers      []func(SessionEvent)
+	initialScanDone    bool // events are only emitted for changes after the first scan
+}
+
+// Session event types
+const (
+	EventSessionCreated = "session_created"
+	EventSessionClosed  = "session_closed"
+	EventAwaitingInput  = "awaiting_input"
+	// EventDecisionPending is emitted when an agent adapter detects a new prompt
+	EventDecisionPending = "decision_pending"
+	// EventTrigger is emitted when a session trigger with notify set fires
+	EventTrigger = "trigger"
+)
+
+// SessionEvent describes a change to a tracked session
+type SessionEvent struct {
+	Type        string
+	SessionName string
+	Detail      string // e.g. the prompt of a pending decision
+	Time        time.Time
 }
 
-// New creates a new tmux manager
-func New(sessionHub *ws.SessionHub) *Manager {
+// New creates a new tmux manager that tracks sessions whose names match one
+// of the autoAttach glob patterns. tmux servers are followed in control mode
+// where possible and otherwise scanned every discoveryInterval.
+func New(sessionHub *ws.SessionHub, discoveryInterval time.Duration, autoAttach []string) *Manager {
 	m := &Manager{
 		sessions:           make(map[string]*session.TmuxSession),
 		sessionByName:      make(map[string]*session.TmuxSession),
-		discoveryInterval:  2 * time.Second, // Check for new sessions every 2 seconds
+		control:            newControlMonitor(),
+		windows:            make(map[string][]Window),
+		writable:           make(map[string]bool),
+		paneStats:          make(map[string]*procinfo.PaneStats),
+		sessionAgents:      make(map[string]string),
+		decisions:          make(map[string]*agent.Decision),
+		triggerMatches:     make(map[string]map[string]string),
+		discoveryInterval:  discoveryInterval,
+		discoveryChanged:   make(chan struct{}, 1),
 		stopDiscovery:      make(chan struct{}),
-		autoAttachPatterns: []string{"claude", "tmux"}, // Auto-attach to sessions starting with these
+		autoAttachPatterns: autoAttach,
 		sessionHub:         sessionHub,
 	}
 
@@ -37,16 +84,51 @@ func New(sessionHub *ws.SessionHub) *Manager {
 	return m
 }
 
-// AttachSession attaches to an existing tmux session (for tracking only)
+// OnEvent registers a handler that is called for session events.
+// Handlers run on the discovery goroutine and should not block.
+func (m *Manager) OnEvent(handler func(SessionEvent)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.eventHandlers = append(m.eventHandlers, handler)
+}
+
+// emit delivers an event to all registered handlers
+func (m *Manager) emit(eventType, sessionName, detail string) {
+	m.mu.RLock()
+	if !m.initialScanDone {
+		m.mu.RUnlock()
+		return
+	}
+	handlers := append([]func(SessionEvent){}, m.eventHandlers...)
+	m.mu.RUnlock()
+
+	ev := SessionEvent{Type: eventType, SessionName: sessionName, Detail: detail, Time: time.Now()}
+	for _, handler := range handlers {
+		handler(ev)
+	}
+}
+
+// AttachSession attaches to an existing tmux session (for tracking only) and
+// emits a session_created event if it was not tracked yet
 func (m *Manager) AttachSession(sessionName string) (*session.TmuxSession, error) {
+	sess, created, err := m.track(sessionName)
+	if created {
+		m.emit(EventSessionCreated, sessionName, "")
+	}
+	return sess, err
+}
+
+// track starts tracking a tmux session and reports whether it was not
+// tracked before
+func (m *Manager) track(sessionName string) (*session.TmuxSession, bool, error) {
 	// Validate session name
 	if !IsValidSessionName(sessionName) {
-		return nil, ErrInvalidSessionName
+		return nil, false, ErrInvalidSessionName
 	}
 
 	// Check if session exists
 	if !SessionExists(sessionName) {
-		return nil, ErrSessionNotFound
+		return nil, false, ErrSessionNotFound
 	}
 
 	m.mu.Lock()
@@ -54,13 +136,13 @@ func (m *Manager) AttachSession(sessionName string) (*session.TmuxSession, error
 
 	// Check if already attached
 	if sess, exists := m.sessionByName[sessionName]; exists {
-		return sess, nil
+		return sess, false, nil
 	}
 
 	// Get session info
 	info, err := GetSessionInfo(sessionName)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	// Create new session
@@ -73,7 +155,7 @@ func (m *Manager) AttachSession(sessionName string) (*session.TmuxSession, error
 
 	log.Printf("Tracking tmux session: %s (id: %s)", sessionName, sess.ID)
 
-	return sess, nil
+	return sess, true, nil
 }
 
 // DetachSession detaches from a tmux session
@@ -96,6 +178,34 @@ func (m *Manager) DetachSession(sessionID string) {
 	log.Printf("Stopped tracking tmux session: %s", sess.SessionName)
 }
 
+// RenameSession renames a tmux session and updates tracking in place,
+// so the session keeps its ID and activity state across the rename
+func (m *Manager) RenameSession(oldName, newName string) error {
+	m.scanMu.Lock()
+	defer m.scanMu.Unlock()
+
+	if err := RenameSession(oldName, newName); err != nil {
+		return err
+	}
+	newName, _ = RenamedName(oldName, newName)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if sess, exists := m.sessionByName[oldName]; exists {
+		delete(m.sessionByName, oldName)
+		sess.SessionName = newName
+		m.sessionByName[newName] = sess
+		log.Printf("Renamed tmux session: %s -> %s", oldName, newName)
+	}
+	return nil
+}
+
+// Refresh rescans tmux sessions immediately and broadcasts the result
+func (m *Manager) Refresh() {
+	m.scanAndAttach()
+}
+
 // GetSession retrieves a session by ID
 func (m *Manager) GetSession(sessionID string) (*session.TmuxSession, bool) {
 	m.mu.RLock()
@@ -129,12 +239,25 @@ func (m *Manager) ListSessions() []*session.TmuxSession {
 
 // Errors
 var (
-	ErrInvalidSessionName = &TmuxError{Message: "invalid session name"}
-	ErrSessionNotFound    = &TmuxError{Message: "tmux session not found"}
+	ErrInvalidSessionName = &TmuxError{Code: "invalid_session_name", Message: "invalid session name"}
+	ErrSessionNotFound    = &TmuxError{Code: "session_not_found", Message: "tmux session not found"}
+	ErrSessionExists      = &TmuxError{Code: "session_exists", Message: "tmux session already exists"}
+	ErrSessionReadOnly    = &TmuxError{Code: "session_read_only", Message: "session is read-only"}
+	ErrInvalidWindow      = &TmuxError{Code: "invalid_window", Message: "invalid window"}
+	ErrWindowNotFound     = &TmuxError{Code: "window_not_found", Message: "window not found in session"}
+	ErrInvalidPane        = &TmuxError{Code: "invalid_pane", Message: "invalid pane"}
+	ErrPaneNotFound       = &TmuxError{Code: "pane_not_found", Message: "pane not found in session"}
+	ErrInvalidBuffer      = &TmuxError{Code: "invalid_buffer", Message: "invalid buffer name"}
+	ErrBufferNotFound     = &TmuxError{Code: "buffer_not_found", Message: "paste buffer not found"}
+	ErrInvalidOptions     = &TmuxError{Code: "invalid_options", Message: "invalid session options"}
+	ErrCommandFailed      = &TmuxError{Code: "tmux_failed", Message: "tmux command failed"}
+	ErrServerNotFound     = &TmuxError{Code: "server_not_found", Message: "unknown tmux server"}
 )
 
-// TmuxError represents a tmux-related error
+// TmuxError represents a tmux-related error.
+// Code is a stable machine-readable identifier used in API responses.
 type TmuxError struct {
+	Code    string
 	Message string
 }
 
@@ -142,9 +265,29 @@ func (e *TmuxError) Error() string {
 	return e.Message
 }
 
-// discoveryLoop periodically scans for new tmux sessions and auto-attaches
+// SetDiscovery changes how often sessions are scanned and which session
+// names are tracked, and rescans right away, e.g. to pick up new tmux
+// servers. Sessions that are already tracked stay tracked.
+func (m *Manager) SetDiscovery(interval time.Duration, autoAttach []string) {
+	m.mu.Lock()
+	m.discoveryInterval = interval
+	m.autoAttachPatterns = autoAttach
+	m.mu.Unlock()
+
+	select {
+	case m.discoveryChanged <- struct{}{}:
+	default:
+	}
+}
+
+// discoveryLoop scans for new tmux sessions and auto-attaches. Servers that
+// are followed in control mode are rescanned when tmux reports a change and
+// refreshed after activity; the others are scanned on every tick.
 func (m *Manager) discoveryLoop() {
-	ticker := time.NewTicker(m.discoveryInterval)
+	m.mu.RLock()
+	interval := m.discoveryInterval
+	m.mu.RUnlock()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	// Do initial scan on startup
@@ -153,9 +296,35 @@ func (m *Manager) discoveryLoop() {
 	for {
 		select {
 		case <-m.stopDiscovery:
+			m.control.stop()
 			return
-		case <-ticker.C:
+		case <-m.discoveryChanged:
+			m.mu.RLock()
+			interval = m.discoveryInterval
+			m.mu.RUnlock()
+			ticker.Reset(interval)
+			m.scanAndAttach()
+		case <-m.control.changed:
+			time.Sleep(controlDebounce)
+			select {
+			case <-m.control.changed:
+			default:
+			}
 			m.scanAndAttach()
+		case now := <-ticker.C:
+			switch {
+			case m.control.needsScan():
+				m.scanAndAttach()
+			case m.control.activeSince(now.Add(-interval - time.Second)):
+				// Subscriptions have a resolution of one second, so a session
+				// that was active within the last tick may have changed since
+				// it was reported
+				m.refresh()
+			default:
+				// Nothing changed in tmux; states still age
+				m.updateStates()
+				m.broadcastSessions()
+			}
 		}
 	}
 }
@@ -163,6 +332,9 @@ func (m *Manager) discoveryLoop() {
 // scanAndAttach scans for tmux sessions and attaches to matching ones
 // Also removes sessions that no longer exist in tmux
 func (m *Manager) scanAndAttach() {
+	m.scanMu.Lock()
+	defer m.scanMu.Unlock()
+
 	// Get all tmux sessions
 	sessionNames, err := ListSessions()
 	if err != nil {
@@ -175,7 +347,16 @@ func (m *Manager) scanAndAttach() {
 		currentSessions[name] = true
 	}
 
+	// Follow the servers that have sessions in control mode
+	withSessions := make(map[string]bool)
+	for _, name := range sessionNames {
+		_, label := tmuxclient.Split(name)
+		withSessions[label] = true
+	}
+	m.control.sync(withSessions)
+
 	// Find and remove sessions that no longer exist
+	var closed []string
 	m.mu.Lock()
 	for sessionID, sess := range m.sessions {
 		if !currentSessions[sess.SessionName] {
@@ -184,10 +365,15 @@ func (m *Manager) scanAndAttach() {
 			sess.Stop()
 			delete(m.sessions, sessionID)
 			delete(m.sessionByName, sess.SessionName)
+			closed = append(closed, sess.SessionName)
 		}
 	}
 	m.mu.Unlock()
 
+	for _, sessionName := range closed {
+		m.emit(EventSessionClosed, sessionName, "")
+	}
+
 	// Add new sessions that aren't tracked yet
 	for _, sessionName := range sessionNames {
 		m.mu.RLock()
@@ -201,39 +387,268 @@ func (m *Manager) scanAndAttach() {
 		// Check if session matches auto-attach patterns
 		if m.shouldAutoAttach(sessionName) {
 			log.Printf("Auto-discovered tmux session: %s", sessionName)
-			m.AttachSession(sessionName)
+			_, _ = m.AttachSession(sessionName)
 		}
 	}
 
+	m.mu.Lock()
+	m.initialScanDone = true
+	m.mu.Unlock()
+
+	m.refresh()
+}
+
+// refresh updates what tmux reports about the tracked sessions and
+// broadcasts the session list
+func (m *Manager) refresh() {
+	m.updateActivity()
+	m.updateWritable()
+	m.updateWindows()
+	m.updateDecisions()
+	m.updateTriggers()
+
 	// Broadcast updated session list to all connected clients
-	if m.sessionHub != nil {
-		m.broadcastSessions()
+	m.broadcastSessions()
+}
+
+// updateActivity refreshes activity timestamps and derived states of tracked sessions
+// from tmux's own #{window_activity} and the #{client_activity} of attached
+// terminals. Output is never taken from web viewers' PTY streams, which also
+// carry status line redraws, e.g. of the clock.
+func (m *Manager) updateActivity() {
+	activity, err := ListSessionActivity()
+	if err != nil {
+		activity = map[string]SessionActivity{}
 	}
+
+	m.mu.RLock()
+	for _, sess := range m.sessions {
+		if a, ok := activity[sess.SessionName]; ok {
+			sess.RecordOutput(a.Window)
+			sess.RecordInput(a.Session)
+		}
+	}
+	m.mu.RUnlock()
+
+	m.updateStates()
 }
 
-// broadcastSessions sends the current session list to all connected WebSocket clients
-func (m *Manager) broadcastSessions() {
+// updateStates derives the state of every tracked session from its activity
+// timestamps and emits an event for each session that starts awaiting input
+func (m *Manager) updateStates() {
+	now := time.Now()
+	var awaiting []string
+
+	m.mu.RLock()
+	for _, sess := range m.sessions {
+		if state, changed := sess.UpdateState(now); changed && state == session.StateAwaitingInput {
+			awaiting = append(awaiting, sess.SessionName)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, sessionName := range awaiting {
+		m.emit(EventAwaitingInput, sessionName, "")
+	}
+}
+
+// updateWritable refreshes the cached writable flag of all sessions
+func (m *Manager) updateWritable() {
+	flags, err := ListWritableFlags()
+	if err != nil {
+		flags = map[string]string{}
+	}
+
+	writable := make(map[string]bool, len(flags))
+	for sessionName, flag := range flags {
+		writable[sessionName] = flag == "1"
+	}
+	m.mu.Lock()
+	m.writable = writable
+	m.mu.Unlock()
+}
+
+// updateWindows refreshes the cached window and pane layout of all sessions
+func (m *Manager) updateWindows() {
+	windows, err := ListAllWindows()
+	if err != nil {
+		windows = map[string][]Window{}
+	}
+
+	m.mu.Lock()
+	m.windows = windows
+	m.mu.Unlock()
+}
+
+// SessionInfos returns the broadcast representation of all tracked sessions
+func (m *Manager) SessionInfos() []ws.SessionInfo {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	sessionInfos := make([]ws.SessionInfo, 0, len(m.sessions))
 	for _, sess := range m.sessions {
+		activity := sess.Activity()
 		sessionInfos = append(sessionInfos, ws.SessionInfo{
 			ID:          sess.ID,
 			SessionName: sess.SessionName,
 			CreatedAt:   sess.CreatedAt.Unix(),
-			LastCapture: sess.LastCapture.Unix(),
-			Writable:    IsWritable(sess.SessionName),
+			LastCapture: unixOrZero(activity.LastOutput),
+			LastInput:   unixOrZero(activity.LastInput),
+			Status:      activity.Status,
+			State:       activity.State,
+			Viewers:     activity.Viewers,
+			Writable:    m.writable[sess.SessionName],
+			Windows:     m.windowInfos(sess.SessionName),
+			Agent:       m.sessionAgents[sess.SessionName],
+			Decision:    m.decisions[sess.SessionName],
 		})
 	}
+	sort.Slice(sessionInfos, func(i, j int) bool {
+		return sessionInfos[i].SessionName < sessionInfos[j].SessionName
+	})
+	return sessionInfos
+}
 
-	m.sessionHub.BroadcastSessions(sessionInfos)
+// WindowInfos returns the windows of a session with process stats for each pane
+func (m *Manager) WindowInfos(sessionName string) []ws.WindowInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.windowInfos(sessionName)
 }
 
-// shouldAutoAttach checks if a session name matches auto-attach patterns
-// Currently returns true for ALL sessions (no filtering)
+// windowInfos converts a session's windows to their broadcast representation.
+// The caller must hold m.mu.
+func (m *Manager) windowInfos(sessionName string) []ws.WindowInfo {
+	windows := m.windows[sessionName]
+	infos := make([]ws.WindowInfo, 0, len(windows))
+	for _, w := range windows {
+		panes := make([]ws.PaneInfo, 0, len(w.Panes))
+		for _, p := range w.Panes {
+			panes = append(panes, ws.PaneInfo{
+				ID:      p.ID,
+				Index:   p.Index,
+				Active:  p.Active,
+				Command: p.Command,
+				PID:     p.PID,
+				Stats:   m.paneStats[p.ID],
+			})
+		}
+		infos = append(infos, ws.WindowInfo{
+			ID:     w.ID,
+			Index:  w.Index,
+			Name:   w.Name,
+			Active: w.Active,
+			Panes:  panes,
+		})
+	}
+	return infos
+}
+
+// StartProcessSampling samples the process tree of every pane at the given
+// interval and broadcasts the results. A zero interval disables sampling.
+func (m *Manager) StartProcessSampling(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	sampler := procinfo.NewSampler()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			m.sampleProcesses(sampler)
+			select {
+			case <-m.stopDiscovery:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sampleProcesses takes one process snapshot and updates the stats of all known panes
+func (m *Manager) sampleProcesses(sampler *procinfo.Sampler) {
+	snapshot, err := sampler.Sample()
+	if err != nil {
+		log.Printf("Process sampling failed: %v", err)
+		return
+	}
+
+	m.mu.Lock()
+	stats := make(map[string]*procinfo.PaneStats)
+	for _, windows := range m.windows {
+		for _, w := range windows {
+			for _, p := range w.Panes {
+				if s := snapshot.PaneStats(p.PID); s != nil {
+					stats[p.ID] = s
+				}
+			}
+		}
+	}
+	m.paneStats = stats
+	m.mu.Unlock()
+
+	m.broadcastSessions()
+}
+
+// broadcastSessions sends the current session list to all connected WebSocket clients
+func (m *Manager) broadcastSessions() {
+	if m.sessionHub != nil {
+		m.sessionHub.BroadcastSessions(m.SessionInfos())
+	}
+}
+
+// ClientAttached records that a web terminal attached to a session and
+// returns the session's ID, or "" if the session is not tracked
+func (m *Manager) ClientAttached(sessionName string) string {
+	sess, ok := m.GetSessionByName(sessionName)
+	if !ok {
+		return ""
+	}
+	sess.AddViewer()
+	return sess.ID
+}
+
+// ClientDetached records that a web terminal detached from a session
+func (m *Manager) ClientDetached(sessionID string) {
+	if sess, ok := m.GetSession(sessionID); ok {
+		sess.RemoveViewer()
+	}
+}
+
+// RecordClientInput records input a web terminal wrote to a session's PTY
+func (m *Manager) RecordClientInput(sessionID string) {
+	if sess, ok := m.GetSession(sessionID); ok {
+		sess.RecordInput(time.Now())
+	}
+}
+
+// RecordInput records input sent to a session through the API
+func (m *Manager) RecordInput(sessionName string) {
+	if sess, ok := m.GetSessionByName(sessionName); ok {
+		sess.RecordInput(time.Now())
+	}
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// shouldAutoAttach checks if a session name matches one of the auto-attach patterns
 func (m *Manager) shouldAutoAttach(sessionName string) bool {
-	return true
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, pattern := range m.autoAttachPatterns {
+		if ok, _ := path.Match(pattern, sessionName); ok {
+			return true
+		}
+	}
+	return false
 }
 
 // Stop stops the discovery loop
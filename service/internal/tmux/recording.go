@@ -0,0 +1,88 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ibrahim/remote-vibecode/internal/paths"
+)
+
+var (
+	recordingMu  sync.RWMutex
+	recordingDir string
+)
+
+// SetRecordingDir sets where new recordings are written. An empty dir
+// restores the default, the state directory's recordings/ folder.
+func SetRecordingDir(dir string) {
+	recordingMu.Lock()
+	defer recordingMu.Unlock()
+	recordingDir = dir
+}
+
+// RecordingDir returns the directory new recordings are written to
+func RecordingDir() (string, error) {
+	recordingMu.RLock()
+	dir := recordingDir
+	recordingMu.RUnlock()
+	if dir != "" {
+		return dir, nil
+	}
+	return paths.StateFile("recordings")
+}
+
+// StartRecording appends everything the session's active pane prints to a log
+// in the recording directory (see SetRecordingDir) and returns its path.
+// Recording an already recorded session returns the existing path.
+func StartRecording(sessionName string) (string, error) {
+	if !IsValidSessionName(sessionName) {
+		return "", ErrInvalidSessionName
+	}
+	if current, err := Recording(sessionName); err != nil || current != "" {
+		return current, err
+	}
+
+	dir, err := RecordingDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.log", sessionName, time.Now().Format("20060102-150405")))
+
+	// pipe-pane runs its command through the shell, so the path is single-quoted
+	quoted := "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+	if err := runTarget(sessionName, "pipe-pane", "-o", "cat >> "+quoted); err != nil {
+		return "", err
+	}
+	if err := runTarget(sessionName, "set-option", "@rvc-recording", path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// StopRecording stops the recording of a session, if any
+func StopRecording(sessionName string) error {
+	if err := runTarget(sessionName, "pipe-pane"); err != nil {
+		return err
+	}
+	return runTarget(sessionName, "set-option", "-u", "@rvc-recording")
+}
+
+// Recording returns the log file a session is being recorded to, or ""
+func Recording(sessionName string) (string, error) {
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return "", err
+	}
+	output, err := c.Command("show-option", "-t", name, "-qv", "@rvc-recording").Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to read @rvc-recording: %v", ErrCommandFailed, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,142 @@
+package tmux
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
+)
+
+// Trigger is a rule evaluated against a session's screen on every scan.
+// When Match starts matching something new, Keys are sent to the session
+// (writable sessions only) and, if Notify is set, an EventTrigger is emitted.
+type Trigger struct {
+	Name   string   `json:"name" yaml:"name"`
+	Match  string   `json:"match" yaml:"match"`
+	Keys   []string `json:"keys,omitempty" yaml:"keys"`
+	Notify bool     `json:"notify,omitempty" yaml:"notify"`
+}
+
+// Validate checks that a trigger has a usable pattern and something to do
+func (t Trigger) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("%w: trigger needs a name", ErrInvalidOptions)
+	}
+	if _, err := regexp.Compile(t.Match); err != nil || t.Match == "" {
+		return fmt.Errorf("%w: trigger %s: invalid match pattern: %v", ErrInvalidOptions, t.Name, err)
+	}
+	if len(t.Keys) == 0 && !t.Notify {
+		return fmt.Errorf("%w: trigger %s: needs keys or notify", ErrInvalidOptions, t.Name)
+	}
+	for _, key := range t.Keys {
+		if !IsAllowedKey(key) {
+			return fmt.Errorf("%w: trigger %s: invalid key %q", ErrInvalidOptions, t.Name, key)
+		}
+	}
+	return nil
+}
+
+// ListSessionTriggers returns the triggers configured for each session
+// (the @rvc-triggers user option), omitting sessions without any
+func ListSessionTriggers() (map[string][]Trigger, error) {
+	result := make(map[string][]Trigger)
+	err := eachServer(func(label, output string) {
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			name, value, ok := strings.Cut(line, fieldSep)
+			if !ok || value == "" {
+				continue
+			}
+			name = tmuxclient.Qualify(name, label)
+			var triggers []Trigger
+			if err := json.Unmarshal([]byte(value), &triggers); err != nil {
+				log.Printf("Ignoring invalid @rvc-triggers on %s: %v", name, err)
+				continue
+			}
+			result[name] = triggers
+		}
+	}, "list-sessions", "-F", formatFields("#{session_name}", "#{@rvc-triggers}"))
+	if err != nil {
+		return nil, fmt.Errorf("list-sessions failed: %w", err)
+	}
+	return result, nil
+}
+
+// SetTriggers stores the triggers of a session. An empty list removes them.
+func SetTriggers(sessionName string, triggers []Trigger) error {
+	if len(triggers) == 0 {
+		return runTarget(sessionName, "set-option", "-u", "@rvc-triggers")
+	}
+	for _, t := range triggers {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(triggers)
+	if err != nil {
+		return err
+	}
+	return runTarget(sessionName, "set-option", "@rvc-triggers", string(data))
+}
+
+// updateTriggers evaluates the triggers of all tracked sessions against their
+// active pane. A trigger fires once per distinct match: it fires again only
+// after the matched text changes or leaves the screen.
+func (m *Manager) updateTriggers() {
+	triggers, err := ListSessionTriggers()
+	if err != nil {
+		triggers = map[string][]Trigger{}
+	}
+
+	matches := make(map[string]map[string]string)
+	var fired []SessionEvent
+	for sessionName, rules := range triggers {
+		if _, tracked := m.GetSessionByName(sessionName); !tracked {
+			continue
+		}
+		screen, err := CapturePane(sessionName)
+		if err != nil {
+			continue
+		}
+
+		m.mu.RLock()
+		prev := m.triggerMatches[sessionName]
+		m.mu.RUnlock()
+
+		current := make(map[string]string)
+		for _, t := range rules {
+			re, err := regexp.Compile(t.Match)
+			if err != nil {
+				continue
+			}
+			match := re.FindString(screen)
+			if match == "" {
+				continue
+			}
+			current[t.Name] = match
+			if prev[t.Name] == match {
+				continue
+			}
+
+			if len(t.Keys) > 0 && IsWritable(sessionName) {
+				if err := SendKeys(sessionName, t.Keys...); err != nil {
+					log.Printf("Trigger %s on %s failed to send keys: %v", t.Name, sessionName, err)
+				}
+			}
+			if t.Notify {
+				fired = append(fired, SessionEvent{SessionName: sessionName, Detail: t.Name + ": " + strings.TrimSpace(match)})
+			}
+		}
+		matches[sessionName] = current
+	}
+
+	m.mu.Lock()
+	m.triggerMatches = matches
+	m.mu.Unlock()
+
+	for _, ev := range fired {
+		m.emit(EventTrigger, ev.SessionName, ev.Detail)
+	}
+}
@@ -0,0 +1,394 @@
+package tmux
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
+)
+
+// Window is a tmux window and its panes
+type Window struct {
+	ID     string `json:"id"` // e.g. "@1", or "@1@label" on a labelled server
+	Index  int    `json:"index"`
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+	Layout string `json:"layout"`
+	Panes  []Pane `json:"panes"`
+}
+
+// Pane is a tmux pane
+type Pane struct {
+	ID      string `json:"id"` // e.g. "%3", or "%3@label" on a labelled server
+	Index   int    `json:"index"`
+	Active  bool   `json:"active"`
+	Command string `json:"command"`
+	Path    string `json:"path"`
+	PID     int    `json:"pid"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+}
+
+// WindowOptions describes a window to create with NewWindow
+type WindowOptions struct {
+	Name    string
+	Command string
+	Cwd     string
+	Select  bool // make the new window the current one
+}
+
+// SplitOptions describes a pane to create with SplitWindow
+type SplitOptions struct {
+	Horizontal bool // split left/right instead of top/bottom
+	Size       int  // size of the new pane in percent (0 = half)
+	Command    string
+	Cwd        string
+}
+
+// paneFormat lists every window and pane field parsed by parsePanes
+var paneFormat = formatFields("#{session_name}", "#{window_id}", "#{window_index}", "#{window_name}", "#{window_active}", "#{window_layout}",
+	"#{pane_id}", "#{pane_index}", "#{pane_active}", "#{pane_current_command}", "#{pane_current_path}", "#{pane_pid}", "#{pane_width}", "#{pane_height}")
+
+var (
+	windowIndexPattern = regexp.MustCompile(`^[0-9]+$`)
+	windowIDPattern    = regexp.MustCompile(`^@[0-9]+(@[A-Za-z0-9_-]{1,64})?$`)
+	windowNamePattern  = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+)
+
+// ListWindows returns the windows of a session together with their panes
+func ListWindows(sessionName string) ([]Window, error) {
+	if !IsValidSessionName(sessionName) {
+		return nil, ErrInvalidSessionName
+	}
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.Command("list-panes", "-s", "-t", name, "-F", paneFormat).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: list-panes failed: %v", ErrCommandFailed, err)
+	}
+	_, label := tmuxclient.Split(sessionName)
+	windows := parsePanes(string(output), label)[sessionName]
+	if windows == nil {
+		windows = []Window{}
+	}
+	return windows, nil
+}
+
+// ListAllWindows returns the windows of every session, keyed by session name,
+// using a single tmux call per watched server
+func ListAllWindows() (map[string][]Window, error) {
+	result := make(map[string][]Window)
+	err := eachServer(func(label, output string) {
+		for name, windows := range parsePanes(output, label) {
+			result[name] = windows
+		}
+	}, "list-panes", "-a", "-F", paneFormat)
+	if err != nil {
+		return nil, fmt.Errorf("%w: list-panes failed: %v", ErrCommandFailed, err)
+	}
+	return result, nil
+}
+
+// ListPanes returns the panes of a window target (see WindowTarget)
+func ListPanes(target string) ([]Pane, error) {
+	c, t, err := resolve(target)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.Command("list-panes", "-t", t, "-F", paneFormat).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: list-panes failed: %v", ErrCommandFailed, err)
+	}
+	_, label := tmuxclient.Split(target)
+	for _, windows := range parsePanes(string(output), label) {
+		if len(windows) > 0 {
+			return windows[0].Panes, nil
+		}
+	}
+	return []Pane{}, nil
+}
+
+// NewWindow creates a window in a session and returns it
+func NewWindow(sessionName string, opts WindowOptions) (*Window, error) {
+	if !IsValidSessionName(sessionName) {
+		return nil, ErrInvalidSessionName
+	}
+
+	c, name, err := resolve(sessionName)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"new-window", "-P", "-F", "#{window_index}", "-t", name + ":"}
+	if !opts.Select {
+		args = append(args, "-d")
+	}
+	if opts.Name != "" {
+		if !windowNamePattern.MatchString(opts.Name) {
+			return nil, fmt.Errorf("%w: invalid window name %q", ErrInvalidOptions, opts.Name)
+		}
+		args = append(args, "-n", opts.Name)
+	}
+	if opts.Cwd != "" {
+		cwd, err := ResolveDir(opts.Cwd)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-c", cwd)
+	}
+	if opts.Command != "" {
+		args = append(args, opts.Command)
+	}
+
+	output, err := c.Command(args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%w: new-window failed: %v\nOutput: %s", ErrCommandFailed, err, string(output))
+	}
+
+	index := strings.TrimSpace(string(output))
+	windows, err := ListWindows(sessionName)
+	if err != nil {
+		return nil, err
+	}
+	for i := range windows {
+		if strconv.Itoa(windows[i].Index) == index {
+			return &windows[i], nil
+		}
+	}
+	return nil, ErrWindowNotFound
+}
+
+// SplitWindow splits a pane target and returns the new pane
+func SplitWindow(target string, opts SplitOptions) (*Pane, error) {
+	c, t, err := resolve(target)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"split-window", "-d", "-P", "-F", "#{pane_id}", "-t", t}
+	if opts.Horizontal {
+		args = append(args, "-h")
+	} else {
+		args = append(args, "-v")
+	}
+	if opts.Size > 0 {
+		if opts.Size >= 100 {
+			return nil, fmt.Errorf("%w: size must be a percentage below 100", ErrInvalidOptions)
+		}
+		args = append(args, "-l", strconv.Itoa(opts.Size)+"%")
+	}
+	if opts.Cwd != "" {
+		cwd, err := ResolveDir(opts.Cwd)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-c", cwd)
+	}
+	if opts.Command != "" {
+		args = append(args, opts.Command)
+	}
+
+	output, err := c.Command(args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%w: split-window failed: %v\nOutput: %s", ErrCommandFailed, err, string(output))
+	}
+
+	_, label := tmuxclient.Split(target)
+	paneID := tmuxclient.Qualify(strings.TrimSpace(string(output)), label)
+	panes, err := ListPanes(paneID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range panes {
+		if panes[i].ID == paneID {
+			return &panes[i], nil
+		}
+	}
+	return nil, ErrPaneNotFound
+}
+
+// SelectWindow makes a window target the current window of its session
+func SelectWindow(target string) error {
+	return runTarget(target, "select-window")
+}
+
+// SelectPane makes a pane target the active pane of its window
+func SelectPane(target string) error {
+	return runTarget(target, "select-pane")
+}
+
+// KillPane destroys a pane; killing the last pane also closes its window
+func KillPane(target string) error {
+	return runTarget(target, "kill-pane")
+}
+
+// PaneCurrentPath returns the working directory of the program in a pane target
+// (see PaneTarget)
+func PaneCurrentPath(target string) (string, error) {
+	path := describe(target, "#{pane_current_path}")
+	if path == "" {
+		return "", fmt.Errorf("%w: no working directory for %s", ErrCommandFailed, target)
+	}
+	return path, nil
+}
+
+// WindowTarget builds a "session:index" target for a window given by index or ID
+func WindowTarget(sessionName, window string) (string, error) {
+	if !IsValidSessionName(sessionName) {
+		return "", ErrInvalidSessionName
+	}
+
+	bareName, label := tmuxclient.Split(sessionName)
+	switch {
+	case windowIndexPattern.MatchString(window):
+		target := sessionName + ":" + window
+		if describe(target, "#{session_name}:#{window_index}") != bareName+":"+window {
+			return "", ErrWindowNotFound
+		}
+		return target, nil
+	case windowIDPattern.MatchString(window):
+		// Window IDs are global to their tmux server, so an ID without a
+		// label belongs to the session's server
+		bareID, idLabel := tmuxclient.Split(window)
+		if idLabel == "" {
+			idLabel = label
+		}
+		fields := splitFields(describe(tmuxclient.Qualify(bareID, idLabel), formatFields("#{window_id}", "#{session_name}", "#{window_index}")))
+		if idLabel != label || len(fields) != 3 || fields[0] != bareID || fields[1] != bareName {
+			return "", ErrWindowNotFound
+		}
+		return sessionName + ":" + fields[2], nil
+	default:
+		return "", ErrInvalidWindow
+	}
+}
+
+// WindowPaneTarget builds a target for a pane (index or "%id") inside a window target
+func WindowPaneTarget(sessionName, windowTarget, pane string) (string, error) {
+	switch {
+	case windowIndexPattern.MatchString(pane):
+		target := windowTarget + "." + pane
+		if bareTarget, _ := tmuxclient.Split(target); describe(target, "#{session_name}:#{window_index}.#{pane_index}") != bareTarget {
+			return "", ErrPaneNotFound
+		}
+		return target, nil
+	case paneIDPattern.MatchString(pane):
+		return PaneTarget(sessionName, pane)
+	default:
+		return "", ErrInvalidPane
+	}
+}
+
+// describe expands a format for a target on the target's server. display-message
+// falls back to the current window or pane when the target does not exist, so
+// callers compare the result with what they asked for, without the label.
+func describe(target, format string) string {
+	c, t, err := resolve(target)
+	if err != nil {
+		return ""
+	}
+	output, err := c.Command("display-message", "-p", "-t", t, format).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// parsePanes groups paneFormat lines of the server with label into windows per
+// session, preserving tmux order. Session names and IDs are qualified with label.
+func parsePanes(output, label string) map[string][]Window {
+	result := make(map[string][]Window)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		f := splitFields(line)
+		if len(f) != 14 {
+			continue
+		}
+		sessionName := tmuxclient.Qualify(f[0], label)
+		windowID := tmuxclient.Qualify(f[1], label)
+		windows := result[sessionName]
+		if len(windows) == 0 || windows[len(windows)-1].ID != windowID {
+			windows = append(windows, Window{
+				ID:     windowID,
+				Index:  atoi(f[2]),
+				Name:   f[3],
+				Active: f[4] == "1",
+				Layout: f[5],
+				Panes:  []Pane{},
+			})
+		}
+		w := &windows[len(windows)-1]
+		w.Panes = append(w.Panes, Pane{
+			ID:      tmuxclient.Qualify(f[6], label),
+			Index:   atoi(f[7]),
+			Active:  f[8] == "1",
+			Command: f[9],
+			Path:    f[10],
+			PID:     atoi(f[11]),
+			Width:   atoi(f[12]),
+			Height:  atoi(f[13]),
+		})
+		result[sessionName] = windows
+	}
+	return result
+}
+
+// runTmux runs a tmux command on a server, wrapping failures in ErrCommandFailed
+func runTmux(c tmuxclient.Client, args ...string) error {
+	output, err := c.Command(args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s failed: %v\nOutput: %s", ErrCommandFailed, args[0], err, string(output))
+	}
+	return nil
+}
+
+// runTarget runs a tmux command with "-t target" and further args on the
+// target's server
+func runTarget(target, command string, args ...string) error {
+	c, t, err := resolve(target)
+	if err != nil {
+		return err
+	}
+	return runTmux(c, append([]string{command, "-t", t}, args...)...)
+}
+
+// resolve returns the tmux server of a name or target and the target as that
+// server knows it
+func resolve(target string) (tmuxclient.Client, string, error) {
+	c, t, err := tmuxclient.Resolve(target)
+	if err != nil {
+		return c, "", fmt.Errorf("%w: %v", ErrServerNotFound, err)
+	}
+	return c, t, nil
+}
+
+// eachServer runs a tmux command on every watched server and passes each
+// output to fn with the server's label. Servers where the command fails,
+// usually because no tmux runs there, are skipped; the first error is only
+// returned when the command failed everywhere.
+func eachServer(fn func(label, output string), args ...string) error {
+	var firstErr error
+	ok := false
+	for _, s := range tmuxclient.Servers() {
+		output, err := s.Command(args...).Output()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		ok = true
+		fn(s.Label, string(output))
+	}
+	if !ok {
+		return firstErr
+	}
+	return nil
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
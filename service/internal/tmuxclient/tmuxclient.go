@@ -0,0 +1,168 @@
+// Package tmuxclient runs tmux commands against a tmux server. Besides the
+// default server, rvc can watch servers on other sockets (tmux -L or -S).
+// Outside tmux, in session lists, URLs and CLI arguments, sessions on those
+// servers are named "session@label" and their window and pane IDs "@3@label"
+// and "%5@label", where label names the server.
+package tmuxclient
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Client selects the tmux server that commands run against. The zero value
+// is tmux's default server.
+type Client struct {
+	SocketName string // tmux -L: a socket in tmux's socket directory
+	SocketPath string // tmux -S: a socket path; takes precedence over SocketName
+}
+
+// Args returns the tmux options that select the server
+func (c Client) Args() []string {
+	switch {
+	case c.SocketPath != "":
+		return []string{"-S", c.SocketPath}
+	case c.SocketName != "":
+		return []string{"-L", c.SocketName}
+	default:
+		return nil
+	}
+}
+
+// Command returns a tmux command for the server. It runs tmux with -u: for
+// clients whose locale is not UTF-8, tmux replaces tabs and other control
+// characters in the output of -F formats with "_", which breaks parsing.
+func (c Client) Command(args ...string) *exec.Cmd {
+	return exec.Command("tmux", append(append([]string{"-u"}, c.Args()...), args...)...)
+}
+
+// TerminalCommand returns a tmux command for the server that leaves the
+// encoding to the locale, for clients that attach the user's own terminal
+func (c Client) TerminalCommand(args ...string) *exec.Cmd {
+	return exec.Command("tmux", append(c.Args(), args...)...)
+}
+
+// String describes the server for messages
+func (c Client) String() string {
+	switch {
+	case c.SocketPath != "":
+		return "tmux -S " + c.SocketPath
+	case c.SocketName != "":
+		return "tmux -L " + c.SocketName
+	default:
+		return "the default tmux server"
+	}
+}
+
+// Server is a watched tmux server and the label that names its sessions
+type Server struct {
+	Label string // empty for the default server
+	Client
+}
+
+// labelPattern matches server labels; like session names, they are safe to
+// pass to tmux and to put in URLs
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// IsValidLabel checks that a server label may be used in session names
+func IsValidLabel(label string) bool {
+	return labelPattern.MatchString(label)
+}
+
+var (
+	mu            sync.RWMutex
+	defaultClient Client
+	servers       []Server // labelled servers, in configuration order
+)
+
+// SetDefault sets the server of unlabelled names, e.g. from the CLI's -L or
+// -S option
+func SetDefault(c Client) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultClient = c
+}
+
+// Default returns the server of unlabelled names
+func Default() Client {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultClient
+}
+
+// SetServers replaces the labelled servers watched besides the default one.
+// Labels must be valid and distinct.
+func SetServers(labelled []Server) {
+	mu.Lock()
+	defer mu.Unlock()
+	servers = append([]Server(nil), labelled...)
+}
+
+// Servers returns the default server followed by the labelled ones. A
+// labelled server that is also the default one is only listed as the default.
+func Servers() []Server {
+	mu.RLock()
+	defer mu.RUnlock()
+	result := []Server{{Client: defaultClient}}
+	for _, s := range servers {
+		if s.Client != defaultClient {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// Qualify names a session, window or pane ID of the server with label, e.g.
+// Qualify("api", "work") is "api@work". The default server's names are
+// returned unchanged.
+func Qualify(name, label string) string {
+	if label == "" {
+		return name
+	}
+	return name + "@" + label
+}
+
+// Split separates the server label from a name or target, e.g. "api@work:1.0"
+// gives "api:1.0" and "work", and "%5@work" gives "%5" and "work". The label
+// follows the first component; a leading "@" starts a window ID, not a label.
+func Split(target string) (string, string) {
+	at := strings.IndexByte(target[min(1, len(target)):], '@') + 1
+	if at == 0 || strings.ContainsAny(target[:at], ":.") {
+		return target, ""
+	}
+	end := strings.IndexAny(target[at+1:], ":.")
+	if end < 0 {
+		return target[:at], target[at+1:]
+	}
+	end += at + 1
+	return target[:at] + target[end:], target[at+1 : end]
+}
+
+// Resolve returns the server of a name or target and the target as that
+// server knows it, without the label
+func Resolve(target string) (Client, string, error) {
+	rest, label := Split(target)
+	c, err := Lookup(label)
+	if err != nil {
+		return c, "", err
+	}
+	return c, rest, nil
+}
+
+// Lookup returns the server with label; the empty label is the default server
+func Lookup(label string) (Client, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if label == "" {
+		return defaultClient, nil
+	}
+	for _, s := range servers {
+		if s.Label == label {
+			return s.Client, nil
+		}
+	}
+	return Client{}, fmt.Errorf("no tmux server is labelled %q", label)
+}
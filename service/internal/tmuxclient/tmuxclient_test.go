@@ -0,0 +1,64 @@
+package tmuxclient
+
+import "testing"
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		target string
+		rest   string
+		label  string
+	}{
+		{"", "", ""},
+		{"api", "api", ""},
+		{"api:1.0", "api:1.0", ""},
+		{"api@work", "api", "work"},
+		{"api@work:1", "api:1", "work"},
+		{"api@work:1.0", "api:1.0", "work"},
+		{"api@work.2", "api.2", "work"},
+		{"%5", "%5", ""},
+		{"%5@work", "%5", "work"},
+		{"@", "@", ""},
+		{"@3", "@3", ""},
+		{"@3@work", "@3", "work"},
+		{"@3@work.1", "@3.1", "work"},
+		{"api:1@work", "api:1@work", ""}, // the label must follow the session
+		{"api.0@work", "api.0@work", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rest, label := Split(tt.target)
+			if rest != tt.rest || label != tt.label {
+				t.Errorf("Split(%q) = %q, %q, want %q, %q", tt.target, rest, label, tt.rest, tt.label)
+			}
+		})
+	}
+}
+
+func TestSplitQualify(t *testing.T) {
+	for _, name := range []string{"api", "%5", "@3"} {
+		for _, label := range []string{"", "work"} {
+			rest, got := Split(Qualify(name, label))
+			if rest != name || got != label {
+				t.Errorf("Split(Qualify(%q, %q)) = %q, %q", name, label, rest, got)
+			}
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	work := Client{SocketName: "work"}
+	SetServers([]Server{{Label: "work", Client: work}})
+	t.Cleanup(func() { SetServers(nil) })
+
+	c, rest, err := Resolve("api@work:1.0")
+	if err != nil || c != work || rest != "api:1.0" {
+		t.Errorf("Resolve = %v, %q, %v, want %v, \"api:1.0\"", c, rest, err, work)
+	}
+	c, rest, err = Resolve("api:1.0")
+	if err != nil || c != Default() || rest != "api:1.0" {
+		t.Errorf("Resolve = %v, %q, %v, want the default server", c, rest, err)
+	}
+	if _, _, err := Resolve("api@home"); err == nil {
+		t.Error("Resolve of an unknown label succeeded")
+	}
+}
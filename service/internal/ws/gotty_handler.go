@@ -5,7 +5,7 @@ import (
 	"encoding/base64"
 	"log"
 	"net/http"
-	"os/exec"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/ibrahim/remote-vibecode/internal/gotty"
+	"github.com/ibrahim/remote-vibecode/internal/tmuxclient"
 )
 
 var gottyUpgrader = websocket.Upgrader{
@@ -30,20 +31,50 @@ const (
 	gottyPing   = '2'
 	gottyPong   = '3'
 	gottyResize = '4'
+	// gottyClipboard carries base64 text a program copied with OSC 52
+	gottyClipboard = '5'
 )
 
+// ActivityRecorder receives terminal activity observed on gotty connections.
+// Connections refer to their session by the ID ClientAttached returns, which
+// stays the same when the session is renamed.
+type ActivityRecorder interface {
+	ClientAttached(sessionName string) (sessionID string)
+	ClientDetached(sessionID string)
+	RecordClientInput(sessionID string)
+}
+
 // GottyHandler handles gotty WebSocket connections for terminal sharing
 type GottyHandler struct {
-	gottyMgr *gotty.Manager
+	gottyMgr  *gotty.Manager
+	activity  ActivityRecorder
+	mu        sync.RWMutex
+	clipboard ClipboardPolicy
 }
 
 // NewGottyHandler creates a new gotty WebSocket handler
-func NewGottyHandler(gottyMgr *gotty.Manager) *GottyHandler {
+func NewGottyHandler(gottyMgr *gotty.Manager, activity ActivityRecorder, clipboard ClipboardPolicy) *GottyHandler {
 	return &GottyHandler{
-		gottyMgr: gottyMgr,
+		gottyMgr:  gottyMgr,
+		activity:  activity,
+		clipboard: clipboard,
 	}
 }
 
+// SetClipboardPolicy changes the clipboard policy for new connections
+func (h *GottyHandler) SetClipboardPolicy(policy ClipboardPolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clipboard = policy
+}
+
+// clipboardPolicy returns the current clipboard policy
+func (h *GottyHandler) clipboardPolicy() ClipboardPolicy {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.clipboard
+}
+
 // HandleTmuxSession handles WebSocket connection for a tmux session using gotty protocol
 // GET /gotty/:tmux_session
 func (h *GottyHandler) HandleTmuxSession(c *gin.Context) {
@@ -79,6 +110,9 @@ func (h *GottyHandler) HandleTmuxSession(c *gin.Context) {
 	}
 	log.Printf("Gotty session created: %s -> tmux:%s (%s)", sessionID[:8], tmuxSessionName, writeMode)
 
+	trackedID := h.activity.ClientAttached(tmuxSessionName)
+	defer h.activity.ClientDetached(trackedID)
+
 	// Start bidirectional streaming
 	var wg sync.WaitGroup
 	wg.Add(2)
@@ -86,12 +120,23 @@ func (h *GottyHandler) HandleTmuxSession(c *gin.Context) {
 	// PTY -> WebSocket (output)
 	go func() {
 		defer wg.Done()
+		// Once tmux detaches (e.g. the session ended), close the connection so the input loop ends too
+		defer conn.Close()
 		buf := make([]byte, 4096)
+		var clipboard *osc52Scanner
+		if h.clipboardPolicy().Allows(isWritable) {
+			clipboard = &osc52Scanner{}
+		}
 		for {
 			n, err := session.Read(buf)
 			if err != nil {
 				if !session.IsClosed() {
 					log.Printf("PTY read error: %v", err)
+					if !sessionExists(tmuxSessionName) {
+						// Tell the client the session is gone rather than the connection
+						msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session ended")
+						_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+					}
 				}
 				break
 			}
@@ -106,12 +151,24 @@ func (h *GottyHandler) HandleTmuxSession(c *gin.Context) {
 				log.Printf("WebSocket write error: %v", err)
 				break
 			}
+
+			if clipboard != nil {
+				for _, text := range clipboard.Scan(buf[:n]) {
+					msg := append([]byte{gottyClipboard}, base64.StdEncoding.EncodeToString([]byte(text))...)
+					if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+						log.Printf("WebSocket write error: %v", err)
+						return
+					}
+				}
+			}
 		}
 	}()
 
 	// WebSocket -> PTY (input)
 	go func() {
 		defer wg.Done()
+		// Once the client is gone, close the PTY so the output loop ends too
+		defer session.Close()
 		_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 		conn.SetPongHandler(func(string) error {
 			_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
@@ -126,6 +183,8 @@ func (h *GottyHandler) HandleTmuxSession(c *gin.Context) {
 				}
 				break
 			}
+			// Any message (including the client's gotty pings) keeps the connection alive
+			_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 
 			if messageType == websocket.TextMessage && len(data) > 0 {
 				switch data[0] {
@@ -140,6 +199,7 @@ func (h *GottyHandler) HandleTmuxSession(c *gin.Context) {
 						log.Printf("PTY write error: %v", err)
 						break
 					}
+					h.activity.RecordClientInput(trackedID)
 
 				case gottyPing:
 					// Respond with pong
@@ -147,8 +207,14 @@ func (h *GottyHandler) HandleTmuxSession(c *gin.Context) {
 
 				case gottyResize:
 					// Resize request - format: columns,rows (as ASCII)
-					// For now, ignore
-					log.Printf("Resize request: %v", string(data[1:]))
+					cols, rows, ok := parseResize(string(data[1:]))
+					if !ok {
+						log.Printf("Invalid resize request: %q", data[1:])
+						break
+					}
+					if err := session.Resize(cols, rows); err != nil {
+						log.Printf("PTY resize error: %v", err)
+					}
 				}
 			}
 		}
@@ -164,9 +230,39 @@ func (h *GottyHandler) HandleTmuxSession(c *gin.Context) {
 	log.Printf("Gotty session closed: %s", sessionID[:8])
 }
 
+// parseResize parses a "columns,rows" resize payload
+func parseResize(payload string) (uint16, uint16, bool) {
+	colsText, rowsText, ok := strings.Cut(payload, ",")
+	if !ok {
+		return 0, 0, false
+	}
+	cols, err := strconv.ParseUint(strings.TrimSpace(colsText), 10, 16)
+	if err != nil || cols == 0 {
+		return 0, 0, false
+	}
+	rows, err := strconv.ParseUint(strings.TrimSpace(rowsText), 10, 16)
+	if err != nil || rows == 0 {
+		return 0, 0, false
+	}
+	return uint16(cols), uint16(rows), true
+}
+
+// sessionExists checks whether a tmux session still exists
+func sessionExists(sessionName string) bool {
+	c, name, err := tmuxclient.Resolve(sessionName)
+	if err != nil {
+		return false
+	}
+	return c.Command("has-session", "-t", name).Run() == nil
+}
+
 // isSessionWritable checks if a session is writable using tmux user-options
 func isSessionWritable(sessionName string) bool {
-	cmd := exec.Command("tmux", "show-option", "-t", sessionName, "-qv", "@rvc-writable")
+	c, name, err := tmuxclient.Resolve(sessionName)
+	if err != nil {
+		return false
+	}
+	cmd := c.Command("show-option", "-t", name, "-qv", "@rvc-writable")
 	output, err := cmd.Output()
 	if err != nil {
 		return false // Not set = read-only
@@ -0,0 +1,167 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// ClipboardPolicy controls whether OSC 52 clipboard writes from a session are
+// forwarded to connected browsers
+type ClipboardPolicy string
+
+const (
+	// ClipboardOff never forwards clipboard writes
+	ClipboardOff ClipboardPolicy = "off"
+	// ClipboardWritable forwards clipboard writes only for writable sessions
+	ClipboardWritable ClipboardPolicy = "writable"
+	// ClipboardOn forwards clipboard writes for every session
+	ClipboardOn ClipboardPolicy = "on"
+)
+
+// ParseClipboardPolicy validates a policy name given on the command line
+func ParseClipboardPolicy(s string) (ClipboardPolicy, error) {
+	switch p := ClipboardPolicy(strings.ToLower(s)); p {
+	case ClipboardOff, ClipboardWritable, ClipboardOn:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid clipboard policy %q (want off, writable or on)", s)
+	}
+}
+
+// Allows reports whether clipboard writes are forwarded for a session
+func (p ClipboardPolicy) Allows(writable bool) bool {
+	return p == ClipboardOn || (p == ClipboardWritable && writable)
+}
+
+// MaxClipboardBytes caps the encoded size of a single OSC 52 sequence;
+// larger sequences are dropped
+const MaxClipboardBytes = 1 << 20
+
+type oscState int
+
+const (
+	oscGround  oscState = iota
+	oscEscape           // saw ESC
+	oscBody             // inside ESC ] ... collecting
+	oscBodyEsc          // saw ESC inside the body, expecting '\' (ST)
+	oscSkip             // inside an OSC we don't care about, or one that is too large
+	oscSkipEsc
+)
+
+// osc52Scanner extracts OSC 52 clipboard writes (ESC ] 52 ; Pc ; Pd BEL|ST)
+// from terminal output. PTY reads can split a sequence anywhere, so the
+// scanner keeps its state between calls.
+type osc52Scanner struct {
+	state oscState
+	buf   []byte
+}
+
+var osc52Prefix = []byte("52;")
+
+// Scan consumes a chunk of output and returns the text of every clipboard
+// write completed within it. Clipboard queries ("?") are ignored.
+func (s *osc52Scanner) Scan(data []byte) []string {
+	var texts []string
+	for _, b := range data {
+		switch s.state {
+		case oscGround:
+			if b == 0x1b {
+				s.state = oscEscape
+			}
+		case oscEscape:
+			switch b {
+			case ']':
+				s.state = oscBody
+				s.buf = s.buf[:0]
+			case 0x1b:
+				// stay in oscEscape
+			default:
+				s.state = oscGround
+			}
+		case oscBody:
+			switch b {
+			case 0x07:
+				if text, ok := decodeOSC52(s.buf); ok {
+					texts = append(texts, text)
+				}
+				s.state = oscGround
+			case 0x1b:
+				s.state = oscBodyEsc
+			default:
+				s.buf = append(s.buf, b)
+				if !isOSC52Prefix(s.buf) || len(s.buf) > MaxClipboardBytes {
+					s.state = oscSkip
+					s.buf = s.buf[:0]
+				}
+			}
+		case oscBodyEsc:
+			if b == '\\' {
+				if text, ok := decodeOSC52(s.buf); ok {
+					texts = append(texts, text)
+				}
+				s.state = oscGround
+			} else if b == ']' {
+				// Unterminated sequence followed by a new OSC
+				s.state = oscBody
+				s.buf = s.buf[:0]
+			} else {
+				s.state = oscGround
+			}
+		case oscSkip:
+			switch b {
+			case 0x07:
+				s.state = oscGround
+			case 0x1b:
+				s.state = oscSkipEsc
+			}
+		case oscSkipEsc:
+			switch b {
+			case '\\':
+				s.state = oscGround
+			case ']':
+				s.state = oscBody
+				s.buf = s.buf[:0]
+			case 0x1b:
+				// stay in oscSkipEsc
+			default:
+				s.state = oscGround
+			}
+		}
+	}
+	return texts
+}
+
+// isOSC52Prefix reports whether buf can still become an OSC 52 sequence
+func isOSC52Prefix(buf []byte) bool {
+	if len(buf) <= len(osc52Prefix) {
+		return bytes.HasPrefix(osc52Prefix, buf)
+	}
+	return bytes.HasPrefix(buf, osc52Prefix)
+}
+
+// decodeOSC52 decodes the "52;Pc;Pd" body of a clipboard write
+func decodeOSC52(body []byte) (string, bool) {
+	if !bytes.HasPrefix(body, osc52Prefix) {
+		return "", false
+	}
+	rest := body[len(osc52Prefix):]
+	i := bytes.IndexByte(rest, ';')
+	if i < 0 {
+		return "", false
+	}
+	payload := string(rest[i+1:])
+	if payload == "" || payload == "?" {
+		return "", false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+		if err != nil {
+			return "", false
+		}
+	}
+	return string(decoded), true
+}
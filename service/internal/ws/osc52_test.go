@@ -0,0 +1,109 @@
+package ws
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOSC52Scanner(t *testing.T) {
+	// "aGVsbG8=" is "hello", "d29ybGQ=" is "world"
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string
+	}{
+		{
+			name:   "BEL terminated",
+			chunks: []string{"before\x1b]52;c;aGVsbG8=\x07after"},
+			want:   []string{"hello"},
+		},
+		{
+			name:   "ST terminated",
+			chunks: []string{"\x1b]52;c;aGVsbG8=\x1b\\"},
+			want:   []string{"hello"},
+		},
+		{
+			name:   "empty selection parameter",
+			chunks: []string{"\x1b]52;;aGVsbG8=\x07"},
+			want:   []string{"hello"},
+		},
+		{
+			name:   "unpadded base64",
+			chunks: []string{"\x1b]52;c;aGVsbG8\x07"},
+			want:   []string{"hello"},
+		},
+		{
+			name:   "split across reads",
+			chunks: []string{"\x1b", "]5", "2;c;aGVs", "bG8=\x1b", "\\"},
+			want:   []string{"hello"},
+		},
+		{
+			name:   "several writes",
+			chunks: []string{"\x1b]52;c;aGVsbG8=\x07 \x1b]52;p;d29ybGQ=\x1b\\"},
+			want:   []string{"hello", "world"},
+		},
+		{
+			name:   "query",
+			chunks: []string{"\x1b]52;c;?\x07"},
+		},
+		{
+			name:   "other OSC",
+			chunks: []string{"\x1b]0;title;aGVsbG8=\x07\x1b]520;c;aGVsbG8=\x07"},
+		},
+		{
+			name:   "write after another OSC",
+			chunks: []string{"\x1b]2;title\x1b\\\x1b]52;c;d29ybGQ=\x07"},
+			want:   []string{"world"},
+		},
+		{
+			name:   "unterminated sequence followed by a write",
+			chunks: []string{"\x1b]52;c;aGVs\x1b]52;c;d29ybGQ=\x07"},
+			want:   []string{"world"},
+		},
+		{
+			name:   "invalid base64",
+			chunks: []string{"\x1b]52;c;!!!\x07"},
+		},
+		{
+			name:   "missing selection parameter",
+			chunks: []string{"\x1b]52aGVsbG8=\x07"},
+		},
+		{
+			name:   "too large",
+			chunks: []string{"\x1b]52;c;" + strings.Repeat("A", MaxClipboardBytes) + "\x07\x1b]52;c;d29ybGQ=\x07"},
+			want:   []string{"world"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s osc52Scanner
+			var got []string
+			for _, chunk := range tt.chunks {
+				got = append(got, s.Scan([]byte(chunk))...)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseClipboardPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ClipboardPolicy
+		wantErr bool
+	}{
+		{"off", ClipboardOff, false},
+		{"Writable", ClipboardWritable, false},
+		{"ON", ClipboardOn, false},
+		{"always", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseClipboardPolicy(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseClipboardPolicy(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
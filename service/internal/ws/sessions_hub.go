@@ -2,20 +2,38 @@
 package ws
 
 import (
+	"bytes"
 	"encoding/json"
 	"log"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/ibrahim/remote-vibecode/internal/agent"
+	"github.com/ibrahim/remote-vibecode/internal/procinfo"
 )
 
-// SessionHub broadcasts session updates to all connected clients
+// KeepaliveInterval is the longest time between two broadcasts. A session
+// list that did not change is only sent again after this long, so that
+// followers, e.g. federated servers, can tell that the server is alive.
+const KeepaliveInterval = 10 * time.Second
+
+// SessionHub broadcasts session updates to all connected clients. Sessions
+// of federated peer servers are merged into every broadcast.
 type SessionHub struct {
 	clients    map[*SessionClient]bool
 	broadcast  chan []byte
 	register   chan *SessionClient
 	unregister chan *SessionClient
 	mu         sync.RWMutex
+
+	peersMu sync.RWMutex
+	peers   map[string][]SessionInfo // peer name -> its sessions, tagged with Host
+
+	lastMu   sync.Mutex
+	last     []byte    // the last broadcast
+	lastSent time.Time // when it was sent
 }
 
 // SessionClient represents a WebSocket client connected for session updates
@@ -29,6 +47,7 @@ type SessionClient struct {
 func NewSessionHub() *SessionHub {
 	hub := &SessionHub{
 		clients:    make(map[*SessionClient]bool),
+		peers:      make(map[string][]SessionInfo),
 		broadcast:  make(chan []byte, 256),
 		register:   make(chan *SessionClient),
 		unregister: make(chan *SessionClient),
@@ -82,27 +101,101 @@ func (h *SessionHub) Unregister(client *SessionClient) {
 	h.unregister <- client
 }
 
-// BroadcastSessions sends session list to all connected clients
+// SetPeerSessions replaces the sessions shown for a peer server; nil
+// removes the peer. They are sent with the next broadcast.
+func (h *SessionHub) SetPeerSessions(peer string, sessions []SessionInfo) {
+	h.peersMu.Lock()
+	defer h.peersMu.Unlock()
+	if sessions == nil {
+		delete(h.peers, peer)
+		return
+	}
+	tagged := make([]SessionInfo, len(sessions))
+	for i, s := range sessions {
+		s.Host = peer
+		tagged[i] = s
+	}
+	h.peers[peer] = tagged
+}
+
+// WithPeers returns the local sessions followed by those of all peers
+func (h *SessionHub) WithPeers(local []SessionInfo) []SessionInfo {
+	h.peersMu.RLock()
+	defer h.peersMu.RUnlock()
+	if len(h.peers) == 0 {
+		return local
+	}
+	names := make([]string, 0, len(h.peers))
+	for name := range h.peers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	all := append([]SessionInfo{}, local...)
+	for _, name := range names {
+		all = append(all, h.peers[name]...)
+	}
+	return all
+}
+
+// BroadcastSessions sends the local session list, merged with the peers'
+// sessions, to all connected clients. A list that did not change since the
+// last broadcast is skipped unless KeepaliveInterval has passed.
 func (h *SessionHub) BroadcastSessions(sessions []SessionInfo) {
 	data, err := json.Marshal(map[string]interface{}{
 		"type":     "sessions",
-		"sessions": sessions,
+		"sessions": h.WithPeers(sessions),
 	})
 	if err != nil {
 		log.Printf("Failed to marshal sessions: %v", err)
 		return
 	}
 
+	h.lastMu.Lock()
+	if bytes.Equal(data, h.last) && time.Since(h.lastSent) < KeepaliveInterval {
+		h.lastMu.Unlock()
+		return
+	}
+	h.last = data
+	h.lastSent = time.Now()
+	h.lastMu.Unlock()
+
 	h.broadcast <- data
 }
 
 // SessionInfo represents session information for broadcasting
 type SessionInfo struct {
-	ID          string `json:"id"`
-	SessionName string `json:"session_name"`
-	CreatedAt   int64  `json:"created_at"`
-	LastCapture int64  `json:"last_capture"`
-	Writable    bool   `json:"writable"`
+	ID          string          `json:"id"`
+	SessionName string          `json:"session_name"`
+	Host        string          `json:"host,omitempty"` // the peer server running it; empty for local sessions
+	CreatedAt   int64           `json:"created_at"`
+	LastCapture int64           `json:"last_capture"` // last output, unix seconds
+	LastInput   int64           `json:"last_input"`   // last input, unix seconds
+	Status      string          `json:"status"`       // "attached" while web terminals are connected
+	State       string          `json:"state"`        // "busy", "awaiting_input" or "idle"
+	Viewers     int             `json:"viewers"`
+	Writable    bool            `json:"writable"`
+	Windows     []WindowInfo    `json:"windows"`
+	Agent       string          `json:"agent,omitempty"`
+	Decision    *agent.Decision `json:"decision,omitempty"`
+}
+
+// WindowInfo represents a tmux window for broadcasting
+type WindowInfo struct {
+	ID     string     `json:"id"`
+	Index  int        `json:"index"`
+	Name   string     `json:"name"`
+	Active bool       `json:"active"`
+	Panes  []PaneInfo `json:"panes"`
+}
+
+// PaneInfo represents a tmux pane for broadcasting
+type PaneInfo struct {
+	ID      string              `json:"id"`
+	Index   int                 `json:"index"`
+	Active  bool                `json:"active"`
+	Command string              `json:"command"`
+	PID     int                 `json:"pid"`
+	Stats   *procinfo.PaneStats `json:"stats,omitempty"`
 }
 
 // ReadPump handles messages from the WebSocket connection
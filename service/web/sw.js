@@ -0,0 +1,32 @@
+// Service worker for rvc Web Push notifications
+
+self.addEventListener('push', (event) => {
+    let data = {};
+    try {
+        data = event.data ? event.data.json() : {};
+    } catch (e) {
+        data = { title: event.data ? event.data.text() : 'rvc' };
+    }
+
+    const title = data.title || 'rvc';
+    event.waitUntil(self.registration.showNotification(title, {
+        body: data.body || '',
+        tag: data.session ? `rvc-${data.session}` : undefined,
+        renotify: !!data.session,
+        data: { url: data.url || '/' }
+    }));
+});
+
+self.addEventListener('notificationclick', (event) => {
+    event.notification.close();
+    const url = (event.notification.data && event.notification.data.url) || '/';
+
+    event.waitUntil(clients.matchAll({ type: 'window', includeUncontrolled: true }).then((windowClients) => {
+        for (const client of windowClients) {
+            if ('focus' in client) {
+                return client.focus();
+            }
+        }
+        return clients.openWindow(url);
+    }));
+});
@@ -13,12 +13,30 @@ const GOTTY_INPUT = '1';
 const GOTTY_PING = '2';
 const GOTTY_PONG = '3';
 const GOTTY_RESIZE = '4';
+const GOTTY_CLIPBOARD = '5';
+
+// Labels for the activity states reported by the server
+const STATE_LABELS = {
+    decision: 'needs decision',
+    busy: 'busy',
+    awaiting_input: 'waiting',
+    idle: 'idle'
+};
 
 // Sessions WebSocket for real-time updates
 let sessionsWs = null;
 
+// Dashboard preferences from the server config (ui: section)
+let uiPrefs = {
+    font_size: 14,
+    font_family: 'SF Mono, Monaco, Consolas, monospace',
+    scrollback: 1000
+};
+
 // Initialize
 async function init() {
+    await loadUIPreferences();
+
     // Initial load via REST API
     await loadSessions();
 
@@ -26,6 +44,18 @@ async function init() {
     connectSessionsWebSocket();
 }
 
+// Load dashboard preferences, keeping the defaults if the request fails
+async function loadUIPreferences() {
+    try {
+        const resp = await fetch('/api/v1/ui/preferences');
+        if (resp.ok) {
+            uiPrefs = { ...uiPrefs, ...(await resp.json()) };
+        }
+    } catch (e) {
+        console.error('Failed to load UI preferences:', e);
+    }
+}
+
 // Connect to sessions WebSocket for real-time updates
 function connectSessionsWebSocket() {
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
@@ -67,12 +97,18 @@ function handleSessionsUpdate(sessionList) {
         newSessions[s.id] = {
             id: s.id,
             name: s.session_name || 'Unknown',
-            created_at: s.last_capture || s.created_at || Date.now() / 1000
+            host: s.host || '',
+            created_at: s.created_at || Date.now() / 1000,
+            state: s.state || 'idle',
+            writable: !!s.writable,
+            process: activePaneStats(s.windows),
+            decision: s.decision || null
         };
     });
 
     sessions = newSessions;
     updateSessionList();
+    renderDecision();
 }
 
 // Load all tmux sessions
@@ -88,7 +124,11 @@ async function loadSessions() {
                 newSessions[s.id] = {
                     id: s.id,
                     name: s.session_name || 'Unknown',
-                    created_at: s.last_capture ? new Date(s.last_capture).getTime() / 1000 : Date.now() / 1000
+                    host: '',
+                    created_at: s.created_at ? new Date(s.created_at).getTime() / 1000 : Date.now() / 1000,
+                    state: s.state || 'idle',
+                    writable: !!s.writable,
+                    process: activePaneStats(s.windows)
                 };
             });
         }
@@ -127,6 +167,7 @@ function updateSessionList() {
         }
 
         const hasUnread = unreadSessions.has(session.id);
+        const state = session.decision ? 'decision' : (session.state || 'idle');
 
         item.innerHTML = `
             <div class="session-item-header">
@@ -134,8 +175,10 @@ function updateSessionList() {
                     ${escapeHtml(session.name)}
                     ${hasUnread ? '<span class="unread-badge"></span>' : ''}
                 </div>
+                <div class="session-status status-${escapeHtml(state)}">${escapeHtml(STATE_LABELS[state] || state)}</div>
             </div>
-            <div class="session-item-id">${escapeHtml(session.id.substring(0, 8))}</div>
+            <div class="session-item-id">${session.host ? `<span class="session-host">${escapeHtml(session.host)}</span> · ` : ''}${escapeHtml(session.id.substring(0, 8))}</div>
+            ${session.process ? `<div class="session-item-process">${escapeHtml(formatPaneStats(session.process))}</div>` : ''}
         `;
 
         item.onclick = () => selectSession(session.id);
@@ -143,6 +186,24 @@ function updateSessionList() {
     });
 }
 
+// Returns the process stats of the active pane in the active window, if sampled
+function activePaneStats(windows) {
+    const win = (windows || []).find(w => w.active);
+    const pane = win && (win.panes || []).find(p => p.active);
+    return pane && pane.stats ? pane.stats : null;
+}
+
+// Path prefix of a session's server: sessions of federated peers are reached
+// through this server's proxy
+function sessionBase(session) {
+    return session.host ? `/peers/${encodeURIComponent(session.host)}` : '';
+}
+
+function formatPaneStats(stats) {
+    const mb = Math.round(stats.rss / (1024 * 1024));
+    return `${stats.foreground} · ${stats.cpu_percent.toFixed(0)}% CPU · ${mb} MB`;
+}
+
 // Select a tmux session
 function selectSession(sessionId) {
     currentSessionId = sessionId;
@@ -163,6 +224,9 @@ function selectSession(sessionId) {
         }
     });
 
+    loadQuickActions(session);
+    renderDecision();
+
     // Create or show terminal for this session
     if (terminals[sessionId]) {
         // Show existing terminal
@@ -187,8 +251,8 @@ function createTerminalForSession(sessionId, tmuxSessionName) {
     // Create xterm.js instance
     const term = new Terminal({
         cursorBlink: true,
-        fontSize: 14,
-        fontFamily: 'SF Mono, Monaco, Consolas, monospace',
+        fontSize: uiPrefs.font_size,
+        fontFamily: uiPrefs.font_family,
         theme: {
             background: '#000000',
             foreground: '#ffffff',
@@ -212,7 +276,7 @@ function createTerminalForSession(sessionId, tmuxSessionName) {
             brightWhite: '#ffffff',
         },
         allowProposedApi: true,
-        scrollback: 1000,
+        scrollback: uiPrefs.scrollback,
     });
 
     const fitAddon = new FitAddon.FitAddon();
@@ -286,7 +350,8 @@ function sendResize(sessionId) {
 function connectGotty(sessionId, tmuxSessionName) {
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
     // Use the gotty endpoint with tmux session name
-    const wsUrl = `${protocol}//${window.location.host}/gotty/${encodeURIComponent(tmuxSessionName)}`;
+    const base = sessions[sessionId] ? sessionBase(sessions[sessionId]) : '';
+    const wsUrl = `${protocol}//${window.location.host}${base}/gotty/${encodeURIComponent(tmuxSessionName)}`;
 
     const terminal = terminals[sessionId];
     if (!terminal) return;
@@ -350,6 +415,15 @@ function connectGotty(sessionId, tmuxSessionName) {
                 // Server-initiated resize (not typically used)
                 break;
 
+            case GOTTY_CLIPBOARD:
+                // A program in the session copied text with OSC 52
+                try {
+                    copyToClipboard(base64ToUtf8(payload));
+                } catch (e) {
+                    console.error('Failed to decode clipboard message:', e);
+                }
+                break;
+
             default:
                 console.log('Unknown gotty message type:', messageType);
         }
@@ -372,14 +446,245 @@ function connectGotty(sessionId, tmuxSessionName) {
     };
 }
 
+// Load and render the one-tap quick-action buttons for a writable session
+async function loadQuickActions(session) {
+    const bar = document.getElementById('quick-actions');
+    bar.innerHTML = '';
+    bar.style.display = 'none';
+    if (!session.writable) {
+        return;
+    }
+
+    try {
+        const resp = await fetch(`${sessionBase(session)}/api/v1/tmux/sessions/${encodeURIComponent(session.name)}/actions`);
+        const data = await resp.json();
+        if (!resp.ok || currentSessionId !== session.id) {
+            return;
+        }
+
+        (data.actions || []).forEach(action => {
+            const button = document.createElement('button');
+            button.className = 'quick-action-btn';
+            button.textContent = action.label;
+            button.onclick = () => sendQuickAction(session, action.keys);
+            bar.appendChild(button);
+        });
+
+        const paste = document.createElement('button');
+        paste.className = 'quick-action-btn';
+        paste.textContent = 'Paste';
+        paste.onclick = () => pasteFromClipboard(session);
+        bar.appendChild(paste);
+        bar.style.display = 'flex';
+    } catch (e) {
+        console.error('Failed to load quick actions:', e);
+    }
+}
+
+async function sendQuickAction(session, keys) {
+    try {
+        const resp = await fetch(`${sessionBase(session)}/api/v1/tmux/sessions/${encodeURIComponent(session.name)}/keys`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ keys })
+        });
+        if (!resp.ok) {
+            const body = await resp.json().catch(() => ({}));
+            console.error('Failed to send keys:', resp.status);
+            // e.g. the server needs an access token for sending keys
+            showClipboardToast(body.error || 'Failed to send keys');
+        }
+    } catch (e) {
+        console.error('Error sending keys:', e);
+    }
+}
+
+// Shows the prompt an agent is waiting on in the current session, with one
+// button per option
+function renderDecision() {
+    const bar = document.getElementById('decision-bar');
+    const session = sessions[currentSessionId];
+    const decision = session && session.decision;
+    if (!decision) {
+        bar.style.display = 'none';
+        bar.dataset.id = '';
+        return;
+    }
+    if (bar.dataset.id === decision.id) {
+        return;
+    }
+
+    bar.dataset.id = decision.id;
+    bar.innerHTML = '';
+    const prompt = document.createElement('div');
+    prompt.className = 'decision-prompt';
+    prompt.textContent = decision.prompt;
+    bar.appendChild(prompt);
+
+    decision.options.forEach((option, index) => {
+        const button = document.createElement('button');
+        button.className = 'quick-action-btn';
+        button.textContent = option.label;
+        button.disabled = !session.writable;
+        button.onclick = () => answerDecision(session, decision.id, index);
+        bar.appendChild(button);
+    });
+    bar.style.display = 'flex';
+}
+
+async function answerDecision(session, id, option) {
+    try {
+        const resp = await fetch(`${sessionBase(session)}/api/v1/tmux/sessions/${encodeURIComponent(session.name)}/decision`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ id, option })
+        });
+        if (!resp.ok) {
+            console.error('Failed to answer decision:', resp.status);
+        }
+    } catch (e) {
+        console.error('Error answering decision:', e);
+    }
+}
+
+// Clipboard bridging
+// Writes text copied in a session to the browser clipboard. Browsers may refuse
+// without a user gesture, so fall back to a tap-to-copy prompt.
+async function copyToClipboard(text) {
+    try {
+        await navigator.clipboard.writeText(text);
+        showClipboardToast('Copied to clipboard', null);
+    } catch (e) {
+        showClipboardToast('Tap to copy from session', async () => {
+            try {
+                await navigator.clipboard.writeText(text);
+                showClipboardToast('Copied to clipboard', null);
+            } catch (err) {
+                console.error('Clipboard write failed:', err);
+            }
+        });
+    }
+}
+
+function showClipboardToast(message, onTap) {
+    const toast = document.getElementById('clipboard-toast');
+    toast.textContent = message;
+    toast.onclick = onTap;
+    toast.style.cursor = onTap ? 'pointer' : 'default';
+    toast.style.display = 'block';
+    clearTimeout(toast.hideTimer);
+    toast.hideTimer = setTimeout(() => {
+        toast.style.display = 'none';
+    }, onTap ? 10000 : 2000);
+}
+
+// Reads the browser clipboard into a tmux paste buffer and pastes it into the session
+async function pasteFromClipboard(session) {
+    try {
+        const text = await navigator.clipboard.readText();
+        if (!text) {
+            return;
+        }
+
+        const created = await fetch(`${sessionBase(session)}/api/v1/tmux/buffers`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ content: text })
+        });
+        const buffer = await created.json();
+        if (!created.ok) {
+            console.error('Failed to store paste buffer:', buffer.error);
+            return;
+        }
+
+        const resp = await fetch(`${sessionBase(session)}/api/v1/tmux/sessions/${encodeURIComponent(session.name)}/paste`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ buffer: buffer.name })
+        });
+        if (!resp.ok) {
+            console.error('Failed to paste buffer:', resp.status);
+        }
+    } catch (e) {
+        console.error('Error pasting from clipboard:', e);
+    }
+}
+
+// Web Push notifications
+// Registers the service worker and subscribes this browser to session events
+async function enableNotifications() {
+    const button = document.getElementById('notifyToggle');
+    try {
+        const permission = await Notification.requestPermission();
+        if (permission !== 'granted') {
+            console.warn('Notification permission not granted');
+            return;
+        }
+
+        const registration = await navigator.serviceWorker.register('/sw.js');
+        const keyResp = await fetch('/api/v1/push/vapid-public-key');
+        const keyData = await keyResp.json();
+
+        const subscription = await registration.pushManager.subscribe({
+            userVisibleOnly: true,
+            applicationServerKey: base64UrlToUint8Array(keyData.public_key)
+        });
+
+        const resp = await fetch('/api/v1/push/subscriptions', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(subscription.toJSON())
+        });
+        if (!resp.ok) {
+            throw new Error(`subscribe failed: ${resp.status}`);
+        }
+
+        button.textContent = 'Notifications enabled';
+        button.disabled = true;
+    } catch (e) {
+        console.error('Failed to enable notifications:', e);
+    }
+}
+
+// Show the notification toggle when the browser supports Web Push
+async function initNotifications() {
+    if (!('serviceWorker' in navigator) || !('PushManager' in window)) {
+        return;
+    }
+
+    const button = document.getElementById('notifyToggle');
+    button.style.display = 'block';
+    button.onclick = enableNotifications;
+
+    const registration = await navigator.serviceWorker.getRegistration('/');
+    if (registration && await registration.pushManager.getSubscription()) {
+        button.textContent = 'Notifications enabled';
+        button.disabled = true;
+    }
+}
+
+function base64UrlToUint8Array(base64Url) {
+    const padding = '='.repeat((4 - base64Url.length % 4) % 4);
+    const base64 = (base64Url + padding).replace(/-/g, '+').replace(/_/g, '/');
+    const raw = atob(base64);
+    return Uint8Array.from(raw, c => c.charCodeAt(0));
+}
+
 // Expose selectSession to window for mobile sidebar functionality
 window.selectSession = selectSession;
 
 // Auto-connect to first available session
 window.addEventListener('DOMContentLoaded', () => {
+    initNotifications();
     init().then(() => {
         const sessionArray = Object.values(sessions);
-        if (sessionArray.length > 0) {
+        // ?session=<name> opens that session, e.g. from rvc open or a login link
+        const requested = new URLSearchParams(window.location.search).get('session');
+        const linked = sessionArray.find(s => s.name === requested && !s.host) ||
+            sessionArray.find(s => s.name === requested);
+        if (linked) {
+            selectSession(linked.id);
+        } else if (sessionArray.length > 0) {
             selectSession(sessionArray[0].id);
         } else {
             document.getElementById('terminal-container').innerHTML =